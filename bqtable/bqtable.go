@@ -0,0 +1,20 @@
+// Package bqtable has the interfaces implemented by the structs generated by bqschema-gen-go with -helper-interfaces.
+// The generated file imports it to assert the structs implement them, e.g. `var _ bqtable.Table = Comments{}`.
+package bqtable
+
+// Table is the struct of the BigQuery table.
+type Table interface {
+	// TableName returns the ID of the BigQuery table.
+	TableName() string
+}
+
+// Identity is the struct of the BigQuery table that has the IDs of its project and dataset, generated with -identity-methods.
+type Identity interface {
+	Table
+	// ProjectID returns the ID of the BigQuery project.
+	ProjectID() string
+	// DatasetID returns the ID of the BigQuery dataset.
+	DatasetID() string
+	// FullID returns the fully-qualified ID of the BigQuery table, e.g. "project:dataset.table".
+	FullID() string
+}
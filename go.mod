@@ -5,6 +5,8 @@ go 1.15
 require (
 	cloud.google.com/go v0.71.0
 	cloud.google.com/go/bigquery v1.13.0
+	golang.org/x/oauth2 v0.0.0-20200902213428-5d25da1a8d43
+	golang.org/x/term v0.0.0-20201117132131-f5c789dd3221
 	golang.org/x/tools v0.0.0-20201103235415-b653051172e4
 	google.golang.org/api v0.34.0
 )
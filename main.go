@@ -3,49 +3,583 @@
 package main
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"go/ast"
 	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io"
 	"io/ioutil"
 	"log"
 	"math/big"
+	"math/rand"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"text/tabwriter"
+	"text/template"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"cloud.google.com/go/bigquery"
 	"cloud.google.com/go/civil"
+	"golang.org/x/oauth2"
+	"golang.org/x/term"
 	"golang.org/x/tools/imports"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
 )
 
 const (
 	// optName
-	optNameProjectID  = "project"
-	optNameDataset    = "dataset"
-	optNameKeyFile    = "keyfile"
-	optNameOutputFile = "output"
+	optNameProjectID           = "project"
+	optNameBillingProjectID    = "billing-project"
+	optNameDataset             = "dataset"
+	optNameKeyFile             = "keyfile"
+	optNameOutputFile          = "output"
+	optNameNullable            = "nullable"
+	optNameLineEnding          = "line-ending"
+	optNameConsoleURL          = "console-url"
+	optNameSkipForbidden       = "skip-forbidden"
+	optNameOutputDir           = "output-dir"
+	optNameTypeMap             = "type-map"
+	optNameAccessToken         = "access-token"
+	optNameConstants           = "constants"
+	optNameNullableRecords     = "nullable-records"
+	optNameNullableTemporal    = "nullable-temporal"
+	optNameAllDatasets         = "all-datasets"
+	optNameExcludeDatasets     = "exclude-datasets"
+	optNameDeprecatedMarker    = "deprecated-marker"
+	optNameSchemaFile          = "schema-file"
+	optNameRequiredOnly        = "required-only"
+	optNameFromRow             = "from-row"
+	optNameEmbed               = "embed"
+	optNameDiff                = "diff"
+	optNameSelectAll           = "select-all"
+	optNameScopes              = "scopes"
+	optNameGetters             = "getters"
+	optNameOutputTemplate      = "output-template"
+	optNameStrict              = "strict"
+	optNameStringer            = "stringer"
+	optNamePolicyTagComment    = "policy-tag-comment"
+	optNamePolicyTagStructTag  = "policy-tag-struct-tag"
+	optNamePrune               = "prune"
+	optNameTemplate            = "template"
+	optNameEnvFile             = "env-file"
+	optNameFileMode            = "file-mode"
+	optNameInformationSchema   = "information-schema"
+	optNameEmitCompileTest     = "emit-compile-test"
+	optNameDatasetKeyFiles     = "dataset-key-files"
+	optNameNoDescription       = "no-description"
+	optNameLossyTypes          = "lossy-types"
+	optNameInit                = "init"
+	optNameForce               = "force"
+	optNameAdded               = "added"
+	optNameLogJSON             = "log-json"
+	optNameHelperInterfaces    = "helper-interfaces"
+	optNameHelperImport        = "helper-import"
+	optNameTablesFrom          = "tables-from"
+	optNameSkipExisting        = "skip-existing"
+	optNameFlattenRecords      = "flatten-records"
+	optNameListTypes           = "list-types"
+	optNameTagKey              = "tag-key"
+	optNameValidate            = "validate"
+	optNameDatasetPattern      = "dataset-pattern"
+	optNamePackage             = "package"
+	optNamePartialOnInterrupt  = "partial-on-interrupt"
+	optNameTagDirective        = "tag-directive"
+	optNameIdentityMethods     = "identity-methods"
+	optNameExcludeColumns      = "exclude-columns"
+	optNameNoProgress          = "no-progress"
+	optNameSchemaVersion       = "schema-version"
+	optNameScaffold            = "scaffold"
+	optNameAsOf                = "as-of"
+	optNamePartitioning        = "partitioning"
+	optNamePartitioningComment = "partitioning-comment"
+	optNameClusteringComment   = "clustering-comment"
+	optNameTableTypeComment    = "table-type-comment"
+	optNameOutputFormat        = "output-format"
+	optNameFieldsComment       = "fields-comment"
+	optNameOutputZip           = "output-zip"
+	optNameCommentWidth        = "comment-width"
+	optNameQueryMethod         = "query-method"
+	optNameQuotaProject        = "quota-project"
+	optNameColumnsVar          = "columns-var"
+	optNameMerge               = "merge"
+	optNameTrace               = "trace"
+	optNameBigQueryNameMethod  = "bigquery-name-method"
+	optNameWildcard            = "wildcard"
+	optNameReport              = "report"
+	optNameNoTableNameMethod   = "no-table-name-method"
 	// envName
 	envNameGoogleApplicationCredentials = "GOOGLE_APPLICATION_CREDENTIALS"
+	envNameGoogleOAuthAccessToken       = "GOOGLE_OAUTH_ACCESS_TOKEN"
 	envNameGCloudProjectID              = "GCLOUD_PROJECT_ID"
+	envNameGCloudBillingProjectID       = "GCLOUD_BILLING_PROJECT_ID"
 	envNameBigQueryDataset              = "BIGQUERY_DATASET"
 	envNameOutputFile                   = "OUTPUT_FILE"
+	envNameGoogleCloudQuotaProject      = "GOOGLE_CLOUD_QUOTA_PROJECT"
 	// defaultValue
-	defaultValueEmpty      = ""
-	defaultValueOutputFile = "bqschema.generated.go"
+	defaultValueEmpty            = ""
+	defaultValueOutputFile       = "bqschema.generated.go"
+	defaultValueOutputFileJSON   = "bqschema.json"
+	defaultValueOutputFormat     = outputFormatGo
+	defaultValueNullable         = nullableModeNone
+	defaultValueLineEnding       = lineEndingLF
+	defaultValueNullableRecords  = nullableRecordsValue
+	defaultValueNullableTemporal = nullableTemporalValue
+	defaultValueFileMode         = "0644"
+	defaultValueLossyTypes       = string(bigquery.GeographyFieldType)
+	defaultValueHelperImport     = "github.com/djeeno/bqschema-gen-go/bqtable"
+	defaultValueTagKey           = "bigquery"
+	defaultValuePackage          = "bqschema"
+	// generatedCode ref. https://golang.org/s/generatedcode
+	generatedCodeHeader = "// Code generated by go run github.com/djeeno/bqschema-gen-go; DO NOT EDIT."
+	partialOutputHeader = "// PARTIAL OUTPUT"
+	scaffoldCodeHeader  = "// Initially generated by go run github.com/djeeno/bqschema-gen-go."
+	// bigqueryImportPath is the import path of the package of the client, that the generated methods, e.g. Query and FromRow, refer as bigquery.
+	bigqueryImportPath = "cloud.google.com/go/bigquery"
+	// wildcardTableSuffixColumn is the pseudo-column of the wildcard tables, that has the suffix of the table ID of the shard. See applyWildcards.
+	wildcardTableSuffixColumn = "_TABLE_SUFFIX"
+	// mergeMarker is the prefix of the comments that delimit the code of each table by the table ID. See mergeGeneratedCode.
+	mergeMarkerBegin = "// bqschema-gen-go:begin "
+	mergeMarkerEnd   = "// bqschema-gen-go:end "
+	// filePath
+	filePathStdout       = "-"
+	filePathStdin        = "-"
+	generatedFileSuffix  = ".generated.go"
+	schemaJSONFileSuffix = ".json"
+	initFileName         = "generate.go"
+	generateDirective    = "//go:generate go run github.com/djeeno/bqschema-gen-go"
+	// nullableMode
+	nullableModeNone = "none"
+	nullableModeNull = "null"
+	// nullableRecords
+	nullableRecordsValue   = "value"
+	nullableRecordsPointer = "pointer"
+	// nullableTemporal
+	nullableTemporalValue   = "value"
+	nullableTemporalPointer = "pointer"
+	// outputFormat
+	outputFormatGo   = "go"
+	outputFormatJSON = "json"
+	// lineEnding
+	lineEndingLF   = "lf"
+	lineEndingCRLF = "crlf"
+	// tagOption
+	tagOptionNullable = "nullable"
 )
 
 var (
 	// optValue
-	optValueProjectID  = flag.String(optNameProjectID, defaultValueEmpty, "")
-	optValueDataset    = flag.String(optNameDataset, defaultValueEmpty, "")
-	optValueKeyFile    = flag.String(optNameKeyFile, defaultValueEmpty, "path to service account json key file")
-	optValueOutputPath = flag.String(optNameOutputFile, defaultValueEmpty, "path to output the generated code")
+	optValueProjectID           = flag.String(optNameProjectID, defaultValueEmpty, "project ID of the dataset")
+	optValueBillingProjectID    = flag.String(optNameBillingProjectID, defaultValueEmpty, "project ID to run query jobs in, and to be billed for them. default is -"+optNameProjectID)
+	optValueDataset             = flag.String(optNameDataset, defaultValueEmpty, "dataset ID, or \"project.dataset\" of the project other than -"+optNameProjectID+". comma-separated dataset IDs generate multiple datasets with -"+optNameOutputDir+", e.g. \"dev.analytics,prod.analytics\"")
+	optValueKeyFile             = flag.String(optNameKeyFile, defaultValueEmpty, "path to service account json key file, or user credentials file of `gcloud auth application-default login`. default is the application default credentials file of gcloud if it exists")
+	optValueOutputPath          = flag.String(optNameOutputFile, defaultValueEmpty, "path to output the generated code. \""+filePathStdout+"\" outputs to stdout")
+	optValueOutputDir           = flag.String(optNameOutputDir, defaultValueEmpty, "directory to output the generated code of each dataset to <dataset>"+generatedFileSuffix+". -"+optNameOutputFile+" is ignored")
+	optValueLineEnding          = flag.String(optNameLineEnding, defaultValueLineEnding, "line ending of the generated code. \""+lineEndingLF+"\" or \""+lineEndingCRLF+"\"")
+	optValueNullable            = flag.String(optNameNullable, defaultValueNullable, "Go type for NULLABLE columns. \""+nullableModeNone+"\": plain Go types, \""+nullableModeNull+"\": bigquery.NullXXX types")
+	optValueConsoleURL          = flag.Bool(optNameConsoleURL, false, "add the BigQuery console URL of the table to the struct comment")
+	optValueSkipForbidden       = flag.Bool(optNameSkipForbidden, false, "skip the rest of the listing of the tables that returns 403 Forbidden, instead of exiting with an error. the tables that fail, including the tables of -diff, are always warned and skipped. -diff and -prune fail if the listing is cut short")
+	optValueTypeMap             = flag.String(optNameTypeMap, defaultValueEmpty, "comma-separated \"table.column=importpath.Type\" or \"BIGQUERYTYPE=importpath.Type\" to override the Go type of the columns, e.g. \"events.created_at=cloud.google.com/go/civil.DateTime,INTEGER=int\"")
+	optValueAccessToken         = flag.String(optNameAccessToken, defaultValueEmpty, "OAuth 2.0 access token with BigQuery read scope. used instead of -"+optNameKeyFile)
+	optValueConstants           = flag.Bool(optNameConstants, false, "add the ProjectID and DatasetID constants")
+	optValueNullableRecords     = flag.String(optNameNullableRecords, defaultValueNullableRecords, "Go type for NULLABLE RECORD columns. \""+nullableRecordsValue+"\": struct, \""+nullableRecordsPointer+"\": pointer to struct")
+	optValueNullableTemporal    = flag.String(optNameNullableTemporal, defaultValueNullableTemporal, "Go type for NULLABLE TIMESTAMP, DATETIME, DATE and TIME columns. \""+nullableTemporalValue+"\": the type of -"+optNameNullable+", \""+nullableTemporalPointer+"\": pointer to time.Time or the civil type, e.g. *time.Time, that requires -"+optNameFromRow+". the REQUIRED columns are the value types")
+	optValueAllDatasets         = flag.Bool(optNameAllDatasets, false, "generate all datasets in the project. -"+optNameDataset+" is ignored")
+	optValueExcludeDatasets     = flag.String(optNameExcludeDatasets, defaultValueEmpty, "comma-separated dataset IDs to exclude from -"+optNameAllDatasets+" or -"+optNameDatasetPattern)
+	optValueDeprecatedMarker    = flag.String(optNameDeprecatedMarker, defaultValueEmpty, "add the \"Deprecated:\" comment to the fields whose column description contains this marker, e.g. \"[DEPRECATED]\"")
+	optValueSchemaFile          = flag.String(optNameSchemaFile, defaultValueEmpty, "path to BigQuery schema JSON file (e.g. the output of `bq show --schema`) to generate from, instead of BigQuery. the table ID is the file name without the extension")
+	optValueRequiredOnly        = flag.Bool(optNameRequiredOnly, false, "generate the fields of the REQUIRED columns only")
+	optValueFromRow             = flag.Bool(optNameFromRow, false, "add the FromRow method that assigns the fields from the row read as map[string]bigquery.Value")
+	optValueEmbed               = flag.String(optNameEmbed, defaultValueEmpty, "\"importpath.Type\" or \"*importpath.Type\" to embed as the first field of the table structs, e.g. \"example.com/models.BaseModel\"")
+	optValueDiff                = flag.Bool(optNameDiff, false, "report the schema drift between the generated files (-output or -output-dir) and the live tables instead of generating, and exit non-zero if found")
+	optValueSelectAll           = flag.Bool(optNameSelectAll, false, "add the constant of the query that selects the columns of each table, e.g. \"TableSelectAll\"")
+	optValueScopes              = flag.String(optNameScopes, defaultValueEmpty, "comma-separated OAuth 2.0 scopes of the client, e.g. \"https://www.googleapis.com/auth/bigquery.readonly\". Default is the scopes of the BigQuery client. ignored with -"+optNameAccessToken)
+	optValueGetters             = flag.Bool(optNameGetters, false, "add the getters of the fields of each table struct, and the interface of them, e.g. \"TableGetter\"")
+	optValueOutputTemplate      = flag.String(optNameOutputTemplate, defaultValueEmpty, "path template of the file of each table, with the placeholders {project}, {dataset} and {table}, e.g. \"models/{table}_model.go\"")
+	optValueStrict              = flag.Bool(optNameStrict, false, "skip the tables whose structs have no fields after filtering the columns, instead of generating the empty structs")
+	optValueStringer            = flag.Bool(optNameStringer, false, "add the String method that returns the field names and values of each struct")
+	optValuePolicyTagComment    = flag.String(optNamePolicyTagComment, defaultValueEmpty, "comment to add to the fields whose columns have the policy tags, e.g. \"PII\"")
+	optValuePolicyTagStructTag  = flag.String(optNamePolicyTagStructTag, defaultValueEmpty, "struct tag to add to the fields whose columns have the policy tags, e.g. `pii:\"true\"`")
+	optValuePrune               = flag.Bool(optNamePrune, false, "remove the generated files that match -output-template but are not of the current tables")
+	optValueTemplate            = flag.String(optNameTemplate, defaultValueEmpty, "text/template file to generate each struct from, instead of the default Go struct. The output is not formatted, so that it may not be Go")
+	optValueEnvFile             = flag.String(optNameEnvFile, defaultValueEmpty, "file of KEY=VALUE lines to set the environment variables that are not set yet, e.g. \".env\"")
+	optValueFileMode            = flag.String(optNameFileMode, defaultValueFileMode, "octal permission of the generated files, e.g. \"0600\"")
+	optValueInformationSchema   = flag.Bool(optNameInformationSchema, false, "fetch the schemas of the tables by querying INFORMATION_SCHEMA of the dataset once, instead of the metadata of each table. It falls back to the metadata if the query fails")
+	optValueEmitCompileTest     = flag.Bool(optNameEmitCompileTest, false, "write the companion \"_test.go\" file of each generated file, that references the generated types, methods and constants so that `go test` fails if the generated code does not compile")
+	optValueDatasetKeyFiles     = flag.String(optNameDatasetKeyFiles, defaultValueEmpty, "comma-separated \"dataset=keyfile\" or \"project.dataset=keyfile\" to fetch the datasets by the clients of the service account key files, instead of the default credentials, e.g. \"analytics=analytics.json,prod.billing=billing.json\"")
+	optValueNoDescription       = flag.Bool(optNameNoDescription, false, "omit the descriptions of the tables and the columns from the comments of the structs")
+	optValueLossyTypes          = flag.String(optNameLossyTypes, defaultValueLossyTypes, "comma-separated BigQuery types whose Go types lose the semantics of the columns, to comment the representation on the fields, e.g. \"GEOGRAPHY,NUMERIC\"")
+	optValueInit                = flag.Bool(optNameInit, false, "write \"generate.go\" that has the go:generate directive of -project, -dataset and -output, into the directory of -output, or of -output-dir, instead of generating")
+	optValueForce               = flag.Bool(optNameForce, false, "always overwrite the existing files, including the file of -"+optNameInit+", that is not overwritten by default")
+	optValueAdded               = flag.String(optNameAdded, defaultValueEmpty, "file to write the \"<Table>Added\" structs of the columns added since the generated file -output, instead of generating, e.g. \"added.go\"")
+	optValueLogJSON             = flag.Bool(optNameLogJSON, false, "write the logs to stderr as the JSON lines of \"time\", \"level\", \"table\" and \"message\", instead of the text")
+	optValueHelperInterfaces    = flag.Bool(optNameHelperInterfaces, false, "assert the table structs implement the interfaces of the helper package -helper-import, e.g. bqtable.Table")
+	optValueHelperImport        = flag.String(optNameHelperImport, defaultValueHelperImport, "import path of the helper package of -helper-interfaces, for the forks or the vendoring")
+	optValueTablesFrom          = flag.String(optNameTablesFrom, defaultValueEmpty, "file of the newline-separated fully-qualified table IDs to generate instead of the tables of the datasets, e.g. \"project.dataset.table\". \""+filePathStdin+"\" reads stdin. blank lines and lines starting with \"#\" are ignored")
+	optValueSkipExisting        = flag.Bool(optNameSkipExisting, false, "do not write the generated files that already exist, and do not fetch their tables. the existing files are overwritten by default")
+	optValueFlattenRecords      = flag.Bool(optNameFlattenRecords, false, "flatten the REPEATED RECORD columns that have only one non-RECORD field into the slices of the field type, e.g. ARRAY<STRUCT<value INT64>> to []int64. requires -"+optNameFromRow+", because RowIterator.Next cannot load them")
+	optValueListTypes           = flag.Bool(optNameListTypes, false, "print the BigQuery field types and their Go types, and the field types not supported, then exit")
+	optValueTagKey              = flag.String(optNameTagKey, defaultValueTagKey, "key of the struct tags of the columns, for the custom loaders that read the other key than bigquery")
+	optValueValidate            = flag.Bool(optNameValidate, false, "after generating, read one sample row of each table into the struct type of the generated fields by the client, and report the tables that fail. it reads the rows of the tables")
+	optValueDatasetPattern      = flag.String(optNameDatasetPattern, defaultValueEmpty, "regular expression of the dataset IDs to generate from the datasets in the project, e.g. \"^analytics_\". -"+optNameDataset+" is ignored, and -"+optNameExcludeDatasets+" is applied")
+	optValuePackage             = flag.String(optNamePackage, defaultValuePackage, "package name of the generated code, e.g. \"models\" for the output directory internal/models. the helper package -"+optNameHelperImport+" must be importable from the output directory")
+	optValuePartialOnInterrupt  = flag.Bool(optNamePartialOnInterrupt, false, "on interrupt (Ctrl-C), write the structs generated so far to the output file with the \""+partialOutputHeader+"\" header, and exit non-zero. not for -"+optNameTemplate+" and -"+optNameOutputTemplate)
+	optValueTagDirective        = flag.String(optNameTagDirective, defaultValueEmpty, "prefix of the struct tag directives in the column descriptions, e.g. \"@tag:\" for \"@tag:validate=required\" that adds the struct tag validate:\"required\" to the field. the directives are stripped from the description comments")
+	optValueIdentityMethods     = flag.Bool(optNameIdentityMethods, false, "add the methods ProjectID, DatasetID and FullID (project:dataset.table) of each table struct")
+	optValueExcludeColumns      = flag.String(optNameExcludeColumns, defaultValueEmpty, "comma-separated column paths table.column to exclude from the structs, or the glob patterns of them, e.g. \"events.payload,*.raw_*\". the nested columns are table.record.column")
+	optValueNoProgress          = flag.Bool(optNameNoProgress, false, "do not print the progress of the tables, e.g. \"[12/300] generating orders\", that is printed to stderr only if it is a terminal")
+	optValueSchemaVersion       = flag.Bool(optNameSchemaVersion, false, "add the constant SchemaVersion, the hash of the column names, types and modes of the generated tables, to detect the schema changes")
+	optValueScaffold            = flag.Bool(optNameScaffold, false, "omit the \"DO NOT EDIT.\" marker and the go:generate directive, and write the comment \""+scaffoldCodeHeader+"\" instead, for the scaffold to edit by hand")
+	optValuePartitioning        = flag.String(optNamePartitioning, defaultValueEmpty, "generate only the tables of the partitioning: \""+partitioningPartitioned+"\", \""+partitioningUnpartitioned+"\", \""+partitioningIngestionTime+"\", \""+partitioningColumn+"\" (time-unit column) or \""+partitioningRange+"\" (integer range). all the tables if empty")
+	optValuePartitioningComment = flag.Bool(optNamePartitioningComment, false, "add the comment of the partitioning of the table, e.g. the partitioning column and the granularity, to the table structs")
+	optValueClusteringComment   = flag.Bool(optNameClusteringComment, false, "add the comment of the clustering columns of the table, e.g. \"Clustered by: customer_id, order_id\", to the table structs")
+	optValueTableTypeComment    = flag.Bool(optNameTableTypeComment, false, "add the comment of the type of the table, e.g. \"Materialized view\", to the structs of the tables but the regular tables")
+	optValueFieldsComment       = flag.Bool(optNameFieldsComment, false, "add the comment of the number of the fields and the short hash of the columns, e.g. \"fields: 12, schema: a1b2c3d4\", to each struct, so that the schema changes are visible in the diff")
+	optValueOutputZip           = flag.String(optNameOutputZip, defaultValueEmpty, "path of the zip archive to write the files of -"+optNameOutputTemplate+" into, instead of the filesystem, e.g. \"models.zip\". the paths in the archive are of -"+optNameOutputTemplate)
+	optValueCommentWidth        = flag.Int(optNameCommentWidth, 0, "maximum width of the comment lines of the structs and the fields, including \"// \" and the indentation, to wrap the long descriptions at, e.g. 120 for the line-length lint. the words longer than it are not split. no wrapping if 0")
+	optValueQueryMethod         = flag.Bool(optNameQueryMethod, false, "add the method Query(client *bigquery.Client) *bigquery.Query of each table struct, that returns the query selecting the columns of the struct from the table")
+	optValueQuotaProject        = flag.String(optNameQuotaProject, defaultValueEmpty, "project to bill the quota of the API requests to, e.g. for the credentials that get \"userProjectMissing\" on listing the tables. or set environment variable "+envNameGoogleCloudQuotaProject+". the quota project of the credentials if empty")
+	optValueColumnsVar          = flag.Bool(optNameColumnsVar, false, "add the variable <Table>Col of each table struct, that has the column names by the field names, e.g. UsersCol.UserID for \"user_id\", to refer to the columns in the queries")
+	optValueMerge               = flag.Bool(optNameMerge, false, "replace only the code of the generated tables, e.g. of -"+optNameTablesFrom+", between the marker comments \""+mergeMarkerBegin+"<table>\" and \""+mergeMarkerEnd+"<table>\" in the existing output file, and keep the code of the other tables. the new tables are appended, and the code is marked")
+	optValueTrace               = flag.Bool(optNameTrace, false, "write the durations of the BigQuery API calls, e.g. of listing the tables and of the metadata of each table, to stderr at the level DEBUG, to find the slow tables")
+	optValueBigQueryNameMethod  = flag.Bool(optNameBigQueryNameMethod, false, "add the method BigQueryName(field string) string of each struct, that returns the name of the BigQuery column of the Go field name, e.g. \"user_id\" for \"UserID\", without reflecting the struct tags")
+	optValueWildcard            = flag.String(optNameWildcard, defaultValueEmpty, "comma-separated prefixes of the sharded tables, e.g. \"events_\" of events_20201101, to generate one struct of each prefix from the union of the schemas of the shards, with the field TableSuffix of the pseudo-column "+wildcardTableSuffixColumn+", for the wildcard queries of `events_*`")
+	optValueReport              = flag.String(optNameReport, defaultValueEmpty, "path of the report of the columns mapped to the fields of the generated structs, with the overridden, lossy and flattened mappings, the excluded columns and the skipped tables, in Markdown if the extension is .md, or in text")
+	optValueOutputFormat        = flag.String(optNameOutputFormat, defaultValueOutputFormat, "format of the output: \""+outputFormatGo+"\" for the Go structs, or \""+outputFormatJSON+"\" for the JSON of the schemas of the tables by the table IDs, in the format of `bq show --schema`. the default output of \""+outputFormatJSON+"\" is \""+defaultValueOutputFileJSON+"\", and <dataset>"+schemaJSONFileSuffix+" in -"+optNameOutputDir)
+	optValueNoTableNameMethod   = flag.Bool(optNameNoTableNameMethod, false, "omit the method TableName() string of each table struct, that returns the table ID, e.g. \"123_events\" of X123_events, for the tables that have the column table_name. cannot be used with -"+optNameHelperInterfaces+", because bqtable.Table has it")
+	optValueAsOf                = flag.String(optNameAsOf, defaultValueEmpty, "RFC 3339 timestamp to generate from the schemas of the tables at the time by time travel (FOR SYSTEM_TIME AS OF), e.g. \"2020-11-01T00:00:00Z\". the tables that time travel is not available for fall back to the current schemas")
 )
 
+// Options is the set of options that controls the generated code.
+type Options struct {
+	// Nullable is the representation of NULLABLE columns. nullableModeNone or nullableModeNull.
+	Nullable string
+	// ConsoleURL adds the BigQuery console URL of the table to the struct comment.
+	ConsoleURL bool
+	// SkipForbidden skips the rest of the listing of the tables that the caller does not have permission to access,
+	// instead of returning an error. The tables that fail, including the tables of Diff, are always warned and skipped.
+	// Diff returns an error if the listing is cut short, because the tables not listed would be reported as removed. See GenerateResult.Truncated.
+	SkipForbidden bool
+	// NameMapper customizes the struct and field names. Default is DefaultNameMapper.
+	NameMapper NameMapper
+	// TypeMap overrides the Go type of the column "table.column" (or "table.record.column") by the Go type "importpath.Type". See parseGoType.
+	// The key may be the BigQuery type instead, e.g. "INTEGER", to override the Go type of the columns of the type, except the NULLABLE columns with nullableModeNull or nullableTemporalPointer.
+	// The Go type is not checked against the column, so that the narrower type is lossy, e.g. "FLOAT=float32" loses the precision of FLOAT, the 64-bit float.
+	TypeMap map[string]string
+	// Template generates the code of each struct from StructTemplateData instead of defaultStructTemplate.
+	// The code is output as is, without the header, the methods, the constants or the formatting, so that it may not be Go, e.g. TypeScript.
+	Template *template.Template
+	// TypeResolver resolves the Go type of the column that is not overridden by TypeMap. See TypeResolver.
+	TypeResolver TypeResolver
+	// NullableRecords is the representation of NULLABLE RECORD columns. nullableRecordsValue or nullableRecordsPointer.
+	NullableRecords string
+	// NullableTemporal is the representation of NULLABLE TIMESTAMP, DATETIME, DATE and TIME columns. nullableTemporalValue or nullableTemporalPointer.
+	// nullableTemporalPointer generates the pointers to the value types, e.g. *time.Time and *civil.Date, instead of Nullable,
+	// and the REQUIRED and the REPEATED columns are the value types as is.
+	// bigquery.RowIterator cannot load the pointers but of the structs, so that the rows must be loaded by FromRow.
+	NullableTemporal string
+	// DatasetPrefix prefixes the struct names with the dataset ID, e.g. "Dataset_Table", to avoid collisions between datasets.
+	DatasetPrefix bool
+	// ProjectPrefix prefixes the struct names with the project ID before DatasetPrefix, e.g. "Project_Dataset_Table", to avoid collisions between projects.
+	ProjectPrefix bool
+	// DeprecatedMarker adds the "Deprecated:" comment to the fields whose column description contains it, e.g. "[DEPRECATED]".
+	DeprecatedMarker string
+	// PolicyTagComment adds the comment to the fields whose columns have the policy tags, e.g. "PII".
+	PolicyTagComment string
+	// PolicyTagStructTag adds the struct tag to the fields whose columns have the policy tags, e.g. `pii:"true"`. See validateStructTag.
+	PolicyTagStructTag string
+	// TagDirective is the prefix of the struct tag directives prefix+key=value in the column descriptions, e.g. "@tag:" for "@tag:validate=required".
+	// The directives add the struct tags to the fields, and are stripped from the descriptions. It is disabled if empty. See parseTagDirectives.
+	TagDirective string
+	// RequiredOnly generates the fields of the REQUIRED columns only.
+	RequiredOnly bool
+	// ExcludeColumns is the column paths "table.column" to exclude from the structs, or the glob patterns of them, e.g. "*.raw_*". See isExcludedColumn.
+	ExcludeColumns []string
+	// Getters adds the getters of the fields of the table structs, and the interface of them, e.g. "TableGetter".
+	Getters bool
+	// Scaffold omits the "DO NOT EDIT." marker and the go:generate directive, and adds scaffoldCodeHeader instead,
+	// so that the generated file is understood as the scaffold to edit by hand. The scaffold is neither pruned nor regenerated by go generate.
+	Scaffold bool
+	// Partitioning generates only the tables of the partitioning, e.g. partitioningColumn, or all the tables if empty. See matchPartitioning.
+	Partitioning string
+	// PartitioningComment adds the comment of the partitioning of the table, e.g. "Partitioning: DAY by column `created_at`.", to the table structs.
+	PartitioningComment bool
+	// ClusteringComment adds the comment of the clustering columns of the table, e.g. "Clustered by: customer_id, order_id", to the table structs.
+	ClusteringComment bool
+	// TableTypeComment adds the comment of the type of the table, e.g. "Materialized view", to the structs of the tables but the regular tables.
+	// See tableTypeComment.
+	TableTypeComment bool
+	// FieldsComment adds the comment of the number of the fields and the short hash of the columns, e.g. "fields: 12, schema: a1b2c3d4",
+	// to each struct. See structFieldsComment.
+	FieldsComment bool
+	// CommentWidth is the maximum width of the comment lines of the structs and the fields, including "// " and the indentation counted as 1 column per tab,
+	// to wrap the comments at the spaces. It does not wrap if 0. See wrapComment.
+	CommentWidth int
+	// AsOf generates the structs of the schemas of the tables at AsOf by time travel, or of the current schemas if zero. See asOfMetadata.
+	AsOf time.Time
+	// SchemaVersion adds the constant SchemaVersion of GenerateResult.SchemaVersion to the generated file.
+	SchemaVersion bool
+	// IdentityMethods adds the methods ProjectID, DatasetID and FullID of the table structs.
+	IdentityMethods bool
+	// NoTableNameMethod omits the method TableName of the table structs, that returns the table ID.
+	// It is added by default, because the struct name may differ from the table ID, e.g. "X123_events" of "123_events". See generateTableNameMethodCode.
+	NoTableNameMethod bool
+	// ColumnsVar adds the variable "<Table>Col" of the table structs, that has the column names by the field names. See generateColumnsVarCode.
+	ColumnsVar bool
+	// QueryMethod adds the method Query of the table structs, that returns *bigquery.Query selecting the columns of the struct from the table.
+	// The generated file imports cloud.google.com/go/bigquery. See generateQueryMethodCode.
+	// It cannot be used with TagKey other than "bigquery", FlattenRecords nor NullableTemporal "pointer", that RowIterator.Next cannot load.
+	QueryMethod bool
+	// BigQueryNameMethod adds the method BigQueryName of the structs, that returns the column name of the field by the Go field name,
+	// so that the column names are available at runtime without reflecting the struct tags. See generateBigQueryNameMethodCode.
+	BigQueryNameMethod bool
+	// Strict skips the tables whose structs have no fields after filtering the columns, instead of generating the empty structs.
+	Strict bool
+	// Stringer adds the String method that returns the field names and values.
+	Stringer bool
+	// FromRow adds the FromRow method that assigns the fields from the row read as map[string]bigquery.Value.
+	FromRow bool
+	// Constants adds the ProjectID and DatasetID constants.
+	Constants bool
+	// SelectAll adds the constant of the query that selects the columns of the table, e.g. "TableSelectAll".
+	SelectAll bool
+	// Embed embeds the type "importpath.Type" or "*importpath.Type" as the first field of the table structs. See parseEmbedType.
+	Embed string
+	// InformationSchema fetches the schemas and the descriptions of the tables by querying INFORMATION_SCHEMA of the dataset once,
+	// instead of the metadata of each table. The query job is run in the project of the client.
+	// The tables that are not in the result, e.g. of the types not supported, or all the tables if the query fails, fall back to the metadata.
+	InformationSchema bool
+	// NoDescription omits the descriptions of the tables from the comments of the structs, and of the columns from FieldContext.
+	// The "Deprecated:" comments of DeprecatedMarker are kept.
+	NoDescription bool
+	// LossyTypes comments the representation on the fields of the columns of the types, whose Go types lose the semantics of the columns,
+	// e.g. "NOTE: GEOGRAPHY stored as string" for GEOGRAPHY. The fields overridden by TypeMap or TypeResolver are not commented.
+	LossyTypes []bigquery.FieldType
+	// TagKey is the key of the struct tags of the columns, or defaultValueTagKey if empty. See validateTagKey.
+	// bigquery.RowIterator reads only the bigquery key, so that the other keys are for the custom loaders.
+	TagKey string
+	// FlattenRecords flattens the REPEATED RECORD columns that have only one field into the slices of the field type,
+	// e.g. ARRAY<STRUCT<value INT64>> to []int64. See isFlattenableRecord.
+	// bigquery.RowIterator cannot load the flattened fields, so that the rows must be loaded by FromRow.
+	FlattenRecords bool
+	// HelperInterfaces asserts the table structs implement the interfaces of the helper package HelperImport, e.g. bqtable.Table.
+	// The helper package is imported only if HelperInterfaces.
+	HelperInterfaces bool
+	// HelperImport is the import path of the helper package, or defaultValueHelperImport if empty.
+	HelperImport string
+	// Package is the package name of the generated code, or defaultValuePackage if empty.
+	Package string
+	// Tables is the table IDs of the dataset to generate, instead of all the tables listed in the dataset. See getTables.
+	Tables []string
+	// Wildcards is the prefixes of the IDs of the sharded tables, e.g. "events_" of "events_20201101", to generate one struct of the wildcard table
+	// of each prefix, e.g. "events_*", from the union of the schemas of the shards, instead of the structs of the shards. See applyWildcards.
+	Wildcards []string
+
+	// omitGenerateDirective omits the go:generate directive from the header, because the init file has it. See hasInitFile.
+	omitGenerateDirective bool
+	// schemaVersion is the value of the constant SchemaVersion of opts.SchemaVersion.
+	schemaVersion string
+	// partial adds partialOutputHeader to the header, because the generation is interrupted before all the tables are generated.
+	partial bool
+	// wildcardShards is the shards of the wildcard tables by the table IDs of the wildcard tables, e.g. "events_*". See applyWildcards.
+	wildcardShards map[string][]*bigquery.Table
+	// report collects the columns mapped to the fields of the generated structs, if not nil. See mappingReport.
+	report *mappingReport
+}
+
+// OptionsError is the error of the invalid options, that has all the problems found, e.g. by Options.Validate.
+type OptionsError struct {
+	// Problems is the problems of the options in the order found.
+	Problems []error
+}
+
+func (e *OptionsError) Error() string {
+	messages := make([]string, 0, len(e.Problems))
+	for _, problem := range e.Problems {
+		messages = append(messages, problem.Error())
+	}
+	noun := " problems"
+	if len(e.Problems) == 1 {
+		noun = " problem"
+	}
+	return strconv.Itoa(len(e.Problems)) + noun + " of the options: " + strings.Join(messages, "; ")
+}
+
+// Validate returns *OptionsError that has all the problems of opts, e.g. the values not supported, the malformed entries of TypeMap
+// and the options that require the others, or nil if opts is valid. The zero values are valid.
+func (opts Options) Validate() (err error) {
+	var problems []error
+	if opts.Nullable != "" && opts.Nullable != nullableModeNone && opts.Nullable != nullableModeNull {
+		problems = append(problems, fmt.Errorf("invalid option value: -%s=%s", optNameNullable, opts.Nullable))
+	}
+	if opts.NullableRecords != "" && opts.NullableRecords != nullableRecordsValue && opts.NullableRecords != nullableRecordsPointer {
+		problems = append(problems, fmt.Errorf("invalid option value: -%s=%s", optNameNullableRecords, opts.NullableRecords))
+	}
+	if opts.NullableTemporal != "" && opts.NullableTemporal != nullableTemporalValue && opts.NullableTemporal != nullableTemporalPointer {
+		problems = append(problems, fmt.Errorf("invalid option value: -%s=%s", optNameNullableTemporal, opts.NullableTemporal))
+	}
+	typeMapKeys := make([]string, 0, len(opts.TypeMap))
+	for key := range opts.TypeMap {
+		typeMapKeys = append(typeMapKeys, key)
+	}
+	sort.Strings(typeMapKeys)
+	for _, key := range typeMapKeys {
+		if err = validateTypeMapEntry(key, opts.TypeMap[key]); err != nil {
+			problems = append(problems, fmt.Errorf("validateTypeMapEntry: %w", err))
+		}
+	}
+	for _, pattern := range opts.ExcludeColumns {
+		if _, err = parseExcludeColumns(pattern); err != nil {
+			problems = append(problems, fmt.Errorf("parseExcludeColumns: %w", err))
+		}
+	}
+	for _, prefix := range opts.Wildcards {
+		if _, err = parseWildcards(prefix); err != nil {
+			problems = append(problems, fmt.Errorf("parseWildcards: %w", err))
+		}
+	}
+	if len(opts.Wildcards) > 0 && !opts.AsOf.IsZero() {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s, because the wildcard tables have no time travel", optNameWildcard, optNameAsOf))
+	}
+	if err = validatePackageName(opts.Package); err != nil {
+		problems = append(problems, fmt.Errorf("validatePackageName: %w", err))
+	}
+	if err = validateTagKey(opts.TagKey); err != nil {
+		problems = append(problems, fmt.Errorf("validateTagKey: %w", err))
+	} else if err = validateStructTag(opts.PolicyTagStructTag, structTagKey(opts)); err != nil {
+		problems = append(problems, fmt.Errorf("validateStructTag: %w", err))
+	}
+	if _, _, err = parseEmbedType(opts.Embed); err != nil {
+		problems = append(problems, fmt.Errorf("parseEmbedType: %w", err))
+	}
+	if err = validatePartitioning(opts.Partitioning); err != nil {
+		problems = append(problems, fmt.Errorf("validatePartitioning: %w", err))
+	} else if opts.Partitioning != "" && opts.InformationSchema {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s, because INFORMATION_SCHEMA.COLUMNS has no partitioning of the tables", optNamePartitioning, optNameInformationSchema))
+	}
+	if opts.PartitioningComment && opts.InformationSchema {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s, because INFORMATION_SCHEMA.COLUMNS has no partitioning of the tables", optNamePartitioningComment, optNameInformationSchema))
+	}
+	if opts.ClusteringComment && opts.InformationSchema {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s, because INFORMATION_SCHEMA.COLUMNS has no clustering of the tables", optNameClusteringComment, optNameInformationSchema))
+	}
+	if opts.TableTypeComment && opts.InformationSchema {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s, because INFORMATION_SCHEMA.COLUMNS has no type of the tables", optNameTableTypeComment, optNameInformationSchema))
+	}
+	if (opts.PolicyTagComment != "" || opts.PolicyTagStructTag != "") && opts.InformationSchema {
+		problems = append(problems, fmt.Errorf("option -%s or -%s cannot be used with -%s, because INFORMATION_SCHEMA.COLUMNS has no policy tags of the columns", optNamePolicyTagComment, optNamePolicyTagStructTag, optNameInformationSchema))
+	}
+	if !opts.AsOf.IsZero() && opts.InformationSchema {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s, because INFORMATION_SCHEMA.COLUMNS has no type of the tables to tell if time travel is available", optNameAsOf, optNameInformationSchema))
+	}
+	if opts.CommentWidth < 0 {
+		problems = append(problems, fmt.Errorf("invalid option value: -%s=%d", optNameCommentWidth, opts.CommentWidth))
+	}
+	if opts.FlattenRecords && !opts.FromRow {
+		problems = append(problems, fmt.Errorf("option -%s requires -%s, because RowIterator.Next cannot load the flattened fields", optNameFlattenRecords, optNameFromRow))
+	}
+	if opts.NullableTemporal == nullableTemporalPointer && !opts.FromRow {
+		problems = append(problems, fmt.Errorf("option -%s=%s requires -%s, because RowIterator.Next cannot load the pointer fields", optNameNullableTemporal, nullableTemporalPointer, optNameFromRow))
+	}
+	if opts.QueryMethod && structTagKey(opts) != defaultValueTagKey {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s=%s, because RowIterator.Next loads the rows by the tags of %s", optNameQueryMethod, optNameTagKey, opts.TagKey, defaultValueTagKey))
+	}
+	if opts.QueryMethod && (opts.FlattenRecords || opts.NullableTemporal == nullableTemporalPointer) {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s or -%s=%s, because RowIterator.Next cannot load the flattened fields nor the pointer fields",
+			optNameQueryMethod, optNameFlattenRecords, optNameNullableTemporal, nullableTemporalPointer))
+	}
+	if opts.HelperInterfaces && opts.NoTableNameMethod {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s, because bqtable.Table has the method TableName", optNameNoTableNameMethod, optNameHelperInterfaces))
+	}
+
+	if len(problems) > 0 {
+		return &OptionsError{Problems: problems}
+	}
+	return nil
+}
+
+// isFlagSet reports whether the command-line flag name is set, even if it is set to the default value.
+func isFlagSet(name string) (set bool) {
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// NameMapper returns the Go identifier of the struct for tableID if columnName is empty,
+// or the Go identifier of the field for columnName in tableID.
+// The returned name must be a valid exported Go identifier. Duplicate field names are suffixed by "_2", "_3", ...
+type NameMapper func(tableID, columnName string) (name string)
+
+// DefaultNameMapper is the NameMapper used if Options.NameMapper is nil.
+// It capitalizes the initial of tableID or columnName. The characters that cannot be in Go identifiers are replaced by "_",
+// and "X" is prefixed if the name does not start with a letter, e.g. "123_events" to "X123_events".
+func DefaultNameMapper(tableID, columnName string) (name string) {
+	if columnName == "" {
+		return exportedIdentifier(sanitizeIdentifier(tableID))
+	}
+	return exportedIdentifier(columnName)
+}
+
+// TypeResolver resolves the Go type of the column field, e.g. "civil.Date", and the import path of its package, e.g. "cloud.google.com/go/civil".
+// goType is the element type of REPEATED columns; "[]" is prefixed by the generator.
+//
+// The resolution is chained as follows:
+//  1. Options.TypeMap, if the column is in it.
+//  2. Options.TypeResolver, if it returns non-empty goType. Returning empty goType and nil err falls back to 3.
+//  3. The built-in resolution: the nested struct for RECORD columns, Options.Nullable for NULLABLE columns,
+//     and DefaultTypeResolver for the others.
+type TypeResolver interface {
+	Resolve(field *bigquery.FieldSchema) (goType, importPath string, err error)
+}
+
+// TypeResolverFunc is the adapter to use the ordinary function as TypeResolver.
+type TypeResolverFunc func(field *bigquery.FieldSchema) (goType, importPath string, err error)
+
+// Resolve calls f(field).
+func (f TypeResolverFunc) Resolve(field *bigquery.FieldSchema) (goType, importPath string, err error) {
+	return f(field)
+}
+
+// DefaultTypeResolver is the TypeResolver of the built-in type mapping, regardless of the mode of the column.
+// It returns error for RECORD columns. Custom TypeResolver can delegate the columns to it.
+type DefaultTypeResolver struct{}
+
+// Resolve returns the Go type of field by bigqueryFieldTypeToGoType.
+func (DefaultTypeResolver) Resolve(field *bigquery.FieldSchema) (goType, importPath string, err error) {
+	return bigqueryFieldTypeToGoType(field.Type)
+}
+
 func main() {
+	// NOTE(djeeno): The jitter of listRetryDelay must differ between the processes listing at once.
+	rand.Seed(time.Now().UnixNano())
 
 	ctx := context.Background()
 
@@ -59,198 +593,5127 @@ func main() {
 // It is separated from the `main` function because of addressing an issue where` defer` is not executed when `os.Exit` is executed.
 func Run(ctx context.Context) (err error) {
 	flag.Parse()
+	logJSON = *optValueLogJSON
+	trace = *optValueTrace
+	// NOTE(djeeno): The progress is not interleaved with the JSON lines, and is not printed in CI, where stderr is not a terminal.
+	if !*optValueNoProgress && !logJSON && isTerminal(os.Stderr) {
+		progressWriter = os.Stderr
+		defer clearProgress()
+	}
 
-	var keyfile string
-	keyfile, err = getOptOrEnvOrDefault(optNameKeyFile, *optValueKeyFile, envNameGoogleApplicationCredentials, "")
+	if *optValueListTypes {
+		if err = writeFieldTypeList(os.Stdout); err != nil {
+			return fmt.Errorf("writeFieldTypeList: %w", err)
+		}
+		return nil
+	}
+
+	if *optValuePartialOnInterrupt {
+		var stop func()
+		ctx, stop = notifyInterrupt(ctx)
+		defer stop()
+	}
+
+	if *optValueEnvFile != "" {
+		if err = loadEnvFile(*optValueEnvFile); err != nil {
+			return fmt.Errorf("loadEnvFile: %w", err)
+		}
+	}
+
+	// NOTE(djeeno): The problems of the options are returned at once, instead of the first one. See OptionsError.
+	var problems []error
+	outputDir := *optValueOutputDir
+	outputTemplate := *optValueOutputTemplate
+	if outputTemplate != "" && outputDir != "" {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s", optNameOutputTemplate, optNameOutputDir))
+	}
+	if isFlagSet(optNameOutputFile) && (outputTemplate != "" || outputDir != "") {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s or -%s", optNameOutputFile, optNameOutputDir, optNameOutputTemplate))
+	}
+	if *optValuePrune && outputTemplate == "" {
+		problems = append(problems, fmt.Errorf("set option -%s to use -%s", optNameOutputTemplate, optNamePrune))
+	}
+	if *optValueOutputZip != "" {
+		if outputTemplate == "" {
+			problems = append(problems, fmt.Errorf("set option -%s to use -%s", optNameOutputTemplate, optNameOutputZip))
+		}
+		if *optValueSkipExisting || *optValuePrune {
+			problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s or -%s, that read the files of the filesystem", optNameOutputZip, optNameSkipExisting, optNamePrune))
+		}
+	}
+	if *optValueMerge {
+		if outputTemplate != "" || *optValueTemplate != "" || *optValueOutputFormat != outputFormatGo || *optValueSkipExisting || *optValuePartialOnInterrupt || *optValueSchemaVersion {
+			problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s, -%s, -%s, -%s, -%s or -%s", optNameMerge,
+				optNameOutputTemplate, optNameTemplate, optNameOutputFormat, optNameSkipExisting, optNamePartialOnInterrupt, optNameSchemaVersion))
+		}
+	}
+	if *optValueReport != "" && (*optValueOutputFormat != outputFormatGo || *optValueDiff || *optValueAdded != "") {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s, -%s or -%s, that generate no structs of the tables", optNameReport, optNameOutputFormat, optNameDiff, optNameAdded))
+	}
+	if *optValueTemplate != "" && (*optValueDiff || *optValueAdded != "") {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s or -%s, that read the generated file as Go", optNameTemplate, optNameDiff, optNameAdded))
+	}
+	if *optValueWildcard != "" && (*optValueValidate || *optValueDiff || *optValueAdded != "" || *optValueSchemaFile != "") {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s, -%s, -%s or -%s, that read the tables of the structs", optNameWildcard,
+			optNameValidate, optNameDiff, optNameAdded, optNameSchemaFile))
+	}
+	if *optValueInit && outputTemplate != "" {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s, because the files of the tables may be in the different directories", optNameInit, optNameOutputTemplate))
+	}
+	outputFormat := *optValueOutputFormat
+	defaultOutputFile := defaultValueOutputFile
+	switch outputFormat {
+	case outputFormatGo:
+	case outputFormatJSON:
+		defaultOutputFile = defaultValueOutputFileJSON
+		// NOTE(djeeno): The options of the Go code, e.g. -nullable, are ignored, but the options that read or write the Go code are not.
+		if outputTemplate != "" || *optValueTemplate != "" || *optValueEmitCompileTest || *optValueValidate || *optValueDiff || *optValueAdded != "" || *optValueInit || *optValueSchemaFile != "" || *optValuePartialOnInterrupt {
+			problems = append(problems, fmt.Errorf("option -%s=%s cannot be used with -%s, -%s, -%s, -%s, -%s, -%s, -%s, -%s or -%s", optNameOutputFormat, outputFormatJSON,
+				optNameOutputTemplate, optNameTemplate, optNameEmitCompileTest, optNameValidate, optNameDiff, optNameAdded, optNameInit, optNameSchemaFile, optNamePartialOnInterrupt))
+		}
+	default:
+		problems = append(problems, fmt.Errorf("invalid option value: -%s=%s", optNameOutputFormat, outputFormat))
+	}
+
+	var filePath string
+	if outputDir == "" && outputTemplate == "" {
+		filePath, err = getOptOrEnvOrDefault(optNameOutputFile, *optValueOutputPath, envNameOutputFile, defaultOutputFile)
+		if err != nil {
+			return fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+		}
+	}
+
+	opts := Options{
+		Nullable:            *optValueNullable,
+		ConsoleURL:          *optValueConsoleURL,
+		SkipForbidden:       *optValueSkipForbidden,
+		Constants:           *optValueConstants,
+		NullableRecords:     *optValueNullableRecords,
+		NullableTemporal:    *optValueNullableTemporal,
+		DeprecatedMarker:    *optValueDeprecatedMarker,
+		RequiredOnly:        *optValueRequiredOnly,
+		FromRow:             *optValueFromRow,
+		FlattenRecords:      *optValueFlattenRecords,
+		TagKey:              *optValueTagKey,
+		Embed:               *optValueEmbed,
+		SelectAll:           *optValueSelectAll,
+		InformationSchema:   *optValueInformationSchema,
+		NoDescription:       *optValueNoDescription,
+		HelperInterfaces:    *optValueHelperInterfaces,
+		HelperImport:        *optValueHelperImport,
+		Package:             *optValuePackage,
+		Getters:             *optValueGetters,
+		IdentityMethods:     *optValueIdentityMethods,
+		NoTableNameMethod:   *optValueNoTableNameMethod,
+		QueryMethod:         *optValueQueryMethod,
+		BigQueryNameMethod:  *optValueBigQueryNameMethod,
+		ColumnsVar:          *optValueColumnsVar,
+		SchemaVersion:       *optValueSchemaVersion,
+		Scaffold:            *optValueScaffold,
+		Partitioning:        *optValuePartitioning,
+		PartitioningComment: *optValuePartitioningComment,
+		ClusteringComment:   *optValueClusteringComment,
+		TableTypeComment:    *optValueTableTypeComment,
+		FieldsComment:       *optValueFieldsComment,
+		CommentWidth:        *optValueCommentWidth,
+		Strict:              *optValueStrict,
+		Stringer:            *optValueStringer,
+		PolicyTagComment:    *optValuePolicyTagComment,
+		PolicyTagStructTag:  *optValuePolicyTagStructTag,
+		TagDirective:        *optValueTagDirective,
+	}
+	opts.TypeMap, err = parseTypeMap(*optValueTypeMap)
 	if err != nil {
-		return fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+		problems = append(problems, fmt.Errorf("parseTypeMap: %w", err))
+	}
+	if *optValueAsOf != "" {
+		opts.AsOf, err = time.Parse(time.RFC3339, *optValueAsOf)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("time.Parse: %w", err))
+		}
+	}
+	opts.ExcludeColumns, err = parseExcludeColumns(*optValueExcludeColumns)
+	if err != nil {
+		problems = append(problems, fmt.Errorf("parseExcludeColumns: %w", err))
+	}
+	opts.Wildcards, err = parseWildcards(*optValueWildcard)
+	if err != nil {
+		problems = append(problems, fmt.Errorf("parseWildcards: %w", err))
+	}
+	opts.LossyTypes, err = parseLossyTypes(*optValueLossyTypes)
+	if err != nil {
+		problems = append(problems, fmt.Errorf("parseLossyTypes: %w", err))
+	}
+	datasetKeyFiles, err := parseDatasetKeyFiles(*optValueDatasetKeyFiles)
+	if err != nil {
+		problems = append(problems, fmt.Errorf("parseDatasetKeyFiles: %w", err))
+	}
+	if *optValueTemplate != "" {
+		opts.Template, err = parseTemplateFile(*optValueTemplate)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("parseTemplateFile: %w", err))
+		}
+	}
+	if err = opts.Validate(); err != nil {
+		var optionsError *OptionsError
+		if !errors.As(err, &optionsError) {
+			return fmt.Errorf("opts.Validate: %w", err)
+		}
+		problems = append(problems, optionsError.Problems...)
+	}
+	if *optValueLineEnding != lineEndingLF && *optValueLineEnding != lineEndingCRLF {
+		problems = append(problems, fmt.Errorf("invalid option value: -%s=%s", optNameLineEnding, *optValueLineEnding))
+	}
+	if *optValueSkipExisting && *optValueForce {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s", optNameSkipExisting, optNameForce))
+	}
+	config := outputConfig{lineEnding: *optValueLineEnding, compileTest: *optValueEmitCompileTest, skipExisting: *optValueSkipExisting, partialOnInterrupt: *optValuePartialOnInterrupt, merge: *optValueMerge, written: make(map[string]bool)}
+	config.fileMode, err = parseFileMode(*optValueFileMode)
+	if err != nil {
+		problems = append(problems, fmt.Errorf("parseFileMode: %w", err))
+	}
+	if config.compileTest && *optValueTemplate != "" {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s", optNameEmitCompileTest, optNameTemplate))
+	}
+	if config.compileTest && filePath == filePathStdout {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with the output to stdout", optNameEmitCompileTest))
+	}
+	if config.merge && filePath == filePathStdout {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with the output to stdout", optNameMerge))
+	}
+	if *optValueInit && outputDir == "" && (filePath == "" || filePath == filePathStdout) {
+		problems = append(problems, fmt.Errorf("option -%s requires the output file -%s or the output directory -%s", optNameInit, optNameOutputFile, optNameOutputDir))
+	}
+	if *optValueAsOf != "" && (*optValueSchemaFile != "" || *optValueDiff || *optValueAdded != "") {
+		problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s, -%s or -%s, that compare or read the current schemas", optNameAsOf, optNameSchemaFile, optNameDiff, optNameAdded))
+	}
+	if *optValueSchemaFile != "" {
+		if opts.Partitioning != "" {
+			problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s, because the schema file has no partitioning of the table", optNamePartitioning, optNameSchemaFile))
+		}
+		if *optValueValidate {
+			problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s, because it reads the rows of the tables", optNameValidate, optNameSchemaFile))
+		}
+	}
+	if outputTemplate != "" {
+		if opts.Constants {
+			problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s, because the constants conflict in the package", optNameConstants, optNameOutputTemplate))
+		}
+		if opts.SchemaVersion {
+			problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s, because the constants conflict in the package", optNameSchemaVersion, optNameOutputTemplate))
+		}
+		if *optValueDiff {
+			problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s", optNameDiff, optNameOutputTemplate))
+		}
+	}
+
+	// NOTE(djeeno): The datasets of -all-datasets and -dataset-pattern are listed after the options are checked, so that they are regarded as multiple datasets.
+	var tablesFrom *tableList
+	var datasetPattern *regexp.Regexp
+	var datasets []datasetRef
+	var multipleDatasets bool
+	if *optValueDatasetPattern != "" {
+		datasetPattern, err = regexp.Compile(*optValueDatasetPattern)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("regexp.Compile: %w", err))
+		}
+	}
+	switch {
+	case *optValueSchemaFile != "" || *optValueInit:
+		// NOTE(djeeno): The schema file and the init file are of the options of the dataset, that is optional.
+	case *optValueTablesFrom != "":
+		if *optValueAllDatasets || *optValueDatasetPattern != "" || *optValueDiff || *optValueAdded != "" || *optValuePrune {
+			problems = append(problems, fmt.Errorf("option -%s cannot be used with -%s, -%s, -%s, -%s or -%s", optNameTablesFrom, optNameAllDatasets, optNameDatasetPattern, optNameDiff, optNameAdded, optNamePrune))
+		}
+		tablesFrom, err = readTableList(*optValueTablesFrom)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("readTableList: %w", err))
+			break
+		}
+		multipleDatasets = len(tablesFrom.datasets) > 1
+	case *optValueAllDatasets || *optValueDatasetPattern != "":
+		multipleDatasets = true
+	default:
+		var dataset string
+		dataset, err = getOptOrEnvOrDefault(optNameDataset, *optValueDataset, envNameBigQueryDataset, "")
+		if err != nil {
+			problems = append(problems, fmt.Errorf("getOptOrEnvOrDefault: %w", err))
+			break
+		}
+		datasets, err = parseDatasetRefs(dataset)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("parseDatasetRefs: %w", err))
+			break
+		}
+		multipleDatasets = len(datasets) > 1
+	}
+	if multipleDatasets {
+		if outputDir == "" && outputTemplate == "" {
+			problems = append(problems, fmt.Errorf("set option -%s to generate multiple datasets", optNameOutputDir))
+		}
+		if opts.Constants {
+			problems = append(problems, fmt.Errorf("option -%s cannot be used with multiple datasets, because the constants conflict in the package", optNameConstants))
+		}
+		if opts.SchemaVersion {
+			problems = append(problems, fmt.Errorf("option -%s cannot be used with multiple datasets, because the constants conflict in the package", optNameSchemaVersion))
+		}
+	}
+	if *optValueAdded != "" && (multipleDatasets || filePath == "" || filePath == filePathStdout) {
+		problems = append(problems, fmt.Errorf("option -%s requires the generated file -%s of one dataset", optNameAdded, optNameOutputFile))
+	}
+	if len(problems) > 0 {
+		return &OptionsError{Problems: problems}
+	}
+	if *optValueReport != "" {
+		opts.report = &mappingReport{}
+	}
+
+	if *optValueInit {
+		initDir, outputOptName, output := filepath.Dir(filePath), optNameOutputFile, filepath.Base(filePath)
+		switch {
+		// NOTE(djeeno): go generate runs the directive in the directory of the init file, that is the output directory.
+		case outputDir != "":
+			initDir, outputOptName, output = outputDir, optNameOutputDir, "."
+		}
+		var project, dataset string
+		project, err = getOptOrEnvOrDefault(optNameProjectID, *optValueProjectID, envNameGCloudProjectID, "")
+		if err != nil {
+			return fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+		}
+		dataset, err = getOptOrEnvOrDefault(optNameDataset, *optValueDataset, envNameBigQueryDataset, "")
+		if err != nil {
+			return fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+		}
+		initFile := filepath.Join(initDir, initFileName)
+		if err = writeInitFile(initFile, project, dataset, outputOptName, output, packageName(opts), *optValueForce, config.fileMode); err != nil {
+			return fmt.Errorf("writeInitFile: %w", err)
+		}
+		infoln("wrote " + initFile)
+		return nil
+	}
+	switch {
+	// NOTE(djeeno): The files of the datasets or of the tables omit the directive, because go generate would run it once for each file.
+	// The directive of -output-dir is written once in the init file instead.
+	case outputDir != "" || outputTemplate != "":
+		opts.omitGenerateDirective = true
+	case filePath != "" && filePath != filePathStdout:
+		opts.omitGenerateDirective = hasInitFile(filepath.Dir(filePath))
+	}
+	if *optValueOutputZip != "" {
+		config.archive, err = createOutputArchive(*optValueOutputZip, config.fileMode)
+		if err != nil {
+			return fmt.Errorf("createOutputArchive: %w", err)
+		}
+		// NOTE(djeeno): The archive is removed on the errors, so that the archive of the partial files is not distributed.
+		defer func() {
+			if closeErr := config.archive.close(err == nil); closeErr != nil && err == nil {
+				err = fmt.Errorf("archive.close: %w", closeErr)
+			}
+			if err == nil {
+				infoln("wrote " + *optValueOutputZip)
+			}
+		}()
+	}
+
+	if *optValueSchemaFile != "" {
+		var result *GenerateResult
+		result, err = GenerateFromSchemaFile(*optValueProjectID, *optValueDataset, *optValueSchemaFile, opts)
+		if err != nil {
+			return fmt.Errorf("GenerateFromSchemaFile: %w", err)
+		}
+		name := *optValueDataset
+		if name == "" {
+			name = schemaFileTableID(*optValueSchemaFile)
+		}
+		outputPath := outputFilePath(filePath, outputDir, name)
+		if outputTemplate != "" {
+			outputPath = expandOutputTemplate(outputTemplate, *optValueProjectID, *optValueDataset, schemaFileTableID(*optValueSchemaFile))
+		}
+		if err = outputGeneratedCode(outputPath, result.Bytes, config); err != nil {
+			return fmt.Errorf("outputGeneratedCode: %w", err)
+		}
+		if opts.report != nil {
+			if err = writeMappingReport(*optValueReport, config.fileMode, opts.report); err != nil {
+				return fmt.Errorf("writeMappingReport: %w", err)
+			}
+			infoln("wrote " + *optValueReport)
+		}
+		return nil
+	}
+
+	// NOTE(djeeno): The access token is not resolved by getOptOrEnvOrDefault so as not to log it.
+	accessToken := *optValueAccessToken
+	if accessToken == "" {
+		accessToken = os.Getenv(envNameGoogleOAuthAccessToken)
+	}
+
+	var keyfile string
+	if accessToken == "" {
+		// NOTE(djeeno): `gcloud auth application-default login` writes the user credentials to the well-known file, without setting GOOGLE_APPLICATION_CREDENTIALS.
+		var defaultKeyFile string
+		if wellKnownFile := wellKnownCredentialsFile(); wellKnownFile != "" {
+			if _, err = os.Stat(wellKnownFile); err == nil {
+				defaultKeyFile = wellKnownFile
+			}
+		}
+		keyfile, err = getOptOrEnvOrDefault(optNameKeyFile, *optValueKeyFile, envNameGoogleApplicationCredentials, defaultKeyFile)
+		if err != nil {
+			return fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+		}
+	}
+
+	var defaultProject string
+	if keyfile != "" {
+		defaultProject, err = credentialsFileProjectID(keyfile)
+		if err != nil {
+			return fmt.Errorf("credentialsFileProjectID: %w", err)
+		}
+	}
+
+	// NOTE(djeeno): The table IDs are fully-qualified, so that their project is used unless the project is set explicitly.
+	if tablesFrom != nil {
+		defaultProject = tablesFrom.project
 	}
 
+	// NOTE(djeeno): The project is optional if all the datasets have their projects, e.g. "prod.analytics".
 	var project string
-	project, err = getOptOrEnvOrDefault(optNameProjectID, *optValueProjectID, envNameGCloudProjectID, "")
+	if isQualifiedDatasetRefs(datasets) {
+		project, err = getOptOrEnv(optNameProjectID, *optValueProjectID, envNameGCloudProjectID)
+		if err != nil {
+			return fmt.Errorf("getOptOrEnv: %w", err)
+		}
+	} else {
+		project, err = getOptOrEnvOrDefault(optNameProjectID, *optValueProjectID, envNameGCloudProjectID, defaultProject)
+		if err != nil {
+			return fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+		}
+	}
+	if tablesFrom != nil && tablesFrom.project != project {
+		return fmt.Errorf("project of the tables of -%s is not -%s. project=%s tablesProject=%s", optNameTablesFrom, optNameProjectID, project, tablesFrom.project)
+	}
+	for i := range datasets {
+		if datasets[i].project == "" {
+			datasets[i].project = project
+		}
+	}
+
+	// NOTE(djeeno): The query jobs of the datasets of the projects are billed to the project of the first dataset, unless the billing project is set.
+	defaultBillingProject := project
+	if defaultBillingProject == "" {
+		defaultBillingProject = datasets[0].project
+	}
+	var billingProject string
+	billingProject, err = getOptOrEnvOrDefault(optNameBillingProjectID, *optValueBillingProjectID, envNameGCloudBillingProjectID, defaultBillingProject)
 	if err != nil {
 		return fmt.Errorf("getOptOrEnvOrDefault: %w", err)
 	}
 
-	var dataset string
-	dataset, err = getOptOrEnvOrDefault(optNameDataset, *optValueDataset, envNameBigQueryDataset, "")
+	// set GOOGLE_APPLICATION_CREDENTIALS for Google Cloud SDK
+	if keyfile != "" && os.Getenv(envNameGoogleApplicationCredentials) != keyfile {
+		if err = os.Setenv(envNameGoogleApplicationCredentials, keyfile); err != nil {
+			return fmt.Errorf("os.Setenv: %w", err)
+		}
+	}
+
+	// NOTE(djeeno): The project of the client is the billing project of query jobs. The tables are read from the data project.
+	var scopes []string
+	if *optValueScopes != "" {
+		scopes = strings.Split(*optValueScopes, ",")
+	}
+	// NOTE(djeeno): The quota project is of the API requests, e.g. listing the tables, and is not the billing project of query jobs.
+	quotaProject, err := getOptOrEnv(optNameQuotaProject, *optValueQuotaProject, envNameGoogleCloudQuotaProject)
 	if err != nil {
-		return fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+		return fmt.Errorf("getOptOrEnv: %w", err)
+	}
+	client, err := bigquery.NewClient(ctx, billingProject, clientOptions(accessToken, "", quotaProject, scopes)...)
+	if err != nil {
+		return fmt.Errorf("bigquery.NewClient: %w", err)
+	}
+	clients := &datasetClients{client: client, billingProject: billingProject, quotaProject: quotaProject, scopes: scopes, keyFiles: datasetKeyFiles}
+	defer clients.close()
+
+	if *optValueAllDatasets || datasetPattern != nil {
+		var datasetIDs []string
+		datasetIDs, err = getAllDatasets(ctx, client, project, strings.Split(*optValueExcludeDatasets, ","), datasetPattern)
+		if err != nil {
+			return fmt.Errorf("getAllDatasets: %w", err)
+		}
+		datasets = projectDatasetRefs(project, datasetIDs)
+	}
+	if tablesFrom != nil {
+		datasets = projectDatasetRefs(tablesFrom.project, tablesFrom.datasets)
+	}
+	if len(datasets) > 1 {
+		// NOTE(djeeno): The files of the datasets are in the same package.
+		opts.DatasetPrefix = true
+		// NOTE(djeeno): The same dataset IDs may be in the projects, e.g. "dev.analytics" and "prod.analytics".
+		opts.ProjectPrefix = hasMultipleProjects(datasets)
+	}
+
+	if *optValueAdded != "" {
+		var client *bigquery.Client
+		client, err = clients.of(ctx, datasets[0])
+		if err != nil {
+			return fmt.Errorf("clients.of: %w", err)
+		}
+		var result *GenerateResult
+		result, err = GenerateAdded(ctx, client, datasets[0].project, datasets[0].dataset, filePath, opts)
+		if err != nil {
+			return fmt.Errorf("GenerateAdded: %w", err)
+		}
+		if err = outputGeneratedCode(*optValueAdded, result.Bytes, config); err != nil {
+			return fmt.Errorf("outputGeneratedCode: %w", err)
+		}
+		infoln("generated " + strconv.Itoa(result.Structs) + " structs of the added columns")
+		return nil
+	}
+
+	if *optValueDiff {
+		var drifts int
+		for _, ref := range datasets {
+			var client *bigquery.Client
+			client, err = clients.of(ctx, ref)
+			if err != nil {
+				return fmt.Errorf("clients.of: %w", err)
+			}
+			var result []Drift
+			result, err = Diff(ctx, client, ref.project, ref.dataset, outputFilePath(filePath, outputDir, ref.fileName(opts.ProjectPrefix)), opts)
+			if err != nil {
+				return fmt.Errorf("Diff: %w", err)
+			}
+			for _, drift := range result {
+				fmt.Println(drift.String())
+			}
+			drifts += len(result)
+		}
+		if drifts > 0 {
+			return fmt.Errorf("schema drift is found: %d drifts", drifts)
+		}
+		infoln("no schema drift is found")
+		return nil
+	}
+
+	var structs int
+	var skipped, invalid, truncated []string
+	outputPaths := make(map[string]string)
+	for _, ref := range datasets {
+		var client *bigquery.Client
+		client, err = clients.of(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("clients.of: %w", err)
+		}
+		datasetOpts := opts
+		if tablesFrom != nil {
+			datasetOpts.Tables = tablesFrom.tables[ref.dataset]
+		}
+		var result *GenerateResult
+		if outputFormat == outputFormatJSON {
+			outputPath := filePath
+			if outputDir != "" {
+				outputPath = filepath.Join(outputDir, ref.fileName(opts.ProjectPrefix)+schemaJSONFileSuffix)
+			}
+			if skipExistingFile(outputPath, config) {
+				continue
+			}
+			result, err = GenerateSchemaJSON(ctx, client, ref.project, ref.dataset, datasetOpts)
+			if err != nil {
+				return fmt.Errorf("GenerateSchemaJSON: %w", err)
+			}
+			if err = outputGeneratedCode(outputPath, result.Bytes, config); err != nil {
+				return fmt.Errorf("outputGeneratedCode: %w", err)
+			}
+		} else if outputTemplate != "" {
+			result, err = generateTableFiles(ctx, client, ref.project, ref.dataset, outputTemplate, outputPaths, config, datasetOpts)
+			if err != nil {
+				return fmt.Errorf("generateTableFiles: %w", err)
+			}
+		} else {
+			result, err = generateFile(ctx, client, ref.project, ref.dataset, outputFilePath(filePath, outputDir, ref.fileName(opts.ProjectPrefix)), config, datasetOpts)
+			if err != nil {
+				return fmt.Errorf("generateFile: %w", err)
+			}
+		}
+		structs += result.Structs
+		skipped = append(skipped, result.Skipped...)
+		if result.Truncated {
+			truncated = append(truncated, ref.String())
+		}
+		if opts.report != nil {
+			for _, tableID := range result.Skipped {
+				opts.report.skipped = append(opts.report.skipped, ref.String()+"."+tableID)
+			}
+		}
+
+		if *optValueValidate {
+			var failures []string
+			failures, err = validateTables(ctx, client, ref.project, ref.dataset, datasetOpts)
+			if err != nil {
+				return fmt.Errorf("validateTables: %w", err)
+			}
+			invalid = append(invalid, failures...)
+		}
+	}
+
+	generated := " structs"
+	if outputFormat == outputFormatJSON {
+		generated = " schemas"
+	}
+	infoln("generated " + strconv.Itoa(structs) + generated + ", skipped " + strconv.Itoa(len(skipped)) + " tables: " + strings.Join(skipped, ","))
+	if structs == 0 && len(skipped) > 0 {
+		return fmt.Errorf("all tables are skipped: %s", strings.Join(skipped, ","))
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("sample rows of the tables cannot be loaded into the generated structs: %s", strings.Join(invalid, ","))
+	}
+
+	if opts.report != nil {
+		if err = writeMappingReport(*optValueReport, config.fileMode, opts.report); err != nil {
+			return fmt.Errorf("writeMappingReport: %w", err)
+		}
+		infoln("wrote " + *optValueReport)
+	}
+
+	if *optValuePrune {
+		// NOTE(djeeno): The files of the tables not listed would be removed as the files of the deleted tables.
+		if len(truncated) > 0 {
+			return fmt.Errorf("option -%s is not applied, because the listing of the tables is cut short by -%s: %s", optNamePrune, optNameSkipForbidden, strings.Join(truncated, ","))
+		}
+		var pruned []string
+		pruned, err = pruneGeneratedFiles(outputTemplate, datasets, outputPaths, config.written)
+		if err != nil {
+			return fmt.Errorf("pruneGeneratedFiles: %w", err)
+		}
+		infoln("pruned " + strconv.Itoa(len(pruned)) + " files: " + strings.Join(pruned, ","))
+	}
+
+	return nil
+}
+
+// writeInitFile writes initFile, the package stub of the package packageName that has the go:generate directive to generate output of project.dataset.
+// outputOptName is the option of output, i.e. optNameOutputFile or optNameOutputDir. It returns error if initFile exists, unless force.
+func writeInitFile(initFile, project, dataset, outputOptName, output, packageName string, force bool, fileMode os.FileMode) (err error) {
+	if _, err = os.Stat(initFile); err == nil && !force {
+		return fmt.Errorf("file already exists. set option -%s to overwrite. path=%s", optNameForce, initFile)
+	}
+
+	if err = writeGeneratedCode(initFile, fileMode, []byte(generateInitCode(project, dataset, outputOptName, output, packageName))); err != nil {
+		return fmt.Errorf("writeGeneratedCode: %w", err)
+	}
+
+	return nil
+}
+
+// hasInitFile reports whether dir has the init file written by writeInitFile, that has the go:generate directive.
+func hasInitFile(dir string) bool {
+	content, err := readFile(filepath.Join(dir, initFileName))
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(content, []byte(generateDirective+" "))
+}
+
+// generateInitCode generates the code of the package stub of the package packageName that has the go:generate directive to generate output of project.dataset by outputOptName.
+// The directive has -package unless packageName is defaultValuePackage.
+func generateInitCode(project, dataset, outputOptName, output, packageName string) (generatedCode string) {
+	// NOTE(djeeno): The arguments of go:generate are split by spaces, except the double-quoted strings. ref. https://golang.org/cmd/go/#hdr-Generate_Go_files_by_processing_source
+	directiveArg := func(arg string) string {
+		if strings.ContainsAny(arg, " \t\"") {
+			return strconv.Quote(arg)
+		}
+		return arg
+	}
+
+	directive := generateDirective +
+		" -" + optNameProjectID + " " + directiveArg(project) +
+		" -" + optNameDataset + " " + directiveArg(dataset) +
+		" -" + outputOptName + " " + directiveArg(output)
+	if packageName != defaultValuePackage {
+		directive = directive + " -" + optNamePackage + " " + packageName
+	}
+
+	return "// Package " + packageName + " has the schema structs of the BigQuery tables of `" + project + ":" + dataset + "`.\n" +
+		"package " + packageName + "\n\n" +
+		directive + "\n"
+}
+
+// packageName returns opts.Package, or defaultValuePackage if empty.
+func packageName(opts Options) string {
+	if opts.Package == "" {
+		return defaultValuePackage
+	}
+	return opts.Package
+}
+
+// validatePackageName returns error if name is neither empty nor a Go identifier, except the blank identifier.
+func validatePackageName(name string) (err error) {
+	if name != "" && (!token.IsIdentifier(name) || name == "_") {
+		return fmt.Errorf("package name is not a Go identifier. name=%s", name)
+	}
+	return nil
+}
+
+// checkHelperImport returns error if the helper package of opts.HelperInterfaces cannot be imported from the package of the directory of filePath,
+// i.e. the helper package is the package itself, or an internal package of the other tree. ref. https://golang.org/cmd/go/#hdr-Internal_Directories
+// It does not check the directories out of any module.
+func checkHelperImport(filePath string, opts Options) (err error) {
+	if !opts.HelperInterfaces || filePath == filePathStdout {
+		return nil
+	}
+	helperImport := opts.HelperImport
+	if helperImport == "" {
+		helperImport = defaultValueHelperImport
+	}
+
+	importPath, err := packageImportPath(filepath.Dir(filePath))
+	if err != nil {
+		return fmt.Errorf("packageImportPath: %w", err)
+	}
+	if importPath == "" {
+		return nil
+	}
+
+	if helperImport == importPath {
+		return fmt.Errorf("import cycle: the helper package is the generated package. -%s=%s path=%s", optNameHelperImport, helperImport, filePath)
+	}
+	elems := strings.Split(helperImport, "/")
+	for i, elem := range elems {
+		if elem != "internal" || i == 0 {
+			continue
+		}
+		parent := strings.Join(elems[:i], "/")
+		if importPath != parent && !strings.HasPrefix(importPath, parent+"/") {
+			return fmt.Errorf("use of internal package %s not allowed from %s. path=%s", helperImport, importPath, filePath)
+		}
+	}
+
+	return nil
+}
+
+// modulePathPattern matches the module directive of go.mod, and its submatch is the module path that may be quoted.
+var modulePathPattern = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+// packageImportPath returns the import path of the package of dir by the module path of the nearest go.mod in dir or its parents.
+// It returns empty if dir is out of any module.
+func packageImportPath(dir string) (importPath string, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("filepath.Abs: %w", err)
+	}
+
+	for moduleDir := dir; ; moduleDir = filepath.Dir(moduleDir) {
+		content, readErr := readFile(filepath.Join(moduleDir, "go.mod"))
+		if readErr == nil {
+			modulePath := modulePathPattern.FindSubmatch(content)
+			if modulePath == nil {
+				return "", fmt.Errorf("module path not found. path=%s", filepath.Join(moduleDir, "go.mod"))
+			}
+			var rel string
+			rel, err = filepath.Rel(moduleDir, dir)
+			if err != nil {
+				return "", fmt.Errorf("filepath.Rel: %w", err)
+			}
+			return path.Join(strings.Trim(string(modulePath[1]), `"`), filepath.ToSlash(rel)), nil
+		}
+		if filepath.Dir(moduleDir) == moduleDir {
+			return "", nil
+		}
+	}
+}
+
+// notifyInterrupt returns the copy of parent that is canceled on the first interrupt (os.Interrupt).
+// The next interrupt terminates the process as usual. The caller must call stop to release the resources.
+func notifyInterrupt(parent context.Context) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(parent)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+
+	go func() {
+		select {
+		case <-signals:
+			warnln("interrupted, writing the partial output")
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// loadEnvFile sets the environment variables in envFile, the lines of KEY=VALUE, e.g. ".env".
+// The empty lines, the lines starting with "#" and the prefix "export " are ignored, and the quoted values are unquoted.
+// The environment variables already set are not overridden.
+func loadEnvFile(envFile string) (err error) {
+	content, err := readFile(envFile)
+	if err != nil {
+		return fmt.Errorf("readFile: %w", err)
+	}
+
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(strings.TrimPrefix(line, "export "), "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || key == "" {
+			return fmt.Errorf("line must be KEY=VALUE. file=%s line=%d", envFile, i+1)
+		}
+		value := strings.TrimSpace(kv[1])
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			if value, err = strconv.Unquote(value); err != nil {
+				return fmt.Errorf("strconv.Unquote: file=%s line=%d: %w", envFile, i+1, err)
+			}
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		}
+
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		if err = os.Setenv(key, value); err != nil {
+			return fmt.Errorf("os.Setenv: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// credentialsFile is the fields of the credentials file of GOOGLE_APPLICATION_CREDENTIALS to resolve the project.
+type credentialsFile struct {
+	// Type is credentialsTypeServiceAccount or credentialsTypeAuthorizedUser.
+	Type string `json:"type"`
+	// ProjectID is the project of the service account.
+	ProjectID string `json:"project_id"`
+	// QuotaProjectID is the quota project of the user credentials, that is set by `gcloud auth application-default set-quota-project`.
+	QuotaProjectID string `json:"quota_project_id"`
+}
+
+// NOTE(djeeno): ref. https://github.com/golang/oauth2/blob/5d25da1a8d43/google/google.go#L92-L96
+const (
+	credentialsTypeServiceAccount = "service_account"
+	credentialsTypeAuthorizedUser = "authorized_user"
+)
+
+// credentialsFileProjectID returns the project of keyfile, the service account key file or the user credentials file.
+// It returns empty if keyfile has no project, e.g. the user credentials without the quota project, so that the project must be set by -project.
+func credentialsFileProjectID(keyfile string) (project string, err error) {
+	content, err := readFile(keyfile)
+	if err != nil {
+		return "", fmt.Errorf("readFile: %w", err)
+	}
+
+	var credentials credentialsFile
+	if err = json.Unmarshal(content, &credentials); err != nil {
+		return "", fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	switch credentials.Type {
+	case credentialsTypeServiceAccount:
+		return credentials.ProjectID, nil
+	case credentialsTypeAuthorizedUser:
+		return credentials.QuotaProjectID, nil
+	default:
+		warnln("credentials type not supported to resolve the project. type=" + credentials.Type)
+		return "", nil
+	}
+}
+
+// wellKnownCredentialsFile returns the path of the application default credentials file of gcloud.
+// NOTE(djeeno): ref. https://github.com/golang/oauth2/blob/5d25da1a8d43/google/default.go#L140-L146
+func wellKnownCredentialsFile() string {
+	const f = "application_default_credentials.json"
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "gcloud", f)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gcloud", f)
+}
+
+// clientOptions returns the options of bigquery.NewClient.
+// If accessToken is not empty, the client uses it instead of GOOGLE_APPLICATION_CREDENTIALS. Or if keyfile is not empty, the client uses it.
+// If quotaProject is not empty, the quota of the API requests of the client is of it instead of the quota project of the credentials.
+// If scopes is not empty, the client requests them instead of the default scopes of the client, except for accessToken.
+func clientOptions(accessToken, keyfile, quotaProject string, scopes []string) (opts []option.ClientOption) {
+	switch {
+	case accessToken != "":
+		opts = append(opts, option.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken})))
+	case keyfile != "":
+		opts = append(opts, option.WithCredentialsFile(keyfile))
+	}
+	// NOTE(djeeno): The scopes are ignored for accessToken, because its scopes are determined when it is issued.
+	if len(scopes) > 0 && accessToken == "" {
+		opts = append(opts, option.WithScopes(scopes...))
+	}
+	// NOTE(djeeno): ref. https://cloud.google.com/docs/quota#quota_project
+	if quotaProject != "" {
+		opts = append(opts, option.WithQuotaProject(quotaProject))
+	}
+	return opts
+}
+
+// datasetRef is the dataset to generate and its project.
+type datasetRef struct {
+	// project is the project ID of the dataset, or empty if the dataset is of -project. See parseDatasetRefs.
+	project string
+	// dataset is the dataset ID.
+	dataset string
+}
+
+// String returns "project.dataset" of r.
+func (r datasetRef) String() string {
+	return r.project + "." + r.dataset
+}
+
+// fileName returns the base name of the generated file of r without the extension, that is the dataset ID, or "project.dataset" if projectPrefix.
+// NOTE(djeeno): ":" of the domain-scoped project IDs, e.g. "example.com:project", cannot be in the file names on Windows.
+func (r datasetRef) fileName(projectPrefix bool) (name string) {
+	if !projectPrefix {
+		return r.dataset
+	}
+	return strings.Replace(r.project, ":", "_", -1) + "." + r.dataset
+}
+
+// parseDatasetRefs parses s, the comma-separated dataset IDs, or "project.dataset" or "project:dataset" (e.g. the output of bq) of the other projects.
+// The project of the dataset IDs is empty, that is the project of -project. It returns error if the dataset is empty or duplicated.
+func parseDatasetRefs(s string) (refs []datasetRef, err error) {
+	seen := make(map[datasetRef]bool)
+	for _, entry := range strings.Split(s, ",") {
+		// NOTE(djeeno): The project is split from the right as parseTableList, because the dataset IDs have neither "." nor ":".
+		ref := datasetRef{dataset: entry}
+		if idx := strings.LastIndexAny(entry, ".:"); idx >= 0 {
+			ref = datasetRef{project: entry[:idx], dataset: entry[idx+1:]}
+			if ref.project == "" {
+				return nil, fmt.Errorf("project of the dataset is empty. dataset=%s", entry)
+			}
+		}
+		if ref.dataset == "" {
+			return nil, fmt.Errorf("dataset is empty. datasets=%s", s)
+		}
+		if seen[ref] {
+			return nil, fmt.Errorf("dataset is duplicated. dataset=%s", entry)
+		}
+		seen[ref] = true
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// projectDatasetRefs returns the datasetRef of each of datasetIDs in project.
+func projectDatasetRefs(project string, datasetIDs []string) (refs []datasetRef) {
+	refs = make([]datasetRef, 0, len(datasetIDs))
+	for _, datasetID := range datasetIDs {
+		refs = append(refs, datasetRef{project: project, dataset: datasetID})
+	}
+	return refs
+}
+
+// isQualifiedDatasetRefs reports whether refs is not empty and all of refs have their projects.
+func isQualifiedDatasetRefs(refs []datasetRef) bool {
+	for _, ref := range refs {
+		if ref.project == "" {
+			return false
+		}
+	}
+	return len(refs) > 0
+}
+
+// hasMultipleProjects reports whether refs are in the multiple projects.
+func hasMultipleProjects(refs []datasetRef) bool {
+	for _, ref := range refs {
+		if ref.project != refs[0].project {
+			return true
+		}
+	}
+	return false
+}
+
+// datasetClients is the clients of the datasets. The datasets in keyFiles are fetched by the clients of their key files, and the others by client.
+type datasetClients struct {
+	// client is the client of the default credentials.
+	client *bigquery.Client
+	// billingProject is the project of the clients.
+	billingProject string
+	// quotaProject is the quota project of the clients, or empty. See clientOptions.
+	quotaProject string
+	// scopes is the scopes of the clients. See clientOptions.
+	scopes []string
+	// keyFiles is the key files by the dataset IDs or "project.dataset". See parseDatasetKeyFiles.
+	keyFiles map[string]string
+	// clients is the clients created by the key files.
+	clients map[string]*bigquery.Client
+}
+
+// of returns the client of ref. The key file of "project.dataset" precedes the key file of the dataset ID, e.g. for the same dataset IDs of the projects.
+// The client of each key file is created once, and shared by the datasets of the key file.
+func (c *datasetClients) of(ctx context.Context, ref datasetRef) (client *bigquery.Client, err error) {
+	keyfile, ok := c.keyFiles[ref.String()]
+	if !ok {
+		keyfile, ok = c.keyFiles[ref.dataset]
+	}
+	if !ok {
+		return c.client, nil
+	}
+	if client, ok = c.clients[keyfile]; ok {
+		return client, nil
+	}
+
+	client, err = bigquery.NewClient(ctx, c.billingProject, clientOptions("", keyfile, c.quotaProject, c.scopes)...)
+	if err != nil {
+		return nil, fmt.Errorf("bigquery.NewClient: %w", err)
+	}
+	if c.clients == nil {
+		c.clients = make(map[string]*bigquery.Client)
+	}
+	c.clients[keyfile] = client
+
+	return client, nil
+}
+
+// close closes all the clients.
+func (c *datasetClients) close() {
+	if err := c.client.Close(); err != nil {
+		warnln("client.Close: " + err.Error())
+	}
+	for _, client := range c.clients {
+		if err := client.Close(); err != nil {
+			warnln("client.Close: " + err.Error())
+		}
+	}
+}
+
+// parseDatasetKeyFiles parses s, the comma-separated "dataset=keyfile" or "project.dataset=keyfile", and returns the key files by them.
+func parseDatasetKeyFiles(s string) (keyFiles map[string]string, err error) {
+	keyFiles = make(map[string]string)
+	if s == "" {
+		return keyFiles, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("dataset key file must be \"dataset=keyfile\". pair=%s", pair)
+		}
+		if _, ok := keyFiles[kv[0]]; ok {
+			return nil, fmt.Errorf("dataset key file is duplicated. dataset=%s", kv[0])
+		}
+		keyFiles[kv[0]] = kv[1]
+	}
+
+	return keyFiles, nil
+}
+
+// generateFile generates the code of dataset and writes it to filePath.
+// The structs are written to the temporary file as they are generated, so that the memory does not grow with the size of dataset. See structsWriter.
+// The result has no Bytes, unless opts.Template is set.
+func generateFile(ctx context.Context, client *bigquery.Client, project, dataset, filePath string, config outputConfig, opts Options) (result *GenerateResult, err error) {
+	// NOTE(djeeno): The tables are not fetched for the existing file.
+	if skipExistingFile(filePath, config) {
+		return &GenerateResult{}, nil
+	}
+	if err = checkHelperImport(filePath, opts); err != nil {
+		return nil, fmt.Errorf("checkHelperImport: %w", err)
+	}
+
+	// NOTE(djeeno): The output of the custom template may not be Go, so that it is neither formatted nor streamed.
+	if opts.Template != nil {
+		result, err = Generate(ctx, client, project, dataset, opts)
+		if err != nil {
+			return nil, fmt.Errorf("Generate: %w", err)
+		}
+		if err = outputGeneratedCode(filePath, result.Bytes, config); err != nil {
+			return nil, fmt.Errorf("outputGeneratedCode: %w", err)
+		}
+		return result, nil
+	}
+
+	tables, truncated, err := getTables(ctx, client, project, dataset, opts)
+	if err != nil {
+		return nil, fmt.Errorf("getTables: %w", err)
+	}
+	tables, opts = applyWildcards(tables, opts)
+
+	var gen *generation
+	if config.merge {
+		gen, err = newMergeGeneration(filePath, tables, opts)
+		if err != nil {
+			return nil, fmt.Errorf("newMergeGeneration: %w", err)
+		}
+	}
+
+	structs, err := newStructsWriter(config.lineEnding)
+	if err != nil {
+		return nil, fmt.Errorf("newStructsWriter: %w", err)
+	}
+	defer structs.close()
+
+	result, err = generateTables(ctx, client, project, dataset, tables, gen, opts, tableHooks{
+		emit: func(table *bigquery.Table, structCode string, importPackages []string) error {
+			// NOTE(djeeno): The code of each table is enclosed between the marker comments to be replaced by the next merge.
+			if config.merge {
+				structCode = mergeBlockCode(table.TableID, structCode)
+			}
+			return structs.write(structCode, importPackages)
+		},
+	})
+	if err != nil {
+		// NOTE(djeeno): The structs generated before the interrupt are written, so that the progress of the long generation is not lost.
+		if config.partialOnInterrupt && ctx.Err() != nil && structs.written {
+			opts.partial = true
+			config.recordWritten(filePath)
+			if writeErr := structs.writeFile(filePath, config.fileMode, project, dataset, opts); writeErr != nil {
+				return nil, fmt.Errorf("structs.writeFile: %w", writeErr)
+			}
+			return nil, fmt.Errorf("generateTables: interrupted, wrote the partial output. path=%s: %w", filePath, err)
+		}
+		return nil, fmt.Errorf("generateTables: %w", err)
+	}
+	result.Truncated = truncated
+
+	opts.schemaVersion = result.SchemaVersion
+	config.recordWritten(filePath)
+	if config.merge {
+		if err = structs.mergeFile(filePath, config.fileMode, project, dataset, opts); err != nil {
+			return nil, fmt.Errorf("structs.mergeFile: %w", err)
+		}
+	} else if err = structs.writeFile(filePath, config.fileMode, project, dataset, opts); err != nil {
+		return nil, fmt.Errorf("structs.writeFile: %w", err)
+	}
+
+	if config.compileTest {
+		var generatedCode []byte
+		generatedCode, err = readFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("readFile: %w", err)
+		}
+		if err = outputCompileTestCode(filePath, generatedCode, config); err != nil {
+			return nil, fmt.Errorf("outputCompileTestCode: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// structsWriter writes the formatted structs to the temporary file and records the packages that they use,
+// so that the generated file is written without holding all the structs in memory.
+// The import declarations, that precede the structs, are written by writeFile after all the structs are written.
+type structsWriter struct {
+	// file is the temporary file of the structs.
+	file *os.File
+	// lineEnding is the line ending of the structs. See convertLineEnding.
+	lineEnding string
+	// importPackages is the import packages of the structs.
+	importPackages []string
+	// qualifiers is the package names referred by the structs, e.g. "civil" of civil.Date.
+	qualifiers map[string]bool
+	// written reports whether any struct is written.
+	written bool
+}
+
+// newStructsWriter creates structsWriter and its temporary file. The caller must call close.
+func newStructsWriter(lineEnding string) (w *structsWriter, err error) {
+	file, err := ioutil.TempFile("", "bqschema-gen-go-*.go")
+	if err != nil {
+		return nil, fmt.Errorf("ioutil.TempFile: %w", err)
+	}
+
+	return &structsWriter{file: file, lineEnding: lineEnding, qualifiers: make(map[string]bool)}, nil
+}
+
+// write formats structCode and appends it to the temporary file.
+func (w *structsWriter) write(structCode string, importPackages []string) (err error) {
+	// NOTE(djeeno): structCode is formatted as the whole file, because format.Source does not reformat the doc comments of the partial source.
+	const packageClause = "package bqschema\n"
+	formatted, err := format.Source([]byte(packageClause + "\n" + structCode))
+	if err != nil {
+		return fmt.Errorf("format.Source: %w", err)
+	}
+	formatted = bytes.TrimLeft(bytes.TrimPrefix(formatted, []byte(packageClause)), "\n")
+	// NOTE(djeeno): gofmt separates the declarations of the tables by a blank line in the whole file.
+	if w.written {
+		formatted = append([]byte{'\n'}, formatted...)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "", packageClause+structCode, 0)
+	if err != nil {
+		return fmt.Errorf("parser.ParseFile: %w", err)
+	}
+	ast.Inspect(file, func(node ast.Node) bool {
+		if selector, ok := node.(*ast.SelectorExpr); ok {
+			if ident, ok := selector.X.(*ast.Ident); ok {
+				w.qualifiers[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	formatted, err = convertLineEnding(formatted, w.lineEnding)
+	if err != nil {
+		return fmt.Errorf("convertLineEnding: %w", err)
+	}
+	if _, err = w.file.Write(formatted); err != nil {
+		return fmt.Errorf("w.file.Write: %w", err)
+	}
+
+	w.importPackages = append(w.importPackages, importPackages...)
+	w.written = true
+
+	return nil
+}
+
+// writeFile writes the header and the structs to filePath with fileMode, or to stdout if filePath is filePathStdout.
+// The import declarations have only the packages referred by the structs, as imports.Process removes the others from the whole file.
+func (w *structsWriter) writeFile(filePath string, fileMode os.FileMode, project, dataset string, opts Options) (err error) {
+	headerCode, err := imports.Process("", []byte(generateFileHeaderCode(project, dataset, w.usedPackages(), opts)), &imports.Options{FormatOnly: true, Comments: true, TabIndent: true, TabWidth: 8})
+	if err != nil {
+		return fmt.Errorf("imports.Process: %w", err)
+	}
+	if w.written {
+		headerCode = append(headerCode, '\n')
+	}
+	headerCode, err = convertLineEnding(headerCode, w.lineEnding)
+	if err != nil {
+		return fmt.Errorf("convertLineEnding: %w", err)
+	}
+
+	if _, err = w.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("w.file.Seek: %w", err)
+	}
+	if err = writeGeneratedCodeFrom(filePath, fileMode, io.MultiReader(bytes.NewReader(headerCode), w.file)); err != nil {
+		return fmt.Errorf("writeGeneratedCodeFrom: %w", err)
+	}
+
+	return nil
+}
+
+// usedPackages returns the import packages referred by the structs.
+func (w *structsWriter) usedPackages() (usedPackages []string) {
+	for _, pkg := range w.importPackages {
+		if name, _ := parseImportPackage(pkg); w.qualifiers[name] {
+			usedPackages = append(usedPackages, pkg)
+		}
+	}
+	return usedPackages
+}
+
+// mergeFile merges the structs into the existing generated file filePath by mergeGeneratedCode, and writes it with fileMode.
+// The file that does not exist, or that has no markers if all the tables are generated (opts.Tables is empty), is written by writeFile instead.
+func (w *structsWriter) mergeFile(filePath string, fileMode os.FileMode, project, dataset string, opts Options) (err error) {
+	if _, err = os.Stat(filePath); os.IsNotExist(err) {
+		return w.writeFile(filePath, fileMode, project, dataset, opts)
+	}
+	existing, err := readFile(filePath)
+	if err != nil {
+		return fmt.Errorf("readFile: %w", err)
+	}
+	// NOTE(djeeno): The markers are matched by the lines of LF, and the line ending is converted again after merging.
+	existing = bytes.ReplaceAll(existing, []byte("\r\n"), []byte("\n"))
+	if !bytes.Contains(existing, []byte(mergeMarkerBegin)) {
+		if len(opts.Tables) == 0 {
+			return w.writeFile(filePath, fileMode, project, dataset, opts)
+		}
+		return fmt.Errorf("existing file has no markers of -%s, generate all the tables with -%s without -%s once. path=%s", optNameMerge, optNameMerge, optNameTablesFrom, filePath)
+	}
+
+	if _, err = w.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("w.file.Seek: %w", err)
+	}
+	structsCode, err := ioutil.ReadAll(w.file)
+	if err != nil {
+		return fmt.Errorf("ioutil.ReadAll: %w", err)
+	}
+	structsCode = bytes.ReplaceAll(structsCode, []byte("\r\n"), []byte("\n"))
+
+	// NOTE(djeeno): The packages of the structs kept are imported too, and imports.Process removes the packages of the replaced structs that are no longer referred.
+	existingFile, err := parser.ParseFile(token.NewFileSet(), filePath, existing, parser.ImportsOnly)
+	if err != nil {
+		return fmt.Errorf("parser.ParseFile: %w", err)
+	}
+	importPackages := w.usedPackages()
+	for _, spec := range existingFile.Imports {
+		var pkg string
+		pkg, err = strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return fmt.Errorf("strconv.Unquote: %w", err)
+		}
+		if spec.Name != nil {
+			pkg = spec.Name.Name + " " + pkg
+		}
+		importPackages = append(importPackages, pkg)
+	}
+
+	merged, err := mergeGeneratedCode(string(existing), string(structsCode), generateFileHeaderCode(project, dataset, importPackages, opts))
+	if err != nil {
+		return fmt.Errorf("mergeGeneratedCode: %w", err)
+	}
+	mergedCode, err := imports.Process("", []byte(merged), nil)
+	if err != nil {
+		return fmt.Errorf("imports.Process: %w", err)
+	}
+	mergedCode, err = convertLineEnding(mergedCode, w.lineEnding)
+	if err != nil {
+		return fmt.Errorf("convertLineEnding: %w", err)
+	}
+	if err = writeGeneratedCode(filePath, fileMode, mergedCode); err != nil {
+		return fmt.Errorf("writeGeneratedCode: %w", err)
+	}
+
+	return nil
+}
+
+// mergeBlock is the code of one table between the markers, or the code between the blocks of the tables if tableID is empty.
+type mergeBlock struct {
+	tableID string
+	code    string
+}
+
+// mergeGeneratedCode replaces the code of the tables between the markers in existing with the code of the same tables in structsCode,
+// and appends the code of the other tables in structsCode. The code before the first marker in existing is replaced with headerCode.
+// The code of the tables that are only in existing, and the code between the blocks of the tables, are kept as is.
+func mergeGeneratedCode(existing, structsCode, headerCode string) (merged string, err error) {
+	existingBlocks, err := splitMergeBlocks(existing)
+	if err != nil {
+		return "", fmt.Errorf("splitMergeBlocks: existing: %w", err)
+	}
+	generatedBlocks, err := splitMergeBlocks(structsCode)
+	if err != nil {
+		return "", fmt.Errorf("splitMergeBlocks: generated: %w", err)
+	}
+
+	generated := make(map[string]string)
+	var generatedTableIDs []string
+	for _, block := range generatedBlocks {
+		if block.tableID != "" {
+			generated[block.tableID] = block.code
+			generatedTableIDs = append(generatedTableIDs, block.tableID)
+		}
+	}
+
+	merged = headerCode
+	replaced := make(map[string]bool)
+	for i, block := range existingBlocks {
+		switch {
+		// NOTE(djeeno): The code before the first marker is the header of the existing file.
+		case i == 0 && block.tableID == "":
+		case block.tableID == "":
+			merged += block.code
+		case generated[block.tableID] != "":
+			merged += generated[block.tableID]
+			replaced[block.tableID] = true
+		default:
+			merged += block.code
+		}
+	}
+	for _, tableID := range generatedTableIDs {
+		if !replaced[tableID] {
+			merged += "\n" + generated[tableID]
+		}
+	}
+
+	return merged, nil
+}
+
+// mergeBlockCode encloses structCode of tableID between the markers.
+func mergeBlockCode(tableID, structCode string) (generatedCode string) {
+	// NOTE(djeeno): The blank line keeps the marker out of the doc comment of the struct.
+	return mergeMarkerBegin + tableID + "\n\n" + structCode + mergeMarkerEnd + tableID + "\n"
+}
+
+// splitMergeBlocks splits code into the blocks of the tables between the markers, including the markers, and the code between them.
+func splitMergeBlocks(code string) (blocks []mergeBlock, err error) {
+	seen := make(map[string]bool)
+	var current mergeBlock
+	for _, line := range strings.SplitAfter(code, "\n") {
+		trimmed := strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(trimmed, mergeMarkerBegin):
+			if current.tableID != "" {
+				return nil, fmt.Errorf("marker of the table is not closed. table=%s", current.tableID)
+			}
+			tableID := strings.TrimPrefix(trimmed, mergeMarkerBegin)
+			if seen[tableID] {
+				return nil, fmt.Errorf("markers of the table are duplicated. table=%s", tableID)
+			}
+			seen[tableID] = true
+			if current.code != "" {
+				blocks = append(blocks, current)
+			}
+			current = mergeBlock{tableID: tableID, code: line}
+		case strings.HasPrefix(trimmed, mergeMarkerEnd):
+			tableID := strings.TrimPrefix(trimmed, mergeMarkerEnd)
+			if current.tableID != tableID {
+				return nil, fmt.Errorf("marker of the end does not match the marker of the beginning. table=%s", tableID)
+			}
+			current.code += line
+			blocks = append(blocks, current)
+			current = mergeBlock{}
+		default:
+			current.code += line
+		}
+	}
+	if current.tableID != "" {
+		return nil, fmt.Errorf("marker of the table is not closed. table=%s", current.tableID)
+	}
+	if current.code != "" {
+		blocks = append(blocks, current)
+	}
+
+	return blocks, nil
+}
+
+// close closes and removes the temporary file.
+func (w *structsWriter) close() {
+	if err := w.file.Close(); err != nil {
+		warnln("w.file.Close: " + err.Error())
+	}
+	if err := os.Remove(w.file.Name()); err != nil {
+		warnln("os.Remove: " + err.Error())
+	}
+}
+
+// generateTableFiles generates the code of each table in dataset, and writes it to the path of outputTemplate. See expandOutputTemplate.
+// outputPaths is the tables by the paths already written, to avoid overwriting them. The paths of dataset are added to it.
+// The result has no Bytes.
+func generateTableFiles(ctx context.Context, client *bigquery.Client, project, dataset, outputTemplate string, outputPaths map[string]string, config outputConfig, opts Options) (result *GenerateResult, err error) {
+	tables, truncated, err := getTables(ctx, client, project, dataset, opts)
+	if err != nil {
+		return nil, fmt.Errorf("getTables: %w", err)
+	}
+	tables, opts = applyWildcards(tables, opts)
+
+	// NOTE(djeeno): The paths are validated before writing any file.
+	for _, table := range tables {
+		outputPath := filepath.Clean(expandOutputTemplate(outputTemplate, project, dataset, table.TableID))
+		if fullID, ok := outputPaths[outputPath]; ok {
+			return nil, fmt.Errorf("output path of the tables conflict. path=%s tables=%s,%s", outputPath, fullID, table.FullyQualifiedName())
+		}
+		outputPaths[outputPath] = table.FullyQualifiedName()
+		// NOTE(djeeno): The files in the archive are of the downstream repositories, that are not in the filesystem.
+		if config.archive != nil {
+			continue
+		}
+		if err = checkHelperImport(outputPath, opts); err != nil {
+			return nil, fmt.Errorf("checkHelperImport: %w", err)
+		}
+	}
+
+	// NOTE(djeeno): The files of the tables are in the same package, so that the struct names are reserved for all the tables by generateTables.
+	result, err = generateTables(ctx, client, project, dataset, tables, nil, opts, tableHooks{
+		skip: func(table *bigquery.Table) bool {
+			return skipExistingFile(expandOutputTemplate(outputTemplate, project, dataset, table.TableID), config)
+		},
+		emit: func(table *bigquery.Table, structCode string, importPackages []string) error {
+			code, err := generateFileCode(project, dataset, importPackages, structCode, opts)
+			if err != nil {
+				return fmt.Errorf("generateFileCode: %w", err)
+			}
+			if err = outputGeneratedCode(expandOutputTemplate(outputTemplate, project, dataset, table.TableID), code, config); err != nil {
+				return fmt.Errorf("outputGeneratedCode: %w", err)
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("generateTables: %w", err)
+	}
+	result.Truncated = truncated
+
+	return result, nil
+}
+
+// pruneGeneratedFiles removes the generated files of datasets that match outputTemplate but are not in outputPaths, the paths of the current tables.
+// The files of the other datasets and projects than datasets, the files without the "Code generated ... DO NOT EDIT." header,
+// and the files written in the run by the keys of written, e.g. the companion test files, are never removed. See outputTemplateGlob and writtenFileKey.
+func pruneGeneratedFiles(outputTemplate string, datasets []datasetRef, outputPaths map[string]string, written map[string]bool) (pruned []string, err error) {
+	var files []string
+	seen := make(map[string]bool)
+	for _, ref := range datasets {
+		var matches []string
+		matches, err = filepath.Glob(outputTemplateGlob(outputTemplate, ref.project, ref.dataset))
+		if err != nil {
+			return nil, fmt.Errorf("filepath.Glob: %w", err)
+		}
+		// NOTE(djeeno): The patterns of the datasets are the same if outputTemplate has neither "{project}" nor "{dataset}".
+		for _, file := range matches {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+
+	for _, file := range files {
+		if _, ok := outputPaths[filepath.Clean(file)]; ok {
+			continue
+		}
+		// NOTE(djeeno): The glob of outputTemplate may match the other files written in the run, e.g. "models/*.go" of "models/users_test.go".
+		if written[writtenFileKey(file)] {
+			continue
+		}
+		var content []byte
+		content, err = readFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("readFile: %w", err)
+		}
+		if !isGeneratedCode(content) {
+			continue
+		}
+		if err = os.Remove(file); err != nil {
+			return nil, fmt.Errorf("os.Remove: %w", err)
+		}
+		pruned = append(pruned, file)
+	}
+
+	return pruned, nil
+}
+
+// generatedCodeComment matches the comment of the generated code.
+// ref. https://golang.org/s/generatedcode
+var generatedCodeComment = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.\r?$`)
+
+// isGeneratedCode reports whether content has the comment of the generated code before the package clause.
+func isGeneratedCode(content []byte) bool {
+	loc := generatedCodeComment.FindIndex(content)
+	if loc == nil {
+		return false
+	}
+	return !bytes.Contains(content[:loc[0]], []byte("package "))
+}
+
+// outputTemplateGlob returns the glob pattern of the files of the tables of project.dataset of outputTemplate,
+// that has "*" for "{table}", and the other parts of outputTemplate escaped, e.g. "models/[[]v2]/*.go" of "models/[v2]/{table}.go". See escapeGlob.
+func outputTemplateGlob(outputTemplate, project, dataset string) (pattern string) {
+	parts := strings.Split(outputTemplate, "{table}")
+	for i, part := range parts {
+		parts[i] = escapeGlob(expandOutputTemplate(part, project, dataset, ""))
+	}
+	return strings.Join(parts, "*")
+}
+
+// escapeGlob escapes the meta characters of filepath.Match in s by the character classes, e.g. "[*]" of "*", so that the pattern matches s literally.
+// NOTE(djeeno): "\\" is the path separator on Windows, and escapes the characters on the others.
+func escapeGlob(s string) (escaped string) {
+	var b strings.Builder
+	for _, c := range s {
+		switch {
+		case c == '*' || c == '?' || c == '[':
+			b.WriteString("[" + string(c) + "]")
+		case c == '\\' && runtime.GOOS != "windows":
+			b.WriteString(`\\`)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// expandOutputTemplate replaces the placeholders "{project}", "{dataset}" and "{table}" in outputTemplate, e.g. "models/{table}_model.go".
+// The table ID of the wildcard table is of its prefix without the trailing "_", e.g. "events" of "events_*", because "*" is not for the file names.
+func expandOutputTemplate(outputTemplate, project, dataset, table string) (outputPath string) {
+	if strings.HasSuffix(table, "*") {
+		table = strings.TrimSuffix(strings.TrimSuffix(table, "*"), "_")
+	}
+	return strings.NewReplacer("{project}", project, "{dataset}", dataset, "{table}", table).Replace(outputTemplate)
+}
+
+// outputConfig is the configuration of the output of the generated files.
+type outputConfig struct {
+	// lineEnding is the line ending of the generated files. lineEndingLF or lineEndingCRLF.
+	lineEnding string
+	// fileMode is the permission of the generated files.
+	fileMode os.FileMode
+	// compileTest writes the companion test file of each generated file. See generateCompileTestCode.
+	compileTest bool
+	// skipExisting does not write the generated files that already exist. See skipExistingFile.
+	skipExisting bool
+	// partialOnInterrupt writes the structs generated before the context is canceled by the interrupt. See notifyInterrupt.
+	partialOnInterrupt bool
+	// archive is the zip archive to write the generated files into instead of the filesystem, or nil. See createOutputArchive.
+	archive *outputArchive
+	// merge replaces only the code of the generated tables in the existing files. See structsWriter.mergeFile.
+	merge bool
+	// written is the registry of the files written in the run by writtenFileKey, not to prune them, or nil not to record them.
+	// It is shared by the copies of outputConfig.
+	written map[string]bool
+}
+
+// outputArchive is the zip archive of the generated files.
+type outputArchive struct {
+	file   *os.File
+	writer *zip.Writer
+	// paths is the paths of the files written, to avoid the duplicated entries.
+	paths map[string]bool
+}
+
+// createOutputArchive creates the zip archive zipPath with fileMode.
+func createOutputArchive(zipPath string, fileMode os.FileMode) (archive *outputArchive, err error) {
+	if err = mkdirIfNotExist(filepath.Dir(zipPath)); err != nil {
+		return nil, fmt.Errorf("mkdirIfNotExist: %w", err)
+	}
+	file, err := os.OpenFile(zipPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileMode)
+	if err != nil {
+		return nil, fmt.Errorf("os.OpenFile: %w", err)
+	}
+
+	return &outputArchive{file: file, writer: zip.NewWriter(file), paths: make(map[string]bool)}, nil
+}
+
+// write writes content to the entry of filePath with fileMode. filePath must be relative and in the archive, e.g. not "../models/users.go".
+// The modification time is not recorded, so that the archive of the same files is the same.
+func (a *outputArchive) write(filePath string, fileMode os.FileMode, content []byte) (err error) {
+	name := filepath.ToSlash(filepath.Clean(filePath))
+	if filepath.IsAbs(filePath) || name == ".." || strings.HasPrefix(name, "../") {
+		return fmt.Errorf("path of the file in the archive must be relative and in the archive. path=%s", filePath)
+	}
+	if a.paths[name] {
+		return fmt.Errorf("file is already written in the archive. path=%s", name)
+	}
+	a.paths[name] = true
+
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate}
+	header.SetMode(fileMode)
+	w, err := a.writer.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("a.writer.CreateHeader: %w", err)
+	}
+	if _, err = w.Write(content); err != nil {
+		return fmt.Errorf("w.Write: %w", err)
+	}
+
+	return nil
+}
+
+// close writes the central directory of the archive and closes the file. The file is removed unless commit, or on the errors.
+func (a *outputArchive) close(commit bool) (err error) {
+	err = a.writer.Close()
+	if closeErr := a.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || !commit {
+		_ = os.Remove(a.file.Name())
+	}
+	if err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+
+	return nil
+}
+
+// writeOutputFile writes content to filePath with config.fileMode, or to the entry of filePath in config.archive if any.
+func writeOutputFile(filePath string, content []byte, config outputConfig) (err error) {
+	if config.archive != nil {
+		if err = config.archive.write(filePath, config.fileMode, content); err != nil {
+			return fmt.Errorf("config.archive.write: %w", err)
+		}
+		return nil
+	}
+
+	config.recordWritten(filePath)
+	if err = writeGeneratedCode(filePath, config.fileMode, content); err != nil {
+		return fmt.Errorf("writeGeneratedCode: %w", err)
+	}
+
+	return nil
+}
+
+// recordWritten records filePath in c.written if any.
+// NOTE(djeeno): The file is recorded before it is written, so that the file written partially is not pruned either.
+func (c outputConfig) recordWritten(filePath string) {
+	if c.written != nil && filePath != filePathStdout {
+		c.written[writtenFileKey(filePath)] = true
+	}
+}
+
+// writtenFileKey returns the absolute path of filePath, so that the relative and the absolute paths of the same file are the same key,
+// or the clean path of filePath if the absolute path is not available.
+func writtenFileKey(filePath string) (key string) {
+	key, err := filepath.Abs(filePath)
+	if err != nil {
+		return filepath.Clean(filePath)
+	}
+	return key
+}
+
+// skipExistingFile reports whether filePath is not written because it already exists and config.skipExisting.
+func skipExistingFile(filePath string, config outputConfig) bool {
+	if !config.skipExisting || filePath == filePathStdout {
+		return false
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		return false
+	}
+
+	infoln("skip the existing file: " + filePath)
+	return true
+}
+
+// outputGeneratedCode converts the line ending of generatedCode to config.lineEnding and writes it to filePath with config.fileMode.
+// It does not write filePath that already exists if config.skipExisting.
+func outputGeneratedCode(filePath string, generatedCode []byte, config outputConfig) (err error) {
+	if skipExistingFile(filePath, config) {
+		return nil
+	}
+
+	var compileTestCode []byte
+	if config.compileTest {
+		compileTestCode, err = generateCompileTestCode(filePath, generatedCode)
+		if err != nil {
+			return fmt.Errorf("generateCompileTestCode: %w", err)
+		}
+	}
+
+	generatedCode, err = convertLineEnding(generatedCode, config.lineEnding)
+	if err != nil {
+		return fmt.Errorf("convertLineEnding: %w", err)
+	}
+
+	// NOTE(djeeno): output
+	if err = writeOutputFile(filePath, generatedCode, config); err != nil {
+		return fmt.Errorf("writeOutputFile: %w", err)
+	}
+
+	if config.compileTest {
+		if err = writeCompileTestCode(filePath, compileTestCode, config); err != nil {
+			return fmt.Errorf("writeCompileTestCode: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// outputCompileTestCode generates the companion test of generatedCode, that is written to filePath, and writes it.
+func outputCompileTestCode(filePath string, generatedCode []byte, config outputConfig) (err error) {
+	compileTestCode, err := generateCompileTestCode(filePath, generatedCode)
+	if err != nil {
+		return fmt.Errorf("generateCompileTestCode: %w", err)
+	}
+
+	if err = writeCompileTestCode(filePath, compileTestCode, config); err != nil {
+		return fmt.Errorf("writeCompileTestCode: %w", err)
+	}
+
+	return nil
+}
+
+// writeCompileTestCode converts the line ending of compileTestCode to config.lineEnding and writes it to the companion test file of filePath.
+func writeCompileTestCode(filePath string, compileTestCode []byte, config outputConfig) (err error) {
+	compileTestCode, err = convertLineEnding(compileTestCode, config.lineEnding)
+	if err != nil {
+		return fmt.Errorf("convertLineEnding: %w", err)
+	}
+
+	if err = writeOutputFile(compileTestFilePath(filePath), compileTestCode, config); err != nil {
+		return fmt.Errorf("writeOutputFile: %w", err)
+	}
+
+	return nil
+}
+
+// compileTestFilePath returns the path of the companion test file of filePath, e.g. "models/users_test.go" for "models/users.go".
+func compileTestFilePath(filePath string) (testFilePath string) {
+	return strings.TrimSuffix(filePath, ".go") + "_test.go"
+}
+
+// generateCompileTestCode generates the code of the companion test of generatedCode, the generated file written to filePath.
+// The test references the types, the methods and the constants declared in generatedCode,
+// so that `go test` fails if generatedCode does not compile.
+func generateCompileTestCode(filePath string, generatedCode []byte) (compileTestCode []byte, err error) {
+	file, err := parser.ParseFile(token.NewFileSet(), filePath, generatedCode, 0)
+	if err != nil {
+		return nil, fmt.Errorf("parser.ParseFile: %w", err)
+	}
+
+	var references string
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					references = references + "\tvar _ " + spec.Name.Name + "\n"
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						if name.Name != "_" {
+							references = references + "\t_ = " + name.Name + "\n"
+						}
+					}
+				}
+			}
+		case *ast.FuncDecl:
+			if decl.Recv == nil || len(decl.Recv.List) == 0 {
+				references = references + "\t_ = " + decl.Name.Name + "\n"
+				continue
+			}
+			// NOTE(djeeno): The method set of the pointer type has the methods of both the value receivers and the pointer receivers.
+			recvType := decl.Recv.List[0].Type
+			if star, ok := recvType.(*ast.StarExpr); ok {
+				recvType = star.X
+			}
+			if ident, ok := recvType.(*ast.Ident); ok {
+				references = references + "\t_ = (*" + ident.Name + ")." + decl.Name.Name + "\n"
+			}
+		}
+	}
+
+	testName := "TestCompile_" + sanitizeIdentifier(strings.TrimSuffix(filepath.Base(filePath), ".go"))
+	compileTestCode = []byte(generatedCodeHeader + "\n\n" +
+		"package " + file.Name.Name + "\n\n" +
+		"import \"testing\"\n\n" +
+		"// " + testName + " fails to compile if " + filepath.Base(filePath) + " does not compile.\n" +
+		"func " + testName + "(t *testing.T) {\n" +
+		references +
+		"}\n")
+
+	compileTestCode, err = format.Source(compileTestCode)
+	if err != nil {
+		return nil, fmt.Errorf("format.Source: %w", err)
+	}
+
+	return compileTestCode, nil
+}
+
+// parseFileMode parses fileMode, the octal permission of the generated files, e.g. "0600".
+func parseFileMode(fileMode string) (mode os.FileMode, err error) {
+	perm, err := strconv.ParseUint(fileMode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("strconv.ParseUint: %w", err)
+	}
+	if os.FileMode(perm)&^os.ModePerm != 0 {
+		return 0, fmt.Errorf("file mode is not a permission. fileMode=%s", fileMode)
+	}
+	return os.FileMode(perm), nil
+}
+
+// outputFilePath returns filePath, or the file named after dataset in outputDir if outputDir is not empty.
+func outputFilePath(filePath, outputDir, dataset string) (outputPath string) {
+	if outputDir == "" {
+		return filePath
+	}
+	return filepath.Join(outputDir, dataset+generatedFileSuffix)
+}
+
+// convertLineEnding converts the line ending of generatedCode from LF to lineEnding.
+// The newlines in the raw string literals of Go are kept as is, so that their values do not change by the line ending.
+// generatedCode that cannot be scanned as Go, e.g. of Options.Template, is converted entirely.
+func convertLineEnding(generatedCode []byte, lineEnding string) (converted []byte, err error) {
+	switch lineEnding {
+	case lineEndingLF:
+		return generatedCode, nil
+	case lineEndingCRLF:
+		converted = make([]byte, 0, len(generatedCode)+bytes.Count(generatedCode, []byte("\n")))
+		offset := 0
+		for _, literal := range rawStringLiterals(generatedCode) {
+			converted = append(converted, bytes.ReplaceAll(generatedCode[offset:literal[0]], []byte("\n"), []byte("\r\n"))...)
+			converted = append(converted, generatedCode[literal[0]:literal[1]]...)
+			offset = literal[1]
+		}
+		return append(converted, bytes.ReplaceAll(generatedCode[offset:], []byte("\n"), []byte("\r\n"))...), nil
+	default:
+		return nil, fmt.Errorf("line ending not supported. lineEnding=%s", lineEnding)
+	}
+}
+
+// rawStringLiterals returns the offsets of the beginnings and the ends of the raw string literals in code, that has no carriage returns,
+// or nil if code cannot be scanned as Go.
+func rawStringLiterals(code []byte) (literals [][2]int) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(code))
+	var s scanner.Scanner
+	invalid := false
+	s.Init(file, code, func(token.Position, string) { invalid = true }, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.STRING && strings.HasPrefix(lit, "`") {
+			offset := file.Offset(pos)
+			literals = append(literals, [2]int{offset, offset + len(lit)})
+		}
+	}
+	if invalid {
+		return nil
+	}
+	return literals
+}
+
+// writeGeneratedCode writes generatedCode to filePath with fileMode, or to stdout if filePath is filePathStdout.
+func writeGeneratedCode(filePath string, fileMode os.FileMode, generatedCode []byte) (err error) {
+	if err = writeGeneratedCodeFrom(filePath, fileMode, bytes.NewReader(generatedCode)); err != nil {
+		return fmt.Errorf("writeGeneratedCodeFrom: %w", err)
+	}
+
+	return nil
+}
+
+// writeGeneratedCodeFrom writes the generated code read from src to filePath with fileMode, or to stdout if filePath is filePathStdout.
+func writeGeneratedCodeFrom(filePath string, fileMode os.FileMode, src io.Reader) (err error) {
+	if filePath == filePathStdout {
+		if _, err = io.Copy(os.Stdout, src); err != nil {
+			return fmt.Errorf("io.Copy: %w", err)
+		}
+		return nil
+	}
+
+	if err = mkdirIfNotExist(filepath.Dir(filePath)); err != nil {
+		return fmt.Errorf("mkdirIfNotExist: %w", err)
+	}
+
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileMode)
+	if err != nil {
+		return fmt.Errorf("os.OpenFile: %w", err)
+	}
+	if _, err = io.Copy(file, src); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("io.Copy: %w", err)
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("file.Close: %w", err)
+	}
+
+	// NOTE(djeeno): os.OpenFile does not change the permission of the existing file, and the permission is masked by umask.
+	if err = os.Chmod(filePath, fileMode); err != nil {
+		return fmt.Errorf("os.Chmod: %w", err)
+	}
+
+	return nil
+}
+
+// mkdirIfNotExist creates dir and its parent directories if they do not exist.
+// It returns error if dir exists but is not a directory.
+func mkdirIfNotExist(dir string) (err error) {
+	var info os.FileInfo
+	if info, err = os.Stat(dir); err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("output directory exists but is not a directory. path=%s", dir)
+		}
+		return nil
+	}
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("os.MkdirAll: %w", err)
+	}
+
+	return nil
+}
+
+// GenerateResult is the result of Generate.
+type GenerateResult struct {
+	// Structs is the number of the generated structs.
+	Structs int
+	// Skipped is the table IDs that are skipped because of errors, or because they are not of Options.Partitioning.
+	Skipped []string
+	// Bytes is the generated code.
+	Bytes []byte
+	// SchemaVersion is the hash of the schemas of the generated tables, that is the same for the same column names, types and modes. See schemaVersion.
+	SchemaVersion string
+	// Truncated reports whether the listing of the tables is cut short by Options.SkipForbidden, so that the tables not listed are not generated.
+	Truncated bool
+}
+
+// Generate generates the code of the schema structs of the tables in project.dataset.
+// opts is validated by Options.Validate, and the error is *OptionsError.
+func Generate(ctx context.Context, client *bigquery.Client, project, dataset string, opts Options) (result *GenerateResult, err error) {
+	if err = opts.Validate(); err != nil {
+		return nil, fmt.Errorf("opts.Validate: %w", err)
+	}
+
+	tables, truncated, err := getTables(ctx, client, project, dataset, opts)
+	if err != nil {
+		return nil, fmt.Errorf("getTables: %w", err)
+	}
+	tables, opts = applyWildcards(tables, opts)
+
+	var tail strings.Builder
+	var importPackages []string
+	result, err = generateTables(ctx, client, project, dataset, tables, nil, opts, tableHooks{
+		emit: func(_ *bigquery.Table, structCode string, pkgs []string) error {
+			importPackages = append(importPackages, pkgs...)
+			tail.WriteString(structCode)
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("generateTables: %w", err)
+	}
+	result.Truncated = truncated
+
+	opts.schemaVersion = result.SchemaVersion
+	result.Bytes, err = generateFileCode(project, dataset, importPackages, tail.String(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("generateFileCode: %w", err)
+	}
+
+	return result, nil
+}
+
+// GenerateSchemaJSON generates the JSON object of the schemas of the tables in project.dataset by the table IDs,
+// e.g. {"table": [{"name": "id", "type": "INTEGER", "mode": "REQUIRED"}]}, for the tools other than Go.
+// The schema of each table is in the format of `bq show --schema`, that can be read by GenerateFromSchemaFile.
+// The schemas are fetched and filtered by Options.ExcludeColumns and Options.RequiredOnly as Generate,
+// but the options of the Go code, e.g. Options.Nullable, are ignored.
+// Structs of the result is the number of the tables in the JSON.
+// opts is validated by Options.Validate, and the error is *OptionsError.
+func GenerateSchemaJSON(ctx context.Context, client *bigquery.Client, project, dataset string, opts Options) (result *GenerateResult, err error) {
+	if err = opts.Validate(); err != nil {
+		return nil, fmt.Errorf("opts.Validate: %w", err)
+	}
+
+	tables, truncated, err := getTables(ctx, client, project, dataset, opts)
+	if err != nil {
+		return nil, fmt.Errorf("getTables: %w", err)
+	}
+	tables, opts = applyWildcards(tables, opts)
+
+	schemas := make(map[string]json.RawMessage)
+	result, err = generateTables(ctx, client, project, dataset, tables, nil, opts, tableHooks{
+		generate: func(table *bigquery.Table, md *bigquery.TableMetadata, _ *generation) (string, []string, error) {
+			fields, err := json.Marshal(schemaJSONFields(filterSchema(table.TableID, "", md.Schema, opts)))
+			if err != nil {
+				return "", nil, fmt.Errorf("json.Marshal: %w", err)
+			}
+			return string(fields), nil, nil
+		},
+		emit: func(table *bigquery.Table, fields string, _ []string) error {
+			schemas[table.TableID] = json.RawMessage(fields)
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("generateTables: %w", err)
+	}
+	result.Truncated = truncated
+
+	// NOTE(djeeno): The keys of the map are sorted by encoding/json, so that the output is stable.
+	result.Bytes, err = json.MarshalIndent(schemas, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("json.MarshalIndent: %w", err)
+	}
+	result.Bytes = append(result.Bytes, '\n')
+
+	return result, nil
+}
+
+// schemaJSONField is the column of the schema JSON in the format of `bq show --schema`, that bigquery.SchemaFromJSON reads.
+// ref. https://cloud.google.com/bigquery/docs/schemas#specifying_a_json_schema_file
+type schemaJSONField struct {
+	Name        string               `json:"name"`
+	Type        string               `json:"type"`
+	Mode        string               `json:"mode"`
+	Description string               `json:"description,omitempty"`
+	PolicyTags  *schemaJSONPolicyTag `json:"policyTags,omitempty"`
+	Fields      []schemaJSONField    `json:"fields,omitempty"`
+}
+
+// schemaJSONPolicyTag is the policy tags of schemaJSONField.
+type schemaJSONPolicyTag struct {
+	Names []string `json:"names"`
+}
+
+// schemaJSONFields returns the columns of the schema JSON of schemas. The columns not REQUIRED nor REPEATED are NULLABLE.
+func schemaJSONFields(schemas bigquery.Schema) (fields []schemaJSONField) {
+	fields = make([]schemaJSONField, 0, len(schemas))
+	for _, schema := range schemas {
+		field := schemaJSONField{Name: schema.Name, Type: string(schema.Type), Mode: "NULLABLE", Description: schema.Description}
+		switch {
+		case schema.Repeated:
+			field.Mode = "REPEATED"
+		case schema.Required:
+			field.Mode = "REQUIRED"
+		}
+		if schema.PolicyTags != nil && len(schema.PolicyTags.Names) > 0 {
+			field.PolicyTags = &schemaJSONPolicyTag{Names: schema.PolicyTags.Names}
+		}
+		if len(schema.Schema) > 0 {
+			field.Fields = schemaJSONFields(schema.Schema)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// tableHooks is the hooks of generateTables for each table. skip and generate may be nil.
+type tableHooks struct {
+	// skip reports whether table is skipped before its metadata is fetched, e.g. the table of the existing file.
+	skip func(table *bigquery.Table) bool
+	// generate generates the code of table by md instead of generateTableMetadataCode, e.g. of the added columns.
+	// The table of the empty code is neither counted nor emitted. The error skips the table with the warning.
+	generate func(table *bigquery.Table, md *bigquery.TableMetadata, gen *generation) (structCode string, importPackages []string, err error)
+	// emit receives the code of the schema struct of table and its import packages in the order of the tables. The error aborts generateTables.
+	emit func(table *bigquery.Table, structCode string, importPackages []string) error
+}
+
+// generateTables generates the code of the schema struct of each table of tables in project.dataset, that are of applyWildcards,
+// and passes it and its import packages to hooks.emit in the order of tables. The struct names of all of tables are reserved in gen, or in newGeneration if nil,
+// including the tables that are skipped. The tables that fail are warned and skipped. The result has no Bytes.
+func generateTables(ctx context.Context, client *bigquery.Client, project, dataset string, tables []*bigquery.Table, gen *generation, opts Options, hooks tableHooks) (result *GenerateResult, err error) {
+	if gen == nil {
+		gen = newGeneration(tables, opts)
+	}
+	gen.schemaHashes = make(map[string]string)
+	generate := hooks.generate
+	if generate == nil {
+		generate = func(table *bigquery.Table, md *bigquery.TableMetadata, gen *generation) (string, []string, error) {
+			return generateTableMetadataCode(table, md, gen, opts)
+		}
+	}
+
+	metadata := informationSchemaMetadata(ctx, client, project, dataset, opts)
+
+	result = &GenerateResult{}
+	for i, table := range tables {
+		if hooks.skip != nil && hooks.skip(table) {
+			continue
+		}
+		printProgress(i+1, len(tables), "generating", table.TableID)
+		var md *bigquery.TableMetadata
+		md, err = tableSchemaMetadata(ctx, client, table, metadata, opts)
+		if errors.Is(err, errPartitioningNotMatched) {
+			infoTableln(table.TableID, "skip the table not of the partitioning "+opts.Partitioning)
+			result.Skipped = append(result.Skipped, table.TableID)
+			continue
+		}
+		if err != nil {
+			warnTableln(table.TableID, "tableSchemaMetadata: "+err.Error())
+			result.Skipped = append(result.Skipped, table.TableID)
+			continue
+		}
+		var structCode string
+		var pkgs []string
+		structCode, pkgs, err = generate(table, md, gen)
+		if err != nil {
+			warnTableln(table.TableID, "generate: "+err.Error())
+			result.Skipped = append(result.Skipped, table.TableID)
+			continue
+		}
+		if structCode == "" {
+			continue
+		}
+
+		result.Structs++
+		// NOTE(djeeno): The packages of each table are returned and merged by emit, instead of being added to the shared set.
+		// gen is shared by the tables and is not guarded, so that the tables of one generation must be generated in one goroutine.
+		if err = hooks.emit(table, structCode, pkgs); err != nil {
+			return nil, fmt.Errorf("hooks.emit: %w", err)
+		}
+	}
+	// NOTE(djeeno): The generated code may be written to stdout on the same terminal.
+	clearProgress()
+	result.SchemaVersion = schemaVersion(gen.schemaHashes)
+
+	return result, nil
+}
+
+// GenerateFromSchemaFile generates the code of the schema struct from schemaFile, the BigQuery schema JSON file
+// (e.g. the output of `bq show --schema`), without accessing BigQuery.
+// The table ID is the base name of schemaFile without the extension. project and dataset may be empty.
+// opts is validated as Generate.
+func GenerateFromSchemaFile(project, dataset, schemaFile string, opts Options) (result *GenerateResult, err error) {
+	if err = opts.Validate(); err != nil {
+		return nil, fmt.Errorf("opts.Validate: %w", err)
+	}
+
+	content, err := readFile(schemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("readFile: %w", err)
+	}
+
+	schema, err := bigquery.SchemaFromJSON(content)
+	if err != nil {
+		return nil, fmt.Errorf("bigquery.SchemaFromJSON: %w", err)
+	}
+
+	table := &bigquery.Table{ProjectID: project, DatasetID: dataset, TableID: schemaFileTableID(schemaFile)}
+	md := &bigquery.TableMetadata{Schema: schema, FullID: table.TableID}
+	if project != "" && dataset != "" {
+		md.FullID = project + ":" + dataset + "." + table.TableID
+	}
+
+	gen := newGeneration([]*bigquery.Table{table}, opts)
+	gen.schemaHashes = make(map[string]string)
+	structCode, importPackages, err := generateTableMetadataCode(table, md, gen, opts)
+	if err != nil {
+		return nil, fmt.Errorf("generateTableMetadataCode: %w", err)
+	}
+
+	opts.schemaVersion = schemaVersion(gen.schemaHashes)
+	generatedCode, err := generateFileCode(project, dataset, importPackages, structCode, opts)
+	if err != nil {
+		return nil, fmt.Errorf("generateFileCode: %w", err)
+	}
+
+	return &GenerateResult{Structs: 1, Bytes: generatedCode, SchemaVersion: opts.schemaVersion}, nil
+}
+
+// schemaFileTableID returns the table ID of schemaFile, the base name without the extension.
+func schemaFileTableID(schemaFile string) (tableID string) {
+	return strings.TrimSuffix(filepath.Base(schemaFile), filepath.Ext(schemaFile))
+}
+
+const (
+	driftAdded   = "added"
+	driftRemoved = "removed"
+	driftChanged = "changed"
+)
+
+// Drift is the difference between the struct in the generated file and the live schema of the table.
+type Drift struct {
+	// TableID is the ID of the table.
+	TableID string
+	// Column is the path of the column, e.g. "record.column", or empty if the table itself is added or removed.
+	Column string
+	// Kind is driftAdded, driftRemoved or driftChanged.
+	Kind string
+	// Generated is the Go type of the column in the generated file.
+	Generated string
+	// Live is the Go type of the column of the live schema.
+	Live string
+}
+
+// String returns the human-readable description of d.
+func (d Drift) String() string {
+	switch {
+	case d.Column == "":
+		return "table `" + d.TableID + "` is " + d.Kind
+	case d.Kind == driftAdded:
+		return "table `" + d.TableID + "`: column `" + d.Column + "` is added: " + d.Live
+	case d.Kind == driftRemoved:
+		return "table `" + d.TableID + "`: column `" + d.Column + "` is removed: " + d.Generated
+	default:
+		return "table `" + d.TableID + "`: column `" + d.Column + "` is changed: " + d.Generated + " -> " + d.Live
+	}
+}
+
+// Diff compares the structs in filePath, the file generated with opts, with the live schemas of the tables in dataset,
+// and returns the drifts ordered by the table ID and the column.
+// opts is validated by Options.Validate, and the error is *OptionsError.
+func Diff(ctx context.Context, client *bigquery.Client, project, dataset, filePath string, opts Options) (drifts []Drift, err error) {
+	if err = opts.Validate(); err != nil {
+		return nil, fmt.Errorf("opts.Validate: %w", err)
+	}
+
+	generated, err := parseGeneratedFile(filePath, structTagKey(opts))
+	if err != nil {
+		return nil, fmt.Errorf("parseGeneratedFile: %w", err)
+	}
+
+	tables, truncated, err := getAllTables(ctx, client, project, dataset, opts.SkipForbidden)
+	if err != nil {
+		return nil, fmt.Errorf("getAllTables: %w", err)
+	}
+	// NOTE(djeeno): The tables not listed would be reported as removed.
+	if truncated {
+		return nil, fmt.Errorf("listing of the tables of %s.%s is cut short by 403 Forbidden, so that the removed tables cannot be found", project, dataset)
+	}
+
+	// NOTE(djeeno): The names of the nested structs are not compared, because they are not the part of the schemas.
+	gen := newGeneration(nil, opts)
+	live := make(map[string]map[string]string)
+	for _, table := range tables {
+		var md *bigquery.TableMetadata
+		md, err = table.Metadata(ctx)
+		if err != nil {
+			// NOTE(djeeno): The table that fails is skipped as Generate skips it, so that the drifts of the other tables are reported.
+			warnTableln(table.TableID, "table.Metadata: "+err.Error())
+			delete(generated, table.TableID)
+			continue
+		}
+		// NOTE(djeeno): The tables that are skipped by Generate because of opts.Partitioning are not compared, instead of being reported as added.
+		if !matchPartitioning(md, opts.Partitioning) {
+			delete(generated, table.TableID)
+			continue
+		}
+		columnTypes := make(map[string]string)
+		if err = schemaColumnTypes(table.TableID, "", md.Schema, gen, opts, columnTypes); err != nil {
+			// NOTE(djeeno): The tables that are skipped by Generate, e.g. of the types not supported, are not compared.
+			warnTableln(table.TableID, "schemaColumnTypes: "+err.Error())
+			delete(generated, table.TableID)
+			continue
+		}
+		live[table.TableID] = columnTypes
+	}
+
+	return diffTables(generated, live), nil
+}
+
+// addedStructSuffix is the suffix of the struct names of the added columns. See GenerateAdded.
+const addedStructSuffix = "Added"
+
+// GenerateAdded generates the code of the "<Table>Added" structs, that have only the columns of the tables in dataset
+// that are not in filePath, the file generated with opts. The RECORD columns that have the added columns have only them.
+// The tables that have no added columns are skipped.
+// opts is validated by Options.Validate, and the error is *OptionsError.
+func GenerateAdded(ctx context.Context, client *bigquery.Client, project, dataset, filePath string, opts Options) (result *GenerateResult, err error) {
+	if err = opts.Validate(); err != nil {
+		return nil, fmt.Errorf("opts.Validate: %w", err)
+	}
+
+	generated, err := parseGeneratedFile(filePath, structTagKey(opts))
+	if err != nil {
+		return nil, fmt.Errorf("parseGeneratedFile: %w", err)
+	}
+
+	tables, _, err := getAllTables(ctx, client, project, dataset, opts.SkipForbidden)
+	if err != nil {
+		return nil, fmt.Errorf("getAllTables: %w", err)
+	}
+
+	opts.NameMapper = addedNameMapper(opts.NameMapper)
+	// NOTE(djeeno): The file of the added columns is not regenerated by go generate.
+	opts.omitGenerateDirective = true
+
+	var tail strings.Builder
+	var importPackages []string
+	result, err = generateTables(ctx, client, project, dataset, tables, nil, opts, tableHooks{
+		generate: func(table *bigquery.Table, md *bigquery.TableMetadata, gen *generation) (string, []string, error) {
+			// NOTE(djeeno): The columns of the table that is not in filePath are all added.
+			added := addedColumns("", md.Schema, generated[table.TableID])
+			if len(added) == 0 {
+				return "", nil, nil
+			}
+			addedMetadata := *md
+			addedMetadata.Schema = added
+			return generateTableMetadataCode(table, &addedMetadata, gen, opts)
+		},
+		emit: func(_ *bigquery.Table, structCode string, pkgs []string) error {
+			importPackages = append(importPackages, pkgs...)
+			tail.WriteString(structCode)
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("generateTables: %w", err)
+	}
+
+	result.Bytes, err = generateFileCode(project, dataset, importPackages, tail.String(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("generateFileCode: %w", err)
+	}
+
+	return result, nil
+}
+
+// addedNameMapper returns the NameMapper that suffixes the struct names of nameMapper with addedStructSuffix, e.g. "UsersAdded".
+func addedNameMapper(nameMapper NameMapper) NameMapper {
+	if nameMapper == nil {
+		nameMapper = DefaultNameMapper
+	}
+	return func(tableID, columnName string) (name string) {
+		if columnName == "" {
+			return nameMapper(tableID, columnName) + addedStructSuffix
+		}
+		return nameMapper(tableID, columnName)
+	}
+}
+
+// addedColumns returns the columns in schemas that are not in columnTypes, the Go types of the generated columns by the column paths.
+// The RECORD columns in columnTypes are returned with their added columns only, if any.
+func addedColumns(columnPrefix string, schemas bigquery.Schema, columnTypes map[string]string) (added bigquery.Schema) {
+	for _, schema := range schemas {
+		if _, ok := columnTypes[columnPrefix+schema.Name]; !ok {
+			added = append(added, schema)
+			continue
+		}
+		if schema.Type != bigquery.RecordFieldType {
+			continue
+		}
+		if nested := addedColumns(columnPrefix+schema.Name+".", schema.Schema, columnTypes); len(nested) > 0 {
+			record := *schema
+			record.Schema = nested
+			added = append(added, &record)
+		}
+	}
+	return added
+}
+
+// diffTables returns the drifts from generated to live, the Go types of the columns by the table IDs.
+func diffTables(generated, live map[string]map[string]string) (drifts []Drift) {
+	tableIDs := make([]string, 0, len(generated))
+	for tableID := range generated {
+		tableIDs = append(tableIDs, tableID)
+	}
+	for tableID := range live {
+		if _, ok := generated[tableID]; !ok {
+			tableIDs = append(tableIDs, tableID)
+		}
+	}
+	sort.Strings(tableIDs)
+
+	for _, tableID := range tableIDs {
+		generatedColumns, inGenerated := generated[tableID]
+		liveColumns, inLive := live[tableID]
+		switch {
+		case !inGenerated:
+			drifts = append(drifts, Drift{TableID: tableID, Kind: driftAdded})
+			continue
+		case !inLive:
+			drifts = append(drifts, Drift{TableID: tableID, Kind: driftRemoved})
+			continue
+		}
+
+		for _, column := range sortedKeys(generatedColumns, liveColumns) {
+			drift := Drift{TableID: tableID, Column: column, Generated: generatedColumns[column], Live: liveColumns[column]}
+			switch {
+			case drift.Generated == "":
+				drift.Kind = driftAdded
+			case drift.Live == "":
+				drift.Kind = driftRemoved
+			case drift.Generated != drift.Live:
+				drift.Kind = driftChanged
+			default:
+				continue
+			}
+			drifts = append(drifts, drift)
+		}
+	}
+
+	return drifts
+}
+
+// sortedKeys returns the sorted union of the keys of m1 and m2.
+func sortedKeys(m1, m2 map[string]string) (keys []string) {
+	for key := range m1 {
+		keys = append(keys, key)
+	}
+	for key := range m2 {
+		if _, ok := m1[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// typeOfRecordColumn is the Go type of the RECORD columns in the column types, instead of the names of the nested structs.
+const typeOfRecordColumn = "struct"
+
+// schemaColumnTypes adds the Go types of schemas, the columns of tableID, to columnTypes by the paths of the columns.
+func schemaColumnTypes(tableID, columnPrefix string, schemas bigquery.Schema, gen *generation, opts Options, columnTypes map[string]string) (err error) {
+	fields, _, _, err := resolveStructFields(&bigquery.Table{TableID: tableID}, nil, "", columnPrefix, schemas, gen, opts)
+	if err != nil {
+		return fmt.Errorf("resolveStructFields: %w", err)
+	}
+
+	structFieldColumnTypes(columnPrefix, fields, columnTypes)
+
+	return nil
+}
+
+// structFieldColumnTypes sets the Go types of fields, and of the fields of its RECORD columns, to columnTypes by the column paths.
+func structFieldColumnTypes(columnPrefix string, fields []structField, columnTypes map[string]string) {
+	for _, f := range fields {
+		goType := f.goType
+		if f.record {
+			goType = strings.TrimSuffix(goType, f.elemType) + typeOfRecordColumn
+			structFieldColumnTypes(columnPrefix+f.schema.Name+".", f.fields, columnTypes)
+		}
+		columnTypes[columnPrefix+f.schema.Name] = goType
+	}
+}
+
+// validateTables loads one sample row of each table of project.dataset into the struct type of the generated fields
+// by the struct loader of the client, and returns the IDs of the tables that fail. The failures are logged with the tables.
+func validateTables(ctx context.Context, client *bigquery.Client, project, dataset string, opts Options) (failures []string, err error) {
+	tables, _, err := getTables(ctx, client, project, dataset, opts)
+	if err != nil {
+		return nil, fmt.Errorf("getTables: %w", err)
+	}
+
+	gen := newGeneration(tables, opts)
+	for i, table := range tables {
+		printProgress(i+1, len(tables), "validating", table.TableID)
+		if err = validateTable(ctx, table, gen, opts); err != nil {
+			warnTableln(table.TableID, "validateTable: "+err.Error())
+			failures = append(failures, table.TableID)
+		}
+	}
+	clearProgress()
+
+	if len(failures) == 0 {
+		infoln("validated " + strconv.Itoa(len(tables)) + " tables of " + dataset)
+	}
+
+	return failures, nil
+}
+
+// validateTable loads one sample row of table into the struct type of the generated fields. See reflectStructType.
+// The views and the external tables are not validated, because their rows cannot be read without the query.
+func validateTable(ctx context.Context, table *bigquery.Table, gen *generation, opts Options) (err error) {
+	md, err := table.Metadata(ctx)
+	if err != nil {
+		return fmt.Errorf("table.Metadata: %w", err)
+	}
+	if md.Type != bigquery.RegularTable {
+		infoln("skip validating the table of type " + string(md.Type) + ": " + table.TableID)
+		return nil
+	}
+
+	structName, err := gen.tableStructName(table, opts)
+	if err != nil {
+		return fmt.Errorf("gen.tableStructName: %w", err)
+	}
+	fields, _, _, err := resolveStructFields(table, md, structName, "", md.Schema, gen, opts)
+	if err != nil {
+		return fmt.Errorf("resolveStructFields: %w", err)
+	}
+	structType, err := reflectStructType(fields)
+	if err != nil {
+		return fmt.Errorf("reflectStructType: %w", err)
+	}
+
+	rowIterator := table.Read(ctx)
+	rowIterator.PageInfo().MaxSize = 1
+	if err = rowIterator.Next(reflect.New(structType).Interface()); err != nil {
+		if err == iterator.Done {
+			infoln("no sample row to validate: " + table.TableID)
+			return nil
+		}
+		return fmt.Errorf("rowIterator.Next: %w", err)
+	}
+
+	return nil
+}
+
+// reflectStructType returns the struct type of fields, that has the same Go types and the bigquery struct tags as the generated struct.
+// The fields of the types overridden by Options.TypeMap or Options.TypeResolver are omitted, because their types are unknown to reflect,
+// and the flattened fields are omitted, because the struct loader cannot load them. See Options.FlattenRecords.
+func reflectStructType(fields []structField) (structType reflect.Type, err error) {
+	var structFields []reflect.StructField
+	for _, f := range fields {
+		// NOTE(djeeno): RowIterator.Next cannot load the pointers but of the structs, that are loaded by FromRow. See Options.NullableTemporal.
+		if f.overridden || f.flattened || (f.pointer && !f.record) {
+			continue
+		}
+
+		var t reflect.Type
+		if f.record {
+			t, err = reflectStructType(f.fields)
+			if err != nil {
+				return nil, fmt.Errorf("reflectStructType: %w", err)
+			}
+			if f.pointer {
+				t = reflect.PtrTo(t)
+			}
+		} else {
+			var ok bool
+			if t, ok = reflectTypes[f.elemType]; !ok {
+				return nil, fmt.Errorf("Go type not supported. goType=%s", f.elemType)
+			}
+		}
+		if f.schema.Repeated {
+			t = reflect.SliceOf(t)
+		}
+
+		structFields = append(structFields, reflect.StructField{Name: f.name, Type: t, Tag: reflect.StructTag(defaultValueTagKey + ":" + strconv.Quote(f.tag()))})
+	}
+
+	return reflect.StructOf(structFields), nil
+}
+
+// generatedTableComment matches the comment of the table struct, and captures the full ID of the table.
+var generatedTableComment = regexp.MustCompile("is BigQuery Table `([^`]*)` schema struct")
+
+// parseGeneratedFile parses filePath, the generated file, and returns the Go types of the columns by the table IDs.
+// The columns are read from the struct tags of tagKey.
+func parseGeneratedFile(filePath, tagKey string) (tables map[string]map[string]string, err error) {
+	file, err := parser.ParseFile(token.NewFileSet(), filePath, nil, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parser.ParseFile: %w", err)
+	}
+
+	structs := make(map[string]*ast.StructType)
+	tableStructs := make(map[string]*ast.StructType)
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			structs[typeSpec.Name.Name] = structType
+			if m := generatedTableComment.FindStringSubmatch(genDecl.Doc.Text()); m != nil {
+				// NOTE(djeeno): The full ID is "project:dataset.table", or "table" for the schema files.
+				tableStructs[m[1][strings.LastIndex(m[1], ".")+1:]] = structType
+			}
+		}
+	}
+
+	tables = make(map[string]map[string]string)
+	for tableID, structType := range tableStructs {
+		columnTypes := make(map[string]string)
+		structColumnTypes(structs, structType, "", tagKey, columnTypes)
+		tables[tableID] = columnTypes
+	}
+
+	return tables, nil
+}
+
+// structColumnTypes adds the Go types of the fields of structType to columnTypes by the paths of the columns in the struct tags of tagKey.
+// The fields of the types in structs are added as the columns of the RECORD column.
+func structColumnTypes(structs map[string]*ast.StructType, structType *ast.StructType, columnPrefix, tagKey string, columnTypes map[string]string) {
+	for _, field := range structType.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+		column := strings.Split(reflect.StructTag(tag).Get(tagKey), ",")[0]
+		if column == "" || column == "-" {
+			continue
+		}
+
+		goType := types.ExprString(field.Type)
+		elemType := strings.TrimLeft(goType, "*[]")
+		if nested, ok := structs[elemType]; ok {
+			goType = strings.TrimSuffix(goType, elemType) + typeOfRecordColumn
+			structColumnTypes(structs, nested, columnPrefix+column+".", tagKey, columnTypes)
+		}
+		columnTypes[columnPrefix+column] = goType
+	}
+}
+
+// generateFileCode combines the header, the import declarations and structsCode, and formats them.
+func generateFileCode(project, dataset string, importPackages []string, structsCode string, opts Options) (generatedCode []byte, err error) {
+	if opts.Template != nil {
+		return []byte(structsCode), nil
+	}
+
+	// NOTE(djeeno): combine
+	code := generateFileHeaderCode(project, dataset, importPackages, opts) + structsCode
+
+	gen := []byte(code)
+
+	// NOTE(djeeno): format.Source re-indents the code with tabs, so that the output is always gofmt-clean regardless of the indentation of the code above.
+	genFmt, err := format.Source(gen)
+	if err != nil {
+		return nil, fmt.Errorf("format.Source: %w", err)
+	}
+
+	genImports, err := imports.Process("", genFmt, nil)
+	if err != nil {
+		return nil, fmt.Errorf("imports.Process: %w", err)
+	}
+
+	return genImports, nil
+}
+
+// generateFileHeaderCode generates the header, the package clause, the import declarations and the constants of the generated file.
+func generateFileHeaderCode(project, dataset string, importPackages []string, opts Options) (generatedCode string) {
+	packageClause := "package " + packageName(opts) + "\n\n"
+	// NOTE(djeeno): go generate of the directive regenerates the file in the same package as the init file. See generateInitCode.
+	directive := generateDirective
+	if packageName(opts) != defaultValuePackage {
+		directive = directive + " -" + optNamePackage + " " + packageName(opts)
+	}
+	head := generatedCodeHeader + "\n\n" + directive + "\n\n" + packageClause
+	switch {
+	// NOTE(djeeno): go generate must not overwrite the scaffold edited by hand.
+	case opts.Scaffold:
+		head = scaffoldCodeHeader + "\n\n" + packageClause
+	// NOTE(djeeno): The directive of the init file generates the file instead, so that go generate does not run twice.
+	case opts.omitGenerateDirective:
+		head = generatedCodeHeader + "\n\n" + packageClause
+	}
+	if opts.partial {
+		head = partialOutputHeader + "\n\n" + head
+	}
+
+	importCode := generateImportPackagesCode(importPackages)
+
+	if opts.Constants {
+		importCode = importCode + generateConstantsCode(project, dataset)
+	}
+	if opts.SchemaVersion {
+		importCode = importCode + "// SchemaVersion is the hash of the column names, types and modes of the BigQuery tables that the code is generated from.\n" +
+			"const SchemaVersion = " + strconv.Quote(opts.schemaVersion) + "\n\n"
+	}
+
+	return head + importCode
+}
+
+// tableSchemaHash returns the hex SHA-256 hash of tableID and the paths, the types and the modes of schemas and of its RECORD columns in order.
+// The descriptions and the policy tags are not hashed, because they do not change the rows.
+func tableSchemaHash(tableID string, schemas bigquery.Schema) (hash string) {
+	var lines func(columnPrefix string, schemas bigquery.Schema) string
+	lines = func(columnPrefix string, schemas bigquery.Schema) (s string) {
+		for _, schema := range schemas {
+			s = s + columnPrefix + schema.Name + " " + string(schema.Type) + " " + columnMode(schema) + "\n"
+			if schema.Type == bigquery.RecordFieldType {
+				s = s + lines(columnPrefix+schema.Name+".", schema.Schema)
+			}
+		}
+		return s
+	}
+
+	sum := sha256.Sum256([]byte(tableID + "\n" + lines("", schemas)))
+	return hex.EncodeToString(sum[:])
+}
+
+// structFieldsComment returns the comment of the number of fields and the first 8 characters of the hash of their columns, e.g. "fields: 12, schema: a1b2c3d4".
+// columnPath is the path of the struct, e.g. "table." or "table.record.", that is hashed with the columns, so that the hash is of the struct. See tableSchemaHash.
+func structFieldsComment(columnPath string, fields []structField) (comment string) {
+	schemas := make(bigquery.Schema, 0, len(fields))
+	for _, f := range fields {
+		schemas = append(schemas, f.schema)
+	}
+	return "fields: " + strconv.Itoa(len(fields)) + ", schema: " + tableSchemaHash(columnPath, schemas)[:8]
+}
+
+// schemaVersion returns the hex SHA-256 hash of the concatenated schemaHashes sorted by the table IDs, that is deterministic for the same schemas.
+// The tables skipped because of errors are not in schemaHashes, so that they change the version.
+func schemaVersion(schemaHashes map[string]string) (version string) {
+	tableIDs := make([]string, 0, len(schemaHashes))
+	for tableID := range schemaHashes {
+		tableIDs = append(tableIDs, tableID)
+	}
+	sort.Strings(tableIDs)
+
+	hash := sha256.New()
+	for _, tableID := range tableIDs {
+		_, _ = io.WriteString(hash, schemaHashes[tableID])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func generateConstantsCode(project, dataset string) (generatedCode string) {
+	return "// ProjectID is the BigQuery project ID that the code is generated from.\n" +
+		"// DatasetID is the BigQuery dataset ID that the code is generated from.\n" +
+		"const (\n" +
+		"\tProjectID = " + strconv.Quote(project) + "\n" +
+		"\tDatasetID = " + strconv.Quote(dataset) + "\n" +
+		")\n\n"
+}
+
+func generateImportPackagesCode(importPackages []string) (generatedCode string) {
+	importPackagesUniq := make(map[string]bool)
+	for _, pkg := range importPackages {
+		importPackagesUniq[pkg] = true
+	}
+
+	// NOTE(djeeno): fix order
+	importPackagesUniqSort := make([]string, len(importPackagesUniq))
+	idx := 0
+	for _, pkg := range importPackages {
+		if importPackagesUniq[pkg] {
+			importPackagesUniq[pkg] = false
+			importPackagesUniqSort[idx] = pkg
+			idx++
+		}
+	}
+
+	switch {
+	case len(importPackagesUniq) == 0:
+		generatedCode = ""
+	case len(importPackagesUniq) == 1:
+		for pkg := range importPackagesUniq {
+			generatedCode = "import " + importSpecCode(pkg) + "\n"
+		}
+		generatedCode = generatedCode + "\n"
+	case len(importPackagesUniq) >= 2:
+		generatedCode = "import (\n"
+		for _, pkg := range importPackagesUniqSort {
+			generatedCode = generatedCode + "\t" + importSpecCode(pkg) + "\n"
+		}
+		generatedCode = generatedCode + ")\n\n"
+	}
+
+	return generatedCode
+}
+
+// importSpecCode generates the import spec of importPackage, e.g. `"time"` or `uuid2 "github.com/b/uuid"` of the alias. See parseImportPackage.
+func importSpecCode(importPackage string) (generatedCode string) {
+	name, importPath := parseImportPackage(importPackage)
+	if name == importPathToAssumedName(importPath) {
+		return strconv.Quote(importPath)
+	}
+	return name + " " + strconv.Quote(importPath)
+}
+
+// tableSchemaMetadata returns the metadata of table to generate, that is of metadata, the metadata fetched in advance by informationSchemaMetadata, if any,
+// and of the schema at opts.AsOf if not zero. client runs the query jobs of opts.AsOf. It returns errPartitioningNotMatched if table is not of opts.Partitioning.
+func tableSchemaMetadata(ctx context.Context, client *bigquery.Client, table *bigquery.Table, metadata map[string]*bigquery.TableMetadata, opts Options) (md *bigquery.TableMetadata, err error) {
+	if len(table.TableID) == 0 {
+		return nil, fmt.Errorf("*bigquery.Table.TableID is empty. *bigquery.Table struct dump: %#v", table)
+	}
+
+	md, ok := metadata[table.TableID]
+	if shards, isWildcard := opts.wildcardShards[table.TableID]; isWildcard {
+		md, err = wildcardMetadata(ctx, client, table, shards, metadata, opts)
+		if err != nil {
+			return nil, fmt.Errorf("wildcardMetadata: %w", err)
+		}
+	} else if !ok {
+		start := time.Now()
+		md, err = table.Metadata(ctx)
+		traceln(table.TableID, "table.Metadata", start)
+		if err != nil {
+			return nil, fmt.Errorf("table.Metadata: %w", err)
+		}
+	}
+	if !matchPartitioning(md, opts.Partitioning) {
+		return nil, errPartitioningNotMatched
+	}
+	if !opts.AsOf.IsZero() {
+		md = asOfMetadata(ctx, client, table, md, opts.AsOf)
+	}
+
+	return md, nil
+}
+
+const (
+	partitioningPartitioned   = "partitioned"
+	partitioningUnpartitioned = "unpartitioned"
+	partitioningIngestionTime = "ingestion-time"
+	partitioningColumn        = "column"
+	partitioningRange         = "range"
+)
+
+// errPartitioningNotMatched is returned by tableSchemaMetadata for the table not of Options.Partitioning, that is skipped without the warning.
+var errPartitioningNotMatched = errors.New("table is not of the partitioning")
+
+// validatePartitioning returns error if partitioning is not empty or one of the partitionings, e.g. partitioningColumn.
+func validatePartitioning(partitioning string) error {
+	switch partitioning {
+	case "", partitioningPartitioned, partitioningUnpartitioned, partitioningIngestionTime, partitioningColumn, partitioningRange:
+		return nil
+	}
+	return fmt.Errorf("invalid option value: -%s=%s", optNamePartitioning, partitioning)
+}
+
+// matchPartitioning reports whether md is of partitioning, or true if partitioning is empty.
+// The ingestion-time partitioned tables are time-partitioned without the partitioning column, that are partitioned by _PARTITIONTIME.
+// ref. https://cloud.google.com/bigquery/docs/partitioned-tables
+func matchPartitioning(md *bigquery.TableMetadata, partitioning string) bool {
+	switch partitioning {
+	case partitioningPartitioned:
+		return md.TimePartitioning != nil || md.RangePartitioning != nil
+	case partitioningUnpartitioned:
+		return md.TimePartitioning == nil && md.RangePartitioning == nil
+	case partitioningIngestionTime:
+		return md.TimePartitioning != nil && md.TimePartitioning.Field == ""
+	case partitioningColumn:
+		return md.TimePartitioning != nil && md.TimePartitioning.Field != ""
+	case partitioningRange:
+		return md.RangePartitioning != nil
+	default:
+		return true
+	}
+}
+
+// partitioningComment returns the comment of the partitioning of md, e.g. "Partitioning: DAY by column `created_at`.", or empty if md is not partitioned.
+func partitioningComment(md *bigquery.TableMetadata) (comment string) {
+	requireFilter := md.RequirePartitionFilter
+	switch {
+	case md.TimePartitioning != nil:
+		// NOTE(djeeno): The type is empty for the tables created without it, that are partitioned by DAY.
+		granularity := string(md.TimePartitioning.Type)
+		if granularity == "" {
+			granularity = string(bigquery.DayPartitioningType)
+		}
+		if md.TimePartitioning.Field == "" {
+			comment = "Partitioning: " + granularity + " by ingestion time (_PARTITIONTIME)."
+		} else {
+			comment = "Partitioning: " + granularity + " by column `" + md.TimePartitioning.Field + "`."
+		}
+		if md.TimePartitioning.Expiration > 0 {
+			comment += " Partitions expire after " + md.TimePartitioning.Expiration.String() + "."
+		}
+		requireFilter = requireFilter || md.TimePartitioning.RequirePartitionFilter
+	case md.RangePartitioning != nil:
+		comment = "Partitioning: integer range by column `" + md.RangePartitioning.Field + "`"
+		if r := md.RangePartitioning.Range; r != nil {
+			comment += " from " + strconv.FormatInt(r.Start, 10) + " to " + strconv.FormatInt(r.End, 10) + " by " + strconv.FormatInt(r.Interval, 10)
+		}
+		comment += "."
+	default:
+		return ""
+	}
+	if requireFilter {
+		comment += " The queries require the partition filter."
+	}
+
+	return comment
+}
+
+// tableTypeComment returns the comment of tableType, e.g. "Materialized view", or empty for the regular tables and the types unknown.
+// The schemas of the views and the materialized views are of the results of their queries.
+func tableTypeComment(tableType bigquery.TableType) (comment string) {
+	switch tableType {
+	case bigquery.MaterializedView:
+		return "Materialized view"
+	case bigquery.ViewTable:
+		return "View"
+	case bigquery.ExternalTable:
+		return "External table"
+	default:
+		return ""
+	}
+}
+
+// asOfMetadata returns the copy of md that has the schema of table at asOf by time travel, or md with the warning
+// if time travel is not available for table, e.g. for the views or at the time out of the time travel window.
+// ref. https://cloud.google.com/bigquery/docs/time-travel
+func asOfMetadata(ctx context.Context, client *bigquery.Client, table *bigquery.Table, md *bigquery.TableMetadata, asOf time.Time) *bigquery.TableMetadata {
+	if md.Type != bigquery.RegularTable {
+		warnTableln(table.TableID, "time travel is not available for "+string(md.Type)+": fall back to the current schema")
+		return md
+	}
+
+	schema, err := asOfSchema(ctx, client, table, asOf)
+	if err != nil {
+		warnTableln(table.TableID, "asOfSchema: "+err.Error()+": fall back to the current schema")
+		return md
+	}
+
+	asOfMD := *md
+	asOfMD.Schema = mergeColumnAnnotations(schema, md.Schema)
+	return &asOfMD
+}
+
+// asOfSchema returns the schema of table at asOf by the dry run of the query FOR SYSTEM_TIME AS OF, that is not billed.
+// ref. https://cloud.google.com/bigquery/docs/reference/standard-sql/query-syntax#for_system_time_as_of
+func asOfSchema(ctx context.Context, client *bigquery.Client, table *bigquery.Table, asOf time.Time) (schema bigquery.Schema, err error) {
+	q := client.Query("SELECT * FROM `" + table.ProjectID + "." + table.DatasetID + "." + table.TableID + "` FOR SYSTEM_TIME AS OF @as_of")
+	q.Parameters = []bigquery.QueryParameter{{Name: "as_of", Value: asOf}}
+	q.DryRun = true
+
+	job, err := q.Run(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("q.Run: %w", err)
+	}
+	// NOTE(djeeno): ref. https://github.com/googleapis/google-cloud-go/blob/bigquery/v1.13.0/bigquery/job.go#L422-L424
+	status := job.LastStatus()
+	if status == nil || status.Statistics == nil {
+		return nil, fmt.Errorf("dry run has no statistics. asOf=%s", asOf.Format(time.RFC3339))
+	}
+	stats, ok := status.Statistics.Details.(*bigquery.QueryStatistics)
+	if !ok || len(stats.Schema) == 0 {
+		return nil, fmt.Errorf("dry run has no schema. asOf=%s", asOf.Format(time.RFC3339))
+	}
+
+	return stats.Schema, nil
+}
+
+// mergeColumnAnnotations returns the copy of schemas that has the descriptions and the policy tags of the same columns in current,
+// because the schema of the query result has neither of them. The columns not in current have none of them.
+func mergeColumnAnnotations(schemas, current bigquery.Schema) (merged bigquery.Schema) {
+	currentColumns := make(map[string]*bigquery.FieldSchema)
+	for _, schema := range current {
+		currentColumns[schema.Name] = schema
+	}
+
+	for _, schema := range schemas {
+		column := *schema
+		if currentColumn, ok := currentColumns[schema.Name]; ok {
+			column.Description = currentColumn.Description
+			column.PolicyTags = currentColumn.PolicyTags
+			column.Schema = mergeColumnAnnotations(schema.Schema, currentColumn.Schema)
+		}
+		merged = append(merged, &column)
+	}
+
+	return merged
+}
+
+// informationSchemaMetadata returns the metadata of the tables in project.dataset fetched from INFORMATION_SCHEMA if opts.InformationSchema,
+// or nil if not or the query fails, so that the metadata of each table is fetched instead.
+func informationSchemaMetadata(ctx context.Context, client *bigquery.Client, project, dataset string, opts Options) (metadata map[string]*bigquery.TableMetadata) {
+	if !opts.InformationSchema {
+		return nil
+	}
+
+	metadata, err := getInformationSchemaMetadata(ctx, client, project, dataset)
+	if err != nil {
+		warnln("getInformationSchemaMetadata: " + err.Error() + ": fall back to the metadata of each table")
+		return nil
+	}
+
+	return metadata
+}
+
+// generateTableMetadataCode generates the code of the schema struct of table by md. gen is the generation of the file that has the struct,
+// or nil for the file of only the struct.
+func generateTableMetadataCode(table *bigquery.Table, md *bigquery.TableMetadata, gen *generation, opts Options) (generatedCode string, importPackages []string, err error) {
+	if gen == nil {
+		gen = newGeneration([]*bigquery.Table{table}, opts)
+	}
+	structName, err := gen.tableStructName(table, opts)
+	if err != nil {
+		return "", nil, fmt.Errorf("gen.tableStructName: %w", err)
+	}
+
+	if len(filterColumns(table.TableID, "", md.Schema, opts)) == 0 {
+		if opts.Strict {
+			return "", nil, fmt.Errorf("struct has no fields after filtering the columns. tableID=%s", table.TableID)
+		}
+		warnTableln(table.TableID, "struct has no fields after filtering the columns")
+	}
+
+	// NOTE(djeeno): structs
+	generatedCode, importPackages, err = generateStructCode(table, md, structName, "", md.Schema, gen, opts)
+	if err != nil {
+		return "", nil, fmt.Errorf("generateStructCode: %w", err)
+	}
+	if gen.schemaHashes != nil {
+		gen.schemaHashes[table.TableID] = tableSchemaHash(table.TableID, md.Schema)
+	}
+
+	if opts.SelectAll && opts.Template == nil {
+		generatedCode = generatedCode + "\n" + generateSelectAllCode(table, uniqueIdentifier(structName+"SelectAll", gen.typeNames), filterColumns(table.TableID, "", md.Schema, opts))
+	}
+
+	return generatedCode, importPackages, nil
+}
+
+// generateSelectAllCode generates the constant constName of the query that selects schemas, the columns of table.
+func generateSelectAllCode(table *bigquery.Table, constName string, schemas bigquery.Schema) (generatedCode string) {
+	return "// " + constName + " is the query that selects the columns of " + queryTableName(table) + ".\n" +
+		"const " + constName + " = " + strconv.Quote(selectAllQuery(table, schemas)) + "\n"
+}
+
+// queryTableName returns the name of table in the queries, "project.dataset.table", or the table ID if the project or the dataset is empty.
+func queryTableName(table *bigquery.Table) (tableName string) {
+	if table.ProjectID != "" && table.DatasetID != "" {
+		return table.ProjectID + "." + table.DatasetID + "." + table.TableID
+	}
+	return table.TableID
+}
+
+// selectAllQuery returns the query that selects schemas, the columns of table.
+func selectAllQuery(table *bigquery.Table, schemas bigquery.Schema) (query string) {
+	columns := make([]string, 0, len(schemas))
+	for _, schema := range schemas {
+		columns = append(columns, quoteIdentifier(schema.Name))
+	}
+	return "SELECT " + strings.Join(columns, ", ") + " FROM " + quoteIdentifier(queryTableName(table))
+}
+
+// quotedIdentifierEscaper escapes the backslashes and the backticks in the quoted identifiers of the queries.
+// NOTE(djeeno): ref. https://cloud.google.com/bigquery/docs/reference/standard-sql/lexical#quoted_identifiers
+var quotedIdentifierEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+
+// quoteIdentifier returns identifier, e.g. the column name, quoted by the backticks for the queries, e.g. "`id`".
+func quoteIdentifier(identifier string) (quoted string) {
+	return "`" + quotedIdentifierEscaper.Replace(identifier) + "`"
+}
+
+// tableStructName returns the struct name of table.
+func tableStructName(table *bigquery.Table, opts Options) (structName string, err error) {
+	nameMapper := opts.NameMapper
+	if nameMapper == nil {
+		nameMapper = DefaultNameMapper
+	}
+
+	structName = nameMapper(table.TableID, "")
+	if !isExportedIdentifier(structName) {
+		return "", fmt.Errorf("struct name is not an exported Go identifier. tableID=%s structName=%s", table.TableID, structName)
+	}
+	if opts.DatasetPrefix {
+		structName = exportedIdentifier(table.DatasetID) + "_" + structName
+	}
+	// NOTE(djeeno): The project IDs have "-", and the domain-scoped project IDs have "." and ":", e.g. "example.com:project".
+	if opts.ProjectPrefix {
+		structName = exportedIdentifier(sanitizeIdentifier(table.ProjectID)) + "_" + structName
+	}
+
+	return structName, nil
+}
+
+// reserveTableStructNames reserves the struct names of tables in typeNames, the registry of the type names, and returns them by queryTableName.
+// The struct names of the tables that are the same, e.g. "Events_v2" of "events-v2" and "events_v2", are suffixed by uniqueIdentifier in the order of tables.
+// NOTE(djeeno): The struct names of the tables are reserved before generating any struct,
+// so that the nested struct names never take them regardless of the order of the tables.
+func reserveTableStructNames(tables []*bigquery.Table, typeNames map[string]bool, opts Options) (structNames map[string]string) {
+	structNames = make(map[string]string)
+	for _, table := range tables {
+		// NOTE(djeeno): The error is returned by generateTableMetadataCode later.
+		if structName, err := tableStructName(table, opts); err == nil {
+			structNames[queryTableName(table)] = uniqueIdentifier(structName, typeNames)
+		}
+	}
+	return structNames
+}
+
+// generation is the state of the generation of one file, or of the files of one package, that is shared by the structs generated in it.
+// It is not safe for concurrent use, so that the structs sharing it must be generated in one goroutine.
+type generation struct {
+	// typeNames is the registry of the type names used in the generated code, to keep the nested struct names unique.
+	typeNames map[string]bool
+	// structNames is the struct names of the tables by queryTableName reserved in typeNames. See reserveTableStructNames.
+	structNames map[string]string
+	// importNames is the registry of the package names of the import paths used in the generated code, to alias the packages of the same name.
+	// See importName.
+	importNames map[string]string
+	// schemaHashes is the registry of the hashes of the schemas of the generated tables by the table IDs, or nil not to record them.
+	// See tableSchemaHash.
+	schemaHashes map[string]string
+}
+
+// newGeneration returns the generation of the structs of tables, that has the struct names of tables reserved.
+// See reserveTableStructNames and reserveImportNames.
+func newGeneration(tables []*bigquery.Table, opts Options) (gen *generation) {
+	gen = &generation{typeNames: make(map[string]bool), importNames: reserveImportNames(opts)}
+	gen.structNames = reserveTableStructNames(tables, gen.typeNames, opts)
+	return gen
+}
+
+// newMergeGeneration returns the generation of the structs of tables merged into filePath by structsWriter.mergeFile,
+// that has the top-level names declared in the blocks kept in filePath and the package names of its imports reserved before the struct names of tables,
+// so that the generated structs do not conflict with the code kept. The blocks of tables and the header are replaced, so that their names are not reserved.
+func newMergeGeneration(filePath string, tables []*bigquery.Table, opts Options) (gen *generation, err error) {
+	existing, err := readFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return newGeneration(tables, opts), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("readFile: %w", err)
+	}
+	// NOTE(djeeno): The blocks are split by the lines of LF as mergeFile does.
+	existing = bytes.ReplaceAll(existing, []byte("\r\n"), []byte("\n"))
+	blocks, err := splitMergeBlocks(string(existing))
+	if err != nil {
+		return nil, fmt.Errorf("splitMergeBlocks: %w", err)
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "", existing, 0)
+	if err != nil {
+		return nil, fmt.Errorf("parser.ParseFile: %w", err)
+	}
+
+	replaced := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		replaced[table.TableID] = true
+	}
+	// NOTE(djeeno): The offsets of the blocks kept in existing, that the offsets of the declarations are in.
+	var keptBlocks [][2]int
+	offset := 0
+	for i, block := range blocks {
+		if !(i == 0 && block.tableID == "") && !replaced[block.tableID] {
+			keptBlocks = append(keptBlocks, [2]int{offset, offset + len(block.code)})
+		}
+		offset += len(block.code)
+	}
+	isKept := func(pos token.Pos) bool {
+		// NOTE(djeeno): The file of the file set starts at the base 1.
+		for _, kept := range keptBlocks {
+			if int(pos)-1 >= kept[0] && int(pos)-1 < kept[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	gen = &generation{typeNames: make(map[string]bool), importNames: reserveImportNames(opts)}
+	for _, decl := range file.Decls {
+		if !isKept(decl.Pos()) {
+			continue
+		}
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil {
+				gen.typeNames[decl.Name.Name] = true
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					gen.typeNames[spec.Name.Name] = true
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						gen.typeNames[name.Name] = true
+					}
+				}
+			}
+		}
+	}
+	// NOTE(djeeno): The imports of the existing file are kept by mergeFile, so that the packages of the overrides are aliased as before.
+	for _, spec := range file.Imports {
+		var importPath string
+		importPath, err = strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, fmt.Errorf("strconv.Unquote: %w", err)
+		}
+		if _, ok := gen.importNames[importPath]; ok {
+			continue
+		}
+		if spec.Name != nil {
+			gen.importNames[importPath] = spec.Name.Name
+			continue
+		}
+		gen.importNames[importPath] = importPathToAssumedName(importPath)
+	}
+	gen.structNames = reserveTableStructNames(tables, gen.typeNames, opts)
+
+	return gen, nil
+}
+
+// tableStructName returns the struct name of table reserved in gen, or of tableStructName if it is not reserved.
+func (gen *generation) tableStructName(table *bigquery.Table, opts Options) (structName string, err error) {
+	if structName, ok := gen.structNames[queryTableName(table)]; ok {
+		return structName, nil
+	}
+	return tableStructName(table, opts)
+}
+
+// structField is the resolved field of the generated struct.
+type structField struct {
+	// schema is the column of the field.
+	schema *bigquery.FieldSchema
+	// name is the Go field name.
+	name string
+	// goType is the Go type of the field, e.g. "[]int64".
+	goType string
+	// elemType is goType without "[]" and "*", e.g. "int64".
+	elemType string
+	// tagOption is the bigquery struct tag option, e.g. "nullable".
+	tagOption string
+	// record reports whether elemType is the struct of the RECORD column.
+	record bool
+	// pointer reports whether goType is the pointer to elemType.
+	pointer bool
+	// overridden reports whether goType is overridden by Options.TypeMap.
+	overridden bool
+	// pkg is the import path of elemType, or empty.
+	pkg string
+	// fields is the resolved fields of the RECORD column, if record or flattened.
+	fields []structField
+	// flattened reports whether elemType is the type of the only field of the REPEATED RECORD column. See Options.FlattenRecords.
+	flattened bool
+}
+
+// tag returns the bigquery struct tag value of f.
+func (f structField) tag() (tag string) {
+	if f.tagOption == "" {
+		return f.schema.Name
+	}
+	return f.schema.Name + "," + f.tagOption
+}
+
+// StructTemplateData is the data of Options.Template.
+type StructTemplateData struct {
+	// Table is the table of the struct.
+	Table *bigquery.Table
+	// Metadata is the metadata of Table. It may be nil.
+	Metadata *bigquery.TableMetadata
+	// ColumnPrefix is the path of the RECORD column of the nested struct, e.g. "record.", or empty for the struct of Table.
+	ColumnPrefix string
+	// Comments is the lines of the comment of the struct.
+	Comments []string
+	// Name is the struct name.
+	Name string
+	// Embed is the type to embed. See Options.Embed.
+	Embed string
+	// Fields is the fields of the struct.
+	Fields []FieldContext
+}
+
+// FieldContext is the field of StructTemplateData.
+type FieldContext struct {
+	// Column is the column of the field.
+	Column *bigquery.FieldSchema
+	// ColumnName is the original name of the column.
+	ColumnName string
+	// Mode is the mode of the column, "NULLABLE", "REQUIRED" or "REPEATED".
+	Mode string
+	// Description is the description of the column.
+	Description string
+	// Comments is the lines of the comment of the field.
+	Comments []string
+	// Name is the field name.
+	Name string
+	// Type is the resolved Go type, e.g. "[]civil.Date".
+	Type string
+	// Import is the import path of Type, e.g. "cloud.google.com/go/civil", or empty.
+	Import string
+	// Tag is the struct tag of Options.TagKey, e.g. `bigquery:"column"`.
+	Tag string
+}
+
+// columnMode returns the mode of schema, "NULLABLE", "REQUIRED" or "REPEATED".
+// ref. https://cloud.google.com/bigquery/docs/schemas#modes
+func columnMode(schema *bigquery.FieldSchema) (mode string) {
+	switch {
+	case schema.Repeated:
+		return "REPEATED"
+	case schema.Required:
+		return "REQUIRED"
+	default:
+		return "NULLABLE"
+	}
+}
+
+// defaultStructTemplate is the template of the struct used if Options.Template is nil.
+var defaultStructTemplate = template.Must(template.New("struct").Parse(`{{range .Comments}}// {{.}}
+{{end}}type {{.Name}} struct {
+{{- if .Embed}}
+	{{.Embed}}
+{{- end}}
+{{- range .Fields}}
+{{- range .Comments}}
+	// {{.}}
+{{- end}}
+	{{.Name}} {{.Type}} ` + "`{{.Tag}}`" + `
+{{- end}}
+}
+`))
+
+// generateStructCode generates the struct structName of schemas, followed by the structs of its RECORD columns.
+// columnPrefix is the path of the RECORD column that has schemas, e.g. "record.", or empty for table.
+// The struct of table has the TableName method.
+func generateStructCode(table *bigquery.Table, md *bigquery.TableMetadata, structName, columnPrefix string, schemas bigquery.Schema, gen *generation, opts Options) (generatedCode string, importPackages []string, err error) {
+	fields, nestedCode, importPackages, err := resolveStructFields(table, md, structName, columnPrefix, schemas, gen, opts)
+	if err != nil {
+		return "", nil, fmt.Errorf("resolveStructFields: %w", err)
+	}
+
+	generatedCode, pkgs, err := generateResolvedStructCode(table, md, structName, columnPrefix, fields, gen, opts)
+	if err != nil {
+		return "", nil, fmt.Errorf("generateResolvedStructCode: %w", err)
+	}
+
+	// NOTE(djeeno): The columns are reported after the struct is generated, so that the skipped tables have no columns in the report.
+	if opts.report != nil && columnPrefix == "" {
+		opts.report.addFields(queryTableName(table), structName, "", fields, opts)
+		for _, column := range excludedColumns(table.TableID, "", schemas, opts) {
+			opts.report.excluded = append(opts.report.excluded, queryTableName(table)+"."+column)
+		}
+	}
+
+	return generatedCode + nestedCode, append(importPackages, pkgs...), nil
+}
+
+// mappingReport is the report of the columns mapped to the fields of the generated structs. See writeMappingReport.
+// It is not safe for concurrent use as generation.
+type mappingReport struct {
+	// columns is the mapped columns in the order of the tables and the columns.
+	columns []mappedColumn
+	// excluded is the paths of the columns excluded by Options.ExcludeColumns, e.g. "project.dataset.table.record.column".
+	excluded []string
+	// skipped is the IDs of the tables skipped because of errors, e.g. "project.dataset.table".
+	skipped []string
+}
+
+// mappedColumn is the column of mappingReport.
+type mappedColumn struct {
+	// table is the ID of the table, e.g. "project.dataset.table". See queryTableName.
+	table string
+	// column is the path of the column, e.g. "record.column".
+	column string
+	// structName is the name of the struct that has the field.
+	structName string
+	// field is the Go field name.
+	field string
+	// goType is the Go type of the field.
+	goType string
+	// note is how the column is mapped, e.g. "overridden" or "lossy: GEOGRAPHY stored as string". See mappedColumnNote.
+	note string
+}
+
+// addFields adds fields of the struct structName of table, and the fields of their nested structs after them.
+func (r *mappingReport) addFields(table, structName, columnPrefix string, fields []structField, opts Options) {
+	for _, f := range fields {
+		r.columns = append(r.columns, mappedColumn{
+			table:      table,
+			column:     columnPrefix + f.schema.Name,
+			structName: structName,
+			field:      f.name,
+			goType:     f.goType,
+			note:       mappedColumnNote(f, opts),
+		})
+		if f.record {
+			r.addFields(table, f.elemType, columnPrefix+f.schema.Name+".", f.fields, opts)
+		}
+	}
+}
+
+// mappedColumnNote returns how the column of f is mapped, the comma-separated "overridden", "flattened", "lossy: <type> stored as <Go type>"
+// or "nested struct", or empty if the column is mapped to the default Go type.
+func mappedColumnNote(f structField, opts Options) (note string) {
+	var notes []string
+	if f.record {
+		notes = append(notes, "nested struct")
+	}
+	if f.flattened {
+		notes = append(notes, "flattened")
+	}
+	if f.overridden {
+		notes = append(notes, "overridden")
+	}
+	// NOTE(djeeno): The same condition as the comment of the lossy field in generateResolvedStructCode.
+	if !f.overridden && !f.record && isLossyType(f.schema.Type, opts.LossyTypes) {
+		notes = append(notes, "lossy: "+string(f.schema.Type)+" stored as "+f.elemType)
+	}
+	return strings.Join(notes, ", ")
+}
+
+// writeMappingReport writes report to filePath with fileMode, in Markdown if the extension of filePath is ".md", or in text.
+func writeMappingReport(filePath string, fileMode os.FileMode, report *mappingReport) (err error) {
+	var buf bytes.Buffer
+	if strings.EqualFold(filepath.Ext(filePath), ".md") {
+		report.writeMarkdown(&buf)
+	} else if err = report.writeText(&buf); err != nil {
+		return fmt.Errorf("report.writeText: %w", err)
+	}
+
+	if err = writeGeneratedCode(filePath, fileMode, buf.Bytes()); err != nil {
+		return fmt.Errorf("writeGeneratedCode: %w", err)
+	}
+
+	return nil
+}
+
+// writeMarkdown writes r to buf as the Markdown tables.
+func (r *mappingReport) writeMarkdown(buf *bytes.Buffer) {
+	buf.WriteString("# Schema mapping report\n\n")
+	buf.WriteString("| Table | Column | Struct | Field | Go type | Note |\n")
+	buf.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, c := range r.columns {
+		buf.WriteString("| `" + c.table + "` | `" + c.column + "` | " + c.structName + " | " + c.field + " | `" + c.goType + "` | " + c.note + " |\n")
+	}
+	for _, section := range []struct {
+		title string
+		items []string
+	}{
+		{title: "Excluded columns", items: r.excluded},
+		{title: "Skipped tables", items: r.skipped},
+	} {
+		if len(section.items) == 0 {
+			continue
+		}
+		buf.WriteString("\n## " + section.title + "\n\n")
+		for _, item := range section.items {
+			buf.WriteString("- `" + item + "`\n")
+		}
+	}
+}
+
+// writeText writes r to w as the table aligned by text/tabwriter, followed by the excluded columns and the skipped tables.
+func (r *mappingReport) writeText(w io.Writer) (err error) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	if _, err = fmt.Fprintln(tw, "TABLE\tCOLUMN\tSTRUCT\tFIELD\tGO TYPE\tNOTE"); err != nil {
+		return fmt.Errorf("fmt.Fprintln: %w", err)
+	}
+	for _, c := range r.columns {
+		if _, err = fmt.Fprintln(tw, c.table+"\t"+c.column+"\t"+c.structName+"\t"+c.field+"\t"+c.goType+"\t"+c.note); err != nil {
+			return fmt.Errorf("fmt.Fprintln: %w", err)
+		}
+	}
+	if err = tw.Flush(); err != nil {
+		return fmt.Errorf("tw.Flush: %w", err)
+	}
+
+	for _, section := range []struct {
+		title string
+		items []string
+	}{
+		{title: "EXCLUDED COLUMNS", items: r.excluded},
+		{title: "SKIPPED TABLES", items: r.skipped},
+	} {
+		if len(section.items) == 0 {
+			continue
+		}
+		if _, err = fmt.Fprintln(w, "\n"+section.title+"\n"+strings.Join(section.items, "\n")); err != nil {
+			return fmt.Errorf("fmt.Fprintln: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// generateResolvedStructCode generates the code of the struct structName of fields, the fields resolved by resolveStructFields.
+// The returned code does not include the structs of the RECORD columns.
+func generateResolvedStructCode(table *bigquery.Table, md *bigquery.TableMetadata, structName, columnPrefix string, fields []structField, gen *generation, opts Options) (generatedCode string, importPackages []string, err error) {
+	data := StructTemplateData{Table: table, Metadata: md, ColumnPrefix: columnPrefix, Name: structName}
+	switch {
+	case columnPrefix != "":
+		data.Comments = []string{structName + " is RECORD column `" + strings.TrimSuffix(columnPrefix, ".") + "` schema struct."}
+	case md != nil:
+		data.Comments = []string{structName + " is BigQuery Table `" + md.FullID + "` schema struct."}
+		if !opts.NoDescription {
+			data.Comments = append(data.Comments, strings.TrimSpace("Description: "+commentText(md.Description)))
+		}
+		if opts.ConsoleURL {
+			data.Comments = append(data.Comments, "Console: "+consoleURL(table))
+		}
+		if opts.PartitioningComment {
+			if comment := partitioningComment(md); comment != "" {
+				data.Comments = append(data.Comments, comment)
+			}
+		}
+		if opts.TableTypeComment {
+			if comment := tableTypeComment(md.Type); comment != "" {
+				data.Comments = append(data.Comments, comment)
+			}
+		}
+		// NOTE(djeeno): The clustering columns are in the order of the clustering, that matters for the query optimization.
+		// ref. https://cloud.google.com/bigquery/docs/clustered-tables
+		if opts.ClusteringComment && md.Clustering != nil && len(md.Clustering.Fields) > 0 {
+			data.Comments = append(data.Comments, "Clustered by: "+strings.Join(md.Clustering.Fields, ", "))
+		}
+		if excluded := excludedColumns(table.TableID, "", md.Schema, opts); len(excluded) > 0 {
+			data.Comments = append(data.Comments, "Excluded columns: "+strings.Join(excluded, ", "))
+		}
+	}
+	if opts.FieldsComment {
+		data.Comments = append(data.Comments, structFieldsComment(table.TableID+"."+columnPrefix, fields))
+	}
+	// NOTE(djeeno): The nested structs of RECORD columns are not the models of the tables, so that only the table structs embed opts.Embed.
+	if opts.Embed != "" && columnPrefix == "" {
+		var pkg string
+		data.Embed, pkg, err = parseEmbedType(opts.Embed)
+		if err != nil {
+			return "", nil, fmt.Errorf("parseEmbedType: %w", err)
+		}
+		if pkg != "" {
+			data.Embed, pkg = aliasImport(data.Embed, pkg, gen.importNames)
+			importPackages = append(importPackages, pkg)
+		}
+	}
+	for _, f := range fields {
+		description, directiveTag := f.schema.Description, ""
+		if opts.TagDirective != "" {
+			description, directiveTag = parseTagDirectives(description, opts.TagDirective)
+			if err = validateStructTag(directiveTag, structTagKey(opts)); err != nil {
+				return "", nil, fmt.Errorf("validateStructTag: column %s: %w", columnPrefix+f.schema.Name, err)
+			}
+		}
+		field := FieldContext{
+			Column:      f.schema,
+			ColumnName:  f.schema.Name,
+			Mode:        columnMode(f.schema),
+			Description: description,
+			Name:        f.name,
+			Type:        f.goType,
+			Import:      f.pkg,
+			Tag:         structTagKey(opts) + ":\"" + f.tag() + "\"",
+		}
+		if directiveTag != "" {
+			field.Tag = field.Tag + " " + directiveTag
+		}
+		if opts.NoDescription {
+			field.Description = ""
+		}
+		if f.flattened {
+			field.Comments = append(field.Comments, "NOTE: flattened from the field "+f.fields[0].schema.Name+" of the REPEATED RECORD column. Load it by FromRow")
+		}
+		if !f.overridden && !f.record && isLossyType(f.schema.Type, opts.LossyTypes) {
+			field.Comments = append(field.Comments, "NOTE: "+string(f.schema.Type)+" stored as "+f.elemType)
+		}
+		if opts.DeprecatedMarker != "" && strings.Contains(description, opts.DeprecatedMarker) {
+			field.Comments = append(field.Comments, "Deprecated: "+commentText(strings.Replace(description, opts.DeprecatedMarker, "", 1)))
+		}
+		// NOTE(djeeno): ref. https://github.com/googleapis/google-cloud-go/blob/bigquery/v1.13.0/bigquery/schema.go#L66-L69
+		if f.schema.PolicyTags != nil && len(f.schema.PolicyTags.Names) > 0 {
+			if opts.PolicyTagComment != "" {
+				field.Comments = append(field.Comments, commentText(opts.PolicyTagComment)+": "+strings.Join(f.schema.PolicyTags.Names, ", "))
+			}
+			if opts.PolicyTagStructTag != "" {
+				field.Tag = field.Tag + " " + opts.PolicyTagStructTag
+			}
+		}
+		data.Fields = append(data.Fields, field)
+	}
+	if opts.CommentWidth > 0 {
+		data.Comments = wrapComments(data.Comments, opts.CommentWidth-len("// "))
+		for i := range data.Fields {
+			data.Fields[i].Comments = wrapComments(data.Fields[i].Comments, opts.CommentWidth-len("\t// "))
+		}
+	}
+
+	structTemplate := opts.Template
+	if structTemplate == nil {
+		structTemplate = defaultStructTemplate
+	}
+	var buf bytes.Buffer
+	if err = structTemplate.Execute(&buf, data); err != nil {
+		return "", nil, fmt.Errorf("template.Execute: %w", err)
+	}
+	generatedCode = buf.String()
+
+	// NOTE(djeeno): The custom template generates the whole code of the struct, that may not be Go.
+	if opts.Template != nil {
+		return generatedCode, importPackages, nil
+	}
+
+	if columnPrefix == "" {
+		if !opts.NoTableNameMethod {
+			var code string
+			code, err = generateTableNameMethodCode(table, structName, fields)
+			if err != nil {
+				return "", nil, fmt.Errorf("generateTableNameMethodCode: %w", err)
+			}
+			generatedCode = generatedCode + "\n" + code
+		}
+		if opts.IdentityMethods {
+			var code string
+			code, err = generateIdentityMethodsCode(table, md, structName, fields)
+			if err != nil {
+				return "", nil, fmt.Errorf("generateIdentityMethodsCode: %w", err)
+			}
+			generatedCode = generatedCode + "\n" + code
+		}
+		if opts.QueryMethod {
+			var code string
+			code, err = generateQueryMethodCode(table, structName, fields)
+			if err != nil {
+				return "", nil, fmt.Errorf("generateQueryMethodCode: %w", err)
+			}
+			generatedCode = generatedCode + "\n" + code
+			importPackages = append(importPackages, bigqueryImportPath)
+		}
+		if opts.ColumnsVar {
+			generatedCode = generatedCode + "\n" + generateColumnsVarCode(structName, uniqueIdentifier(structName+"Col", gen.typeNames), fields)
+		}
+		if opts.HelperInterfaces {
+			helperImport := opts.HelperImport
+			if helperImport == "" {
+				helperImport = defaultValueHelperImport
+			}
+			generatedCode = generatedCode + "\nvar _ " + importPathToAssumedName(helperImport) + ".Table = " + structName + "{}\n"
+			if opts.IdentityMethods {
+				generatedCode = generatedCode + "var _ " + importPathToAssumedName(helperImport) + ".Identity = " + structName + "{}\n"
+			}
+			importPackages = append(importPackages, helperImport)
+		}
+	}
+
+	if opts.Getters && columnPrefix == "" {
+		gettersCode, err := generateGettersCode(structName, uniqueIdentifier(structName+"Getter", gen.typeNames), fields)
+		if err != nil {
+			return "", nil, fmt.Errorf("generateGettersCode: %w", err)
+		}
+		generatedCode = generatedCode + "\n" + gettersCode
+	}
+
+	if opts.FromRow {
+		fromRowCode, err := generateFromRowCode(structName, fields)
+		if err != nil {
+			return "", nil, fmt.Errorf("generateFromRowCode: %w", err)
+		}
+		generatedCode = generatedCode + "\n" + fromRowCode
+		importPackages = append(importPackages, "fmt", bigqueryImportPath)
+	}
+
+	if opts.BigQueryNameMethod {
+		var code string
+		code, err = generateBigQueryNameMethodCode(structName, fields)
+		if err != nil {
+			return "", nil, fmt.Errorf("generateBigQueryNameMethodCode: %w", err)
+		}
+		generatedCode = generatedCode + "\n" + code
+	}
+
+	if opts.Stringer {
+		stringCode, err := generateStringCode(structName, fields)
+		if err != nil {
+			return "", nil, fmt.Errorf("generateStringCode: %w", err)
+		}
+		generatedCode = generatedCode + "\n" + stringCode
+		importPackages = append(importPackages, "fmt")
+	}
+
+	return generatedCode, importPackages, nil
+}
+
+// resolveStructFields resolves the fields of the struct structName of schemas, and generates the structs of its RECORD columns.
+func resolveStructFields(table *bigquery.Table, md *bigquery.TableMetadata, structName, columnPrefix string, schemas bigquery.Schema, gen *generation, opts Options) (fields []structField, nestedCode string, importPackages []string, err error) {
+	tableID := table.TableID
+	nameMapper := opts.NameMapper
+	if nameMapper == nil {
+		nameMapper = DefaultNameMapper
+	}
+
+	fieldNames := make(map[string]bool)
+	// NOTE(djeeno): The columns are filtered before the types are resolved, so that importPackages has only the packages of the generated fields.
+	for _, schema := range filterColumns(tableID, columnPrefix, schemas, opts) {
+		f := structField{schema: schema}
+
+		f.name = nameMapper(tableID, schema.Name)
+		if !isExportedIdentifier(f.name) {
+			return nil, "", nil, fmt.Errorf("field name is not an exported Go identifier. tableID=%s columnName=%s fieldName=%s", tableID, schema.Name, f.name)
+		}
+		f.name = uniqueIdentifier(f.name, fieldNames)
+
+		var pkg string
+		typeMapKey := tableID + "." + columnPrefix + schema.Name
+		var resolvedType, resolvedPkg string
+		if opts.TypeMap[typeMapKey] == "" && opts.TypeResolver != nil {
+			resolvedType, resolvedPkg, err = opts.TypeResolver.Resolve(schema)
+			if err != nil {
+				return nil, "", nil, fmt.Errorf("TypeResolver.Resolve: %w", err)
+			}
+		}
+		switch {
+		case opts.TypeMap[typeMapKey] != "":
+			f.elemType, pkg, err = parseGoType(opts.TypeMap[typeMapKey])
+			if err != nil {
+				return nil, "", nil, fmt.Errorf("parseGoType: %w", err)
+			}
+			f.overridden = true
+		case resolvedType != "":
+			f.elemType, pkg = resolvedType, resolvedPkg
+			f.overridden = true
+		case opts.FlattenRecords && isFlattenableRecord(tableID, columnPrefix, schema, opts):
+			// NOTE(djeeno): The nested struct is not generated, and the field has the slice of the type of the only field.
+			f.fields, _, _, err = resolveStructFields(table, md, structName+f.name, columnPrefix+schema.Name+".", schema.Schema, gen, opts)
+			if err != nil {
+				return nil, "", nil, fmt.Errorf("resolveStructFields: %w", err)
+			}
+			f.elemType, pkg = f.fields[0].elemType, f.fields[0].pkg
+			f.overridden = f.fields[0].overridden
+			f.flattened = true
+		case schema.Type == bigquery.RecordFieldType:
+			// NOTE(djeeno): ref. https://github.com/googleapis/google-cloud-go/blob/bigquery/v1.13.0/bigquery/schema.go#L258-L261
+			// NOTE(djeeno): "<Parent><Field>" may collide, e.g. "A" + "B_c" and "AB" + "_c", or with the struct name of the other table.
+			f.elemType = uniqueIdentifier(structName+f.name, gen.typeNames)
+			f.record = true
+			// NOTE(djeeno): The resolved fields are kept in f.fields, so that the callers do not resolve the nested columns again.
+			var code, nestedNestedCode string
+			var pkgs, nestedPkgs []string
+			f.fields, nestedNestedCode, nestedPkgs, err = resolveStructFields(table, md, f.elemType, columnPrefix+schema.Name+".", schema.Schema, gen, opts)
+			if err != nil {
+				return nil, "", nil, fmt.Errorf("resolveStructFields: %w", err)
+			}
+			code, pkgs, err = generateResolvedStructCode(table, md, f.elemType, columnPrefix+schema.Name+".", f.fields, gen, opts)
+			if err != nil {
+				return nil, "", nil, fmt.Errorf("generateResolvedStructCode: %w", err)
+			}
+			nestedCode = nestedCode + "\n" + code + nestedNestedCode
+			importPackages = append(importPackages, nestedPkgs...)
+			importPackages = append(importPackages, pkgs...)
+			if opts.NullableRecords == nullableRecordsPointer && !schema.Required && !schema.Repeated {
+				f.pointer = true
+				f.tagOption = tagOptionNullable
+			}
+		case opts.NullableTemporal == nullableTemporalPointer && isTemporalType(schema.Type) && !schema.Required && !schema.Repeated:
+			// NOTE(djeeno): NULL is nil, so that time.Time and the civil types are the pointers alike, instead of bigquery.NullTimestamp and the others of Nullable.
+			f.elemType, pkg, err = bigqueryFieldTypeToGoType(schema.Type)
+			if err != nil {
+				return nil, "", nil, fmt.Errorf("bigqueryFieldTypeToGoType: %w", err)
+			}
+			f.pointer = true
+		case opts.Nullable == nullableModeNull && !schema.Required && !schema.Repeated:
+			f.elemType, pkg, f.tagOption, err = bigqueryNullableFieldTypeToGoType(schema.Type)
+			if err != nil {
+				return nil, "", nil, fmt.Errorf("bigqueryNullableFieldTypeToGoType: %w", err)
+			}
+		case opts.TypeMap[string(schema.Type)] != "":
+			f.elemType, pkg, err = parseGoType(opts.TypeMap[string(schema.Type)])
+			if err != nil {
+				return nil, "", nil, fmt.Errorf("parseGoType: %w", err)
+			}
+			f.overridden = true
+		default:
+			f.elemType, pkg, err = bigqueryFieldTypeToGoType(schema.Type)
+			if err != nil {
+				return nil, "", nil, fmt.Errorf("bigqueryFieldTypeToGoType: %w", err)
+			}
+		}
+		f.pkg = pkg
+		if pkg != "" {
+			f.elemType, pkg = aliasImport(f.elemType, pkg, gen.importNames)
+			importPackages = append(importPackages, pkg)
+		}
+
+		f.goType = f.elemType
+		if f.pointer {
+			f.goType = "*" + f.goType
+		}
+		// NOTE(djeeno): ref. https://github.com/googleapis/google-cloud-go/blob/bigquery/v1.13.0/bigquery/schema.go#L237-L238
+		if schema.Repeated {
+			f.goType = "[]" + f.goType
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields, nestedCode, importPackages, nil
+}
+
+// generateTableNameMethodCode generates the method TableName of the table struct structName, that returns the ID of table.
+// NOTE(djeeno): The struct name may differ from the table ID, e.g. "X123_events" for "123_events".
+func generateTableNameMethodCode(table *bigquery.Table, structName string, fields []structField) (generatedCode string, err error) {
+	for _, f := range fields {
+		if f.name == "TableName" {
+			return "", fmt.Errorf("field %s of %s conflicts with the method TableName. set -%s to omit it. column=%s", f.name, structName, optNameNoTableNameMethod, f.schema.Name)
+		}
+	}
+
+	return "// TableName returns the ID of the BigQuery table of " + structName + ".\n" +
+		"func (" + structName + ") TableName() string {\n" +
+		"\treturn " + strconv.Quote(table.TableID) + "\n" +
+		"}\n", nil
+}
+
+// generateIdentityMethodsCode generates the methods ProjectID, DatasetID and FullID of the table struct structName.
+// FullID returns md.FullID, e.g. "project:dataset.table", or the same of table if md has no FullID, e.g. of the schema file.
+// It returns error if fields have the same names as the methods.
+func generateIdentityMethodsCode(table *bigquery.Table, md *bigquery.TableMetadata, structName string, fields []structField) (generatedCode string, err error) {
+	for _, f := range fields {
+		if f.name == "ProjectID" || f.name == "DatasetID" || f.name == "FullID" {
+			return "", fmt.Errorf("field %s of %s conflicts with the method of -%s. column=%s", f.name, structName, optNameIdentityMethods, f.schema.Name)
+		}
+	}
+
+	fullID := table.ProjectID + ":" + table.DatasetID + "." + table.TableID
+	if md != nil && md.FullID != "" {
+		fullID = md.FullID
+	}
+
+	return "// ProjectID returns the ID of the BigQuery project of " + structName + ".\n" +
+		"func (" + structName + ") ProjectID() string {\n" +
+		"\treturn " + strconv.Quote(table.ProjectID) + "\n" +
+		"}\n\n" +
+		"// DatasetID returns the ID of the BigQuery dataset of " + structName + ".\n" +
+		"func (" + structName + ") DatasetID() string {\n" +
+		"\treturn " + strconv.Quote(table.DatasetID) + "\n" +
+		"}\n\n" +
+		"// FullID returns the fully-qualified ID of the BigQuery table of " + structName + ", e.g. \"project:dataset.table\".\n" +
+		"func (" + structName + ") FullID() string {\n" +
+		"\treturn " + strconv.Quote(fullID) + "\n" +
+		"}\n", nil
+}
+
+// generateColumnsVarCode generates the variable varName of the anonymous struct, that has the column names of fields by the field names,
+// e.g. UsersCol.UserID for "user_id". The field names are of the struct structName, that are unique and valid Go identifiers.
+// The struct is used instead of the constants, so that the column names are grouped by the table for the completion.
+func generateColumnsVarCode(structName, varName string, fields []structField) (generatedCode string) {
+	var typeCode, valueCode string
+	for _, f := range fields {
+		typeCode = typeCode + "\t" + f.name + " string\n"
+		valueCode = valueCode + "\t" + f.name + ": " + strconv.Quote(f.schema.Name) + ",\n"
+	}
+
+	return "// " + varName + " is the names of the columns of " + structName + " by the field names.\n" +
+		"var " + varName + " = struct {\n" + typeCode + "}{\n" + valueCode + "}\n"
+}
+
+// generateQueryMethodCode generates the method Query of the table struct structName, that returns the query of the client
+// selecting the columns of fields from table, so that the rows are read into the struct by RowIterator.Next.
+// The columns are listed instead of "SELECT *", so that the columns excluded or added after generating are not selected.
+func generateQueryMethodCode(table *bigquery.Table, structName string, fields []structField) (generatedCode string, err error) {
+	schemas := make(bigquery.Schema, 0, len(fields))
+	for _, f := range fields {
+		if f.name == "Query" {
+			return "", fmt.Errorf("field %s of %s conflicts with the method of -%s. column=%s", f.name, structName, optNameQueryMethod, f.schema.Name)
+		}
+		schemas = append(schemas, f.schema)
+	}
+
+	return "// Query returns the query of client that selects the columns of " + structName + " from " + queryTableName(table) + ".\n" +
+		"func (" + structName + ") Query(client *bigquery.Client) *bigquery.Query {\n" +
+		"\treturn client.Query(" + strconv.Quote(selectAllQuery(table, schemas)) + ")\n" +
+		"}\n", nil
+}
+
+// generateBigQueryNameMethodCode generates the method BigQueryName of the struct structName, that returns the name of the column of the field
+// by the Go field name of fields, e.g. "user_id" for "UserID", or empty if structName has no such field.
+// The names of the nested columns are of the structs of the RECORD columns. It returns error if fields have the same name as the method.
+func generateBigQueryNameMethodCode(structName string, fields []structField) (generatedCode string, err error) {
+	var casesCode string
+	for _, f := range fields {
+		if f.name == "BigQueryName" {
+			return "", fmt.Errorf("field %s of %s conflicts with the method of -%s. column=%s", f.name, structName, optNameBigQueryNameMethod, f.schema.Name)
+		}
+		casesCode = casesCode + "\tcase " + strconv.Quote(f.name) + ":\n" +
+			"\t\treturn " + strconv.Quote(f.schema.Name) + "\n"
+	}
+	if casesCode != "" {
+		casesCode = "\tswitch field {\n" + casesCode + "\t}\n"
+	}
+
+	return "// BigQueryName returns the name of the BigQuery column of the field of " + structName + " by the Go field name, or empty if no such field.\n" +
+		"func (" + structName + ") BigQueryName(field string) string {\n" +
+		casesCode +
+		"\treturn \"\"\n" +
+		"}\n", nil
+}
+
+// generateGettersCode generates the interface interfaceName of the getters of fields, and the getters of the struct structName.
+// The getters return the zero values if the receiver is nil. The NULLABLE columns are returned as is, e.g. bigquery.NullInt64 or the pointer of the RECORD.
+// It returns error if a getter has the same name as a field, e.g. GetId of the columns "id" and "getId" by DefaultNameMapper.
+func generateGettersCode(structName, interfaceName string, fields []structField) (generatedCode string, err error) {
+	names := make(map[string]string, len(fields))
+	for _, f := range fields {
+		names[f.name] = f.schema.Name
+	}
+
+	var methodsCode string
+	for _, f := range fields {
+		// NOTE(djeeno): The getter cannot have the same name as the field.
+		getter := "Get" + f.name
+		if column, ok := names[getter]; ok {
+			return "", fmt.Errorf("getter %s of %s conflicts with the field of the column %s. column=%s", getter, structName, column, f.schema.Name)
+		}
+		generatedCode = generatedCode + "\t" + getter + "() " + f.goType + "\n"
+		methodsCode = methodsCode + "\n// " + getter + " returns " + f.name + ", or the zero value if s is nil.\n" +
+			"func (s *" + structName + ") " + getter + "() " + f.goType + " {\n" +
+			"\tif s == nil {\n" +
+			"\t\tvar zero " + f.goType + "\n" +
+			"\t\treturn zero\n" +
+			"\t}\n" +
+			"\treturn s." + f.name + "\n" +
+			"}\n"
+	}
+
+	return "// " + interfaceName + " is the interface of the getters of " + structName + ".\n" +
+		"type " + interfaceName + " interface {\n" + generatedCode + "}\n\n" +
+		"var _ " + interfaceName + " = (*" + structName + ")(nil)\n" +
+		methodsCode, nil
+}
+
+// generateStringCode generates the String method of the struct structName, that returns the names and values of fields.
+// It returns error if fields have the same name as the method.
+// NOTE(djeeno): The nil pointer fields are printed as "<nil>" by fmt, even if their String methods have the value receivers.
+// ref. https://golang.org/pkg/fmt/#hdr-Printing
+func generateStringCode(structName string, fields []structField) (generatedCode string, err error) {
+	format := make([]string, 0, len(fields))
+	var args string
+	for _, f := range fields {
+		if f.name == "String" {
+			return "", fmt.Errorf("field %s of %s conflicts with the method of -%s. column=%s", f.name, structName, optNameStringer, f.schema.Name)
+		}
+		format = append(format, f.name+": %v")
+		args = args + ", s." + f.name
+	}
+
+	return "// String returns the field names and values of s.\n" +
+		"func (s " + structName + ") String() string {\n" +
+		"\treturn fmt.Sprintf(" + strconv.Quote(structName+"{"+strings.Join(format, ", ")+"}") + args + ")\n" +
+		"}\n", nil
+}
+
+// generateFromRowCode generates the FromRow method of the struct structName, that assigns the fields from the row read as map[string]bigquery.Value.
+// It returns error if fields have the same name as the method.
+// ref. https://github.com/googleapis/google-cloud-go/blob/bigquery/v1.13.0/bigquery/value.go#L63-L88
+func generateFromRowCode(structName string, fields []structField) (generatedCode string, err error) {
+	for _, f := range fields {
+		if f.name == "FromRow" {
+			return "", fmt.Errorf("field %s of %s conflicts with the method of -%s. column=%s", f.name, structName, optNameFromRow, f.schema.Name)
+		}
+	}
+
+	generatedCode = "// FromRow assigns the fields of " + structName + " from row. NULL columns are left as is.\n" +
+		"func (s *" + structName + ") FromRow(row map[string]bigquery.Value) error {\n"
+	for _, f := range fields {
+		if f.overridden {
+			generatedCode = generatedCode + "\t// NOTE: " + f.name + " is not assigned because its type is overridden.\n"
+			continue
+		}
+		generatedCode = generatedCode + "\tif v, ok := row[" + strconv.Quote(f.schema.Name) + "]; ok && v != nil {\n"
+		if f.schema.Repeated {
+			generatedCode = generatedCode +
+				"\t\tvs, ok := v.([]bigquery.Value)\n" +
+				"\t\tif !ok {\n" +
+				"\t\t\treturn fmt.Errorf(\"column " + f.schema.Name + ": unexpected type %T\", v)\n" +
+				"\t\t}\n" +
+				"\t\ts." + f.name + " = make(" + f.goType + ", len(vs))\n" +
+				"\t\tfor i, v := range vs {\n" +
+				generateFromRowAssignCode(f, "s."+f.name+"[i]", "\t\t\t") +
+				"\t\t}\n"
+		} else {
+			generatedCode = generatedCode + generateFromRowAssignCode(f, "s."+f.name, "\t\t")
+		}
+		generatedCode = generatedCode + "\t}\n"
+	}
+	generatedCode = generatedCode + "\treturn nil\n}\n"
+
+	return generatedCode, nil
+}
+
+// generateFromRowAssignCode generates the code that assigns the non-nil bigquery.Value v to dst, the element of the field f.
+func generateFromRowAssignCode(f structField, dst, indent string) (generatedCode string) {
+	assertType, assign := f.elemType, "x"
+	switch {
+	case f.flattened:
+		// NOTE(djeeno): The element of the REPEATED RECORD column is map[string]bigquery.Value, and NULL fields are left as the zero values.
+		return indent + "x, ok := v.(map[string]bigquery.Value)\n" +
+			indent + "if !ok {\n" +
+			indent + "\treturn fmt.Errorf(\"column " + f.schema.Name + ": unexpected type %T\", v)\n" +
+			indent + "}\n" +
+			indent + "if v, ok := x[" + strconv.Quote(f.fields[0].schema.Name) + "]; ok && v != nil {\n" +
+			generateFromRowAssignCode(f.fields[0], dst, indent+"\t") +
+			indent + "}\n"
+	case f.record:
+		assertType = "map[string]bigquery.Value"
+	case nullTypes[f.elemType] != nil:
+		// NOTE(djeeno): The row has the value of the bigquery.NullXXX type, e.g. int64 for bigquery.NullInt64.
+		valueField := nullTypes[f.elemType].Field(0)
+		assertType = valueField.Type.String()
+		assign = f.elemType + "{" + valueField.Name + ": x, Valid: true}"
+	}
+
+	generatedCode = indent + "x, ok := v.(" + assertType + ")\n" +
+		indent + "if !ok {\n" +
+		indent + "\treturn fmt.Errorf(\"column " + f.schema.Name + ": unexpected type %T\", v)\n" +
+		indent + "}\n"
+	if !f.record {
+		if f.pointer {
+			return generatedCode + indent + dst + " = &" + assign + "\n"
+		}
+		return generatedCode + indent + dst + " = " + assign + "\n"
+	}
+
+	if f.pointer {
+		generatedCode = generatedCode + indent + dst + " = &" + f.elemType + "{}\n"
+	}
+	return generatedCode +
+		indent + "if err := " + dst + ".FromRow(x); err != nil {\n" +
+		indent + "\treturn fmt.Errorf(\"column " + f.schema.Name + ": %w\", err)\n" +
+		indent + "}\n"
+}
+
+// isFlattenableRecord reports whether schema is the REPEATED RECORD column that has only one field to generate, that is neither RECORD nor REPEATED.
+// columnPrefix is the path of the RECORD column that has schema in tableID, e.g. "record.", or empty.
+func isFlattenableRecord(tableID, columnPrefix string, schema *bigquery.FieldSchema, opts Options) bool {
+	if schema.Type != bigquery.RecordFieldType || !schema.Repeated {
+		return false
+	}
+	nested := filterColumns(tableID, columnPrefix+schema.Name+".", schema.Schema, opts)
+	return len(nested) == 1 && nested[0].Type != bigquery.RecordFieldType && !nested[0].Repeated
+}
+
+// filterColumns returns the columns in schemas to generate the fields for.
+// columnPrefix is the path of the RECORD column that has schemas in tableID, e.g. "record.", or empty for the table.
+func filterColumns(tableID, columnPrefix string, schemas bigquery.Schema, opts Options) (filtered bigquery.Schema) {
+	for _, schema := range schemas {
+		if opts.RequiredOnly && !schema.Required {
+			continue
+		}
+		if isExcludedColumn(tableID, columnPrefix+schema.Name, opts) {
+			continue
+		}
+		filtered = append(filtered, schema)
+	}
+	return filtered
+}
+
+// filterSchema returns the columns in schemas filtered by filterColumns, with the columns of the RECORD columns filtered as well.
+func filterSchema(tableID, columnPrefix string, schemas bigquery.Schema, opts Options) (filtered bigquery.Schema) {
+	for _, schema := range filterColumns(tableID, columnPrefix, schemas, opts) {
+		if schema.Type == bigquery.RecordFieldType {
+			record := *schema
+			record.Schema = filterSchema(tableID, columnPrefix+schema.Name+".", schema.Schema, opts)
+			schema = &record
+		}
+		filtered = append(filtered, schema)
+	}
+	return filtered
+}
+
+// isExcludedColumn reports whether the column columnPath of tableID, e.g. "record.column", matches any of opts.ExcludeColumns.
+// ref. https://golang.org/pkg/path/#Match
+func isExcludedColumn(tableID, columnPath string, opts Options) bool {
+	for _, pattern := range opts.ExcludeColumns {
+		// NOTE(djeeno): The patterns are validated by parseExcludeColumns, so that the error is ErrBadPattern of the patterns not parsed.
+		if matched, err := path.Match(pattern, tableID+"."+columnPath); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// excludedColumns returns the paths of the columns in schemas, and in its RECORD columns, that are excluded by opts.ExcludeColumns, e.g. "record.column".
+func excludedColumns(tableID, columnPrefix string, schemas bigquery.Schema, opts Options) (excluded []string) {
+	for _, schema := range schemas {
+		if isExcludedColumn(tableID, columnPrefix+schema.Name, opts) {
+			excluded = append(excluded, columnPrefix+schema.Name)
+			continue
+		}
+		if schema.Type == bigquery.RecordFieldType {
+			excluded = append(excluded, excludedColumns(tableID, columnPrefix+schema.Name+".", schema.Schema, opts)...)
+		}
+	}
+	return excluded
+}
+
+// parseExcludeColumns parses s, the comma-separated column paths "table.column" or the glob patterns of them, e.g. "*.raw_*". s may be empty.
+func parseExcludeColumns(s string) (excludeColumns []string, err error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	for _, pattern := range strings.Split(s, ",") {
+		if !strings.Contains(pattern, ".") {
+			return nil, fmt.Errorf("exclude column must be \"table.column\" or the glob pattern of it. pattern=%s", pattern)
+		}
+		if _, err = path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("path.Match: pattern=%s: %w", pattern, err)
+		}
+		excludeColumns = append(excludeColumns, pattern)
+	}
+
+	return excludeColumns, nil
+}
+
+// parseWildcards parses the comma-separated prefixes of the sharded tables of Options.Wildcards, e.g. "events_,sessions_".
+func parseWildcards(s string) (prefixes []string, err error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	for _, prefix := range strings.Split(s, ",") {
+		if prefix == "" || strings.ContainsAny(prefix, "*.") {
+			return nil, fmt.Errorf("wildcard must be the prefix of the table IDs without \"*\" and \".\", e.g. \"events_\". prefix=%s", prefix)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+
+	return prefixes, nil
+}
+
+// parseTypeMap parses comma-separated "table.column=importpath.Type" or "BIGQUERYTYPE=importpath.Type" pairs.
+// NOTE(djeeno): The Go type must be readable by the bigquery package, e.g. "INTEGER=int" fails to read the values that overflow int on 32-bit platforms.
+// ref. https://github.com/googleapis/google-cloud-go/blob/bigquery/v1.13.0/bigquery/value.go#L121-L131
+func parseTypeMap(s string) (typeMap map[string]string, err error) {
+	typeMap = make(map[string]string)
+	if s == "" {
+		return typeMap, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("type map must be \"table.column=importpath.Type\" or \"BIGQUERYTYPE=importpath.Type\". pair=%s", pair)
+		}
+		if err = validateTypeMapEntry(kv[0], kv[1]); err != nil {
+			return nil, fmt.Errorf("validateTypeMapEntry: %w", err)
+		}
+		typeMap[kv[0]] = kv[1]
+	}
+
+	return typeMap, nil
+}
+
+// validateTypeMapEntry validates the entry of the type map, the key "table.column" or "BIGQUERYTYPE" and goType "importpath.Type".
+func validateTypeMapEntry(key, goType string) (err error) {
+	// NOTE(djeeno): The key without "." is the BigQuery type. RECORD cannot be overridden, because it is generated as the nested struct.
+	if !strings.Contains(key, ".") {
+		if _, _, err = bigqueryFieldTypeToGoType(bigquery.FieldType(key)); err != nil {
+			return fmt.Errorf("bigqueryFieldTypeToGoType: %w", err)
+		}
+	}
+	if _, _, err = parseGoType(goType); err != nil {
+		return fmt.Errorf("parseGoType: key=%s: %w", key, err)
+	}
+	return nil
+}
+
+// parseLossyTypes parses s, the comma-separated BigQuery types, e.g. "GEOGRAPHY,NUMERIC". s may be empty.
+func parseLossyTypes(s string) (lossyTypes []bigquery.FieldType, err error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	for _, fieldType := range strings.Split(s, ",") {
+		// NOTE(djeeno): RECORD is not lossy, because it is generated as the nested struct.
+		if _, _, err = bigqueryFieldTypeToGoType(bigquery.FieldType(fieldType)); err != nil {
+			return nil, fmt.Errorf("bigqueryFieldTypeToGoType: %w", err)
+		}
+		lossyTypes = append(lossyTypes, bigquery.FieldType(fieldType))
+	}
+
+	return lossyTypes, nil
+}
+
+// isLossyType reports whether fieldType is in lossyTypes.
+func isLossyType(fieldType bigquery.FieldType, lossyTypes []bigquery.FieldType) bool {
+	for _, lossyType := range lossyTypes {
+		if fieldType == lossyType {
+			return true
+		}
+	}
+	return false
+}
+
+// parseGoType parses s, the Go type with its import path, e.g. "int", "[]byte", "*math/big.Rat" or "cloud.google.com/go/civil.DateTime",
+// and returns the Go type qualified by the package name assumed from the import path, e.g. "civil.DateTime", and the import path.
+// See importPathToAssumedName.
+func parseGoType(s string) (goType string, pkg string, err error) {
+	typeName := strings.TrimLeft(s, "*[]")
+	prefix := s[:len(s)-len(typeName)]
+
+	dot := strings.LastIndex(typeName, ".")
+	if dot == -1 {
+		if !token.IsIdentifier(typeName) {
+			return "", "", fmt.Errorf("invalid Go type. type=%s", s)
+		}
+		return s, "", nil
+	}
+
+	pkg, name := typeName[:dot], typeName[dot+1:]
+	if dot < strings.LastIndex(typeName, "/") || !isExportedIdentifier(name) {
+		return "", "", fmt.Errorf("invalid Go type. type=%s", s)
+	}
+
+	return prefix + importPathToAssumedName(pkg) + "." + name, pkg, nil
+}
+
+// importPathToAssumedName returns the package name assumed from importPath by the convention of goimports,
+// e.g. "geom" for "github.com/twpayne/go-geom", and "yaml" for "gopkg.in/yaml.v2".
+// ref. https://github.com/golang/tools/blob/b653051172e4/internal/imports/fix.go#L1136-L1151
+func importPathToAssumedName(importPath string) (name string) {
+	base := path.Base(importPath)
+	if strings.HasPrefix(base, "v") {
+		if _, err := strconv.Atoi(base[1:]); err == nil {
+			dir := path.Dir(importPath)
+			if dir != "." {
+				base = path.Base(dir)
+			}
+		}
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexFunc(base, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' }); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
+
+// reserveImportNames returns the registry of the package names of the import paths, that has the packages that the generated code refers by the names,
+// e.g. "bigquery" of bigquery.NullInt64 of FromRow, so that the packages of the overrides of the same names are aliased instead. See importName.
+func reserveImportNames(opts Options) (importNames map[string]string) {
+	importNames = make(map[string]string)
+	importPaths := []string{bigqueryImportPath, typeOfDateTime.PkgPath(), typeOfGoTime.PkgPath(), typeOfRat.Elem().PkgPath(), "fmt"}
+	if opts.HelperInterfaces {
+		helperImport := opts.HelperImport
+		if helperImport == "" {
+			helperImport = defaultValueHelperImport
+		}
+		importPaths = append(importPaths, helperImport)
+	}
+	for _, importPath := range importPaths {
+		importNames[importPath] = importPathToAssumedName(importPath)
+	}
+	return importNames
+}
+
+// importName returns the package name of importPath in the generated file registered in importNames, or registers and returns
+// importPathToAssumedName(importPath) if it is not used by the other packages, or the alias suffixed by "2", "3", ..., e.g. "uuid2".
+// The aliases are deterministic for the same order of the columns. importNames may be nil not to alias.
+func importName(importPath string, importNames map[string]string) (name string) {
+	if name, ok := importNames[importPath]; ok {
+		return name
+	}
+	name = importPathToAssumedName(importPath)
+	if importNames == nil {
+		return name
+	}
+
+	used := make(map[string]bool, len(importNames))
+	for _, usedName := range importNames {
+		used[usedName] = true
+	}
+	alias := name
+	for i := 2; used[alias]; i++ {
+		alias = name + strconv.Itoa(i)
+	}
+	if alias != name {
+		warnln("package name " + name + " of " + importPath + " is already used. use " + alias)
+	}
+	importNames[importPath] = alias
+
+	return alias
+}
+
+// aliasImport returns goType of importPath qualified by the package name of importName, e.g. "*uuid2.UUID" for "*uuid.UUID",
+// and the import package of importPath, that is "<alias> <import path>" if aliased. See parseImportPackage.
+func aliasImport(goType, importPath string, importNames map[string]string) (aliasedType, importPackage string) {
+	name, assumed := importName(importPath, importNames), importPathToAssumedName(importPath)
+	if name == assumed {
+		return goType, importPath
+	}
+
+	typeName := strings.TrimLeft(goType, "*[]")
+	if strings.HasPrefix(typeName, assumed+".") {
+		goType = goType[:len(goType)-len(typeName)] + name + strings.TrimPrefix(typeName, assumed)
+	}
+	return goType, name + " " + importPath
+}
+
+// parseImportPackage returns the package name and the import path of importPackage, the import path or "<alias> <import path>" of aliasImport.
+func parseImportPackage(importPackage string) (name, importPath string) {
+	if i := strings.Index(importPackage, " "); i >= 0 {
+		return importPackage[:i], importPackage[i+1:]
+	}
+	return importPathToAssumedName(importPackage), importPackage
+}
+
+// parseEmbedType parses s, the type to embed, e.g. "example.com/models.BaseModel" or "*example.com/models.BaseModel",
+// and returns the type qualified by the last element of the import path and the import path. s may be empty.
+func parseEmbedType(s string) (embedType string, pkg string, err error) {
+	if s == "" {
+		return "", "", nil
+	}
+
+	embedType, pkg, err = parseGoType(s)
+	if err != nil {
+		return "", "", fmt.Errorf("parseGoType: %w", err)
+	}
+	// NOTE(djeeno): ref. https://golang.org/ref/spec#Struct_types
+	if strings.HasPrefix(strings.TrimPrefix(embedType, "*"), "*") || strings.Contains(embedType, "[]") {
+		return "", "", fmt.Errorf("type cannot be embedded. type=%s", s)
+	}
+
+	return embedType, pkg, nil
+}
+
+// parseTemplateFile parses templateFile, the text/template of Options.Template. See StructTemplateData.
+func parseTemplateFile(templateFile string) (structTemplate *template.Template, err error) {
+	content, err := readFile(templateFile)
+	if err != nil {
+		return nil, fmt.Errorf("readFile: %w", err)
+	}
+
+	structTemplate, err = template.New(filepath.Base(templateFile)).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("template.Parse: %w", err)
+	}
+
+	return structTemplate, nil
+}
+
+// structTagPairs matches the space-separated key:"value" pairs of the struct tag.
+var structTagPairs = regexp.MustCompile(`^[^\s:"` + "`" + `]+:"(?:[^"\\]|\\.)*"(?: +[^\s:"` + "`" + `]+:"(?:[^"\\]|\\.)*")*$`)
+
+// validateStructTag validates s, the struct tag to add except the key tagKey of the columns, e.g. `pii:"true"`. s may be empty.
+// ref. https://golang.org/pkg/reflect/#StructTag
+func validateStructTag(s, tagKey string) (err error) {
+	if s == "" {
+		return nil
+	}
+	if _, ok := reflect.StructTag(s).Lookup(tagKey); ok || !structTagPairs.MatchString(s) {
+		return fmt.Errorf("struct tag must be space-separated key:\"value\" pairs except %s. tag=%s", tagKey, s)
+	}
+	return nil
+}
+
+// parseTagDirectives returns description without the struct tag directives prefix+key=value, and the struct tag of them, e.g. `validate:"required"` of "@tag:validate=required".
+// The value is up to the next space or backquote, and may be empty. The tag is empty if description has no directive.
+func parseTagDirectives(description, prefix string) (stripped, tag string) {
+	directive := tagDirectivePattern(prefix)
+
+	var pairs []string
+	for _, m := range directive.FindAllStringSubmatch(description, -1) {
+		pairs = append(pairs, m[1]+":"+strconv.Quote(m[2]))
+	}
+
+	return strings.TrimSpace(directive.ReplaceAllString(description, "")), strings.Join(pairs, " ")
+}
+
+// tagDirectivePatterns is the patterns of the struct tag directives by the prefixes, that are compiled once for each prefix. See tagDirectivePattern.
+var tagDirectivePatterns = make(map[string]*regexp.Regexp)
+
+// tagDirectivePatternsMutex guards tagDirectivePatterns, because the generations of the different files, e.g. of the library callers, may be concurrent.
+var tagDirectivePatternsMutex sync.Mutex
+
+// tagDirectivePattern returns the pattern of the struct tag directives of prefix, that matches the key and the value of parseTagDirectives.
+func tagDirectivePattern(prefix string) (pattern *regexp.Regexp) {
+	tagDirectivePatternsMutex.Lock()
+	defer tagDirectivePatternsMutex.Unlock()
+	if pattern, ok := tagDirectivePatterns[prefix]; ok {
+		return pattern
+	}
+	pattern = regexp.MustCompile(`[ \t]*` + regexp.QuoteMeta(prefix) + `([^\s:"=` + "`" + `]+)=([^\s` + "`" + `]*)`)
+	tagDirectivePatterns[prefix] = pattern
+	return pattern
+}
+
+// structTagKeyPattern matches the key of the struct tag, that has neither space, quote, colon nor backquote of the raw string literal.
+var structTagKeyPattern = regexp.MustCompile(`^[^\s:"` + "`" + `]+$`)
+
+// validateTagKey validates key, the key of the struct tags of the columns. key may be empty for defaultValueTagKey.
+// ref. https://golang.org/pkg/reflect/#StructTag
+func validateTagKey(key string) (err error) {
+	if key == "" {
+		return nil
+	}
+	if !structTagKeyPattern.MatchString(key) || strings.IndexFunc(key, unicode.IsControl) >= 0 {
+		return fmt.Errorf("struct tag key must be non-empty and have neither space, quote, colon, backquote nor control character. key=%q", key)
+	}
+	return nil
+}
+
+// structTagKey returns opts.TagKey, or defaultValueTagKey if empty.
+func structTagKey(opts Options) string {
+	if opts.TagKey == "" {
+		return defaultValueTagKey
+	}
+	return opts.TagKey
+}
+
+// commentText returns s as the text of a single line comment.
+func commentText(s string) (text string) {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// wrapComments returns the lines of comments wrapped at width. See wrapComment.
+func wrapComments(comments []string, width int) (lines []string) {
+	for _, comment := range comments {
+		lines = append(lines, wrapComment(comment, width)...)
+	}
+	return lines
+}
+
+// wrapComment splits comment into the lines of the words at most width characters, or of one word if the word is longer than width,
+// e.g. the URLs and the descriptions without spaces. The spaces are normalized as commentText.
+func wrapComment(comment string, width int) (lines []string) {
+	var line string
+	for _, word := range strings.Fields(comment) {
+		switch {
+		case line == "":
+			line = word
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > width:
+			lines = append(lines, line)
+			line = word
+		default:
+			line = line + " " + word
+		}
+	}
+	return append(lines, line)
+}
+
+// consoleURL returns the BigQuery console URL of table.
+func consoleURL(table *bigquery.Table) (consoleURL string) {
+	return "https://console.cloud.google.com/bigquery" +
+		"?p=" + url.QueryEscape(table.ProjectID) +
+		"&d=" + url.QueryEscape(table.DatasetID) +
+		"&t=" + url.QueryEscape(table.TableID) +
+		"&page=table"
+}
+
+// getAllDatasets returns the IDs of all datasets in projectID that match pattern except excludeDatasetIDs. See selectDatasets.
+// It returns error if no dataset is selected.
+func getAllDatasets(ctx context.Context, client *bigquery.Client, projectID string, excludeDatasetIDs []string, pattern *regexp.Regexp) (datasetIDs []string, err error) {
+	datasetIterator := client.DatasetsInProject(ctx, projectID)
+	for {
+		var dataset *bigquery.Dataset
+		err = nextWithRetry(ctx, func() (err error) {
+			dataset, err = datasetIterator.Next()
+			return err
+		}, func() {
+			token := datasetIterator.PageInfo().Token
+			datasetIterator = client.DatasetsInProject(ctx, projectID)
+			datasetIterator.PageInfo().Token = token
+		})
+		if err != nil {
+			if err == iterator.Done {
+				break
+			}
+			return nil, fmt.Errorf("datasetIterator.Next: %w", err)
+		}
+		datasetIDs = append(datasetIDs, dataset.DatasetID)
+	}
+
+	datasetIDs = selectDatasets(datasetIDs, excludeDatasetIDs, pattern)
+	if len(datasetIDs) == 0 {
+		if pattern != nil {
+			return nil, fmt.Errorf("no dataset matches pattern %s in project %s", pattern, projectID)
+		}
+		return nil, fmt.Errorf("no dataset found in project %s", projectID)
+	}
+
+	return datasetIDs, nil
+}
+
+// selectDatasets returns datasetIDs that match pattern except excludeDatasetIDs in the order of datasetIDs. pattern may be nil to match all.
+func selectDatasets(datasetIDs, excludeDatasetIDs []string, pattern *regexp.Regexp) (selected []string) {
+	exclude := make(map[string]bool)
+	for _, datasetID := range excludeDatasetIDs {
+		exclude[datasetID] = true
+	}
+
+	for _, datasetID := range datasetIDs {
+		if exclude[datasetID] {
+			infoln("exclude dataset: " + datasetID)
+			continue
+		}
+		if pattern != nil && !pattern.MatchString(datasetID) {
+			continue
+		}
+		selected = append(selected, datasetID)
+	}
+
+	return selected
+}
+
+// getTables returns opts.Tables of project.dataset sorted, or all the tables of project.dataset if opts.Tables is empty.
+// The tables of opts.Tables are not listed, so that the tables not found are skipped when their metadata is fetched.
+// truncated reports whether the listing is cut short by Options.SkipForbidden. See collectTables.
+func getTables(ctx context.Context, client *bigquery.Client, projectID, datasetID string, opts Options) (tables []*bigquery.Table, truncated bool, err error) {
+	if len(opts.Tables) == 0 {
+		tables, truncated, err = getAllTables(ctx, client, projectID, datasetID, opts.SkipForbidden)
+		if err != nil {
+			return nil, false, fmt.Errorf("getAllTables: %w", err)
+		}
+		return tables, truncated, nil
+	}
+
+	ds := client.DatasetInProject(projectID, datasetID)
+	for _, tableID := range opts.Tables {
+		tables = append(tables, ds.Table(tableID))
+	}
+	sortTables(tables)
+	return tables, false, nil
+}
+
+// applyWildcards replaces the shards of opts.Wildcards in tables, e.g. "events_20201101", with the wildcard table of each prefix, e.g. "events_*",
+// and returns opts that has the shards of the wildcard tables and the NameMapper of them. See wildcardNameMapper.
+// The prefixes that no table matches are warned. The table that matches multiple prefixes is the shard of the longest prefix.
+func applyWildcards(tables []*bigquery.Table, opts Options) (applied []*bigquery.Table, wildcardOpts Options) {
+	if len(opts.Wildcards) == 0 {
+		return tables, opts
+	}
+
+	prefixes := append([]string{}, opts.Wildcards...)
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+
+	opts.wildcardShards = make(map[string][]*bigquery.Table)
+	for _, table := range tables {
+		var wildcardTableID string
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(table.TableID, prefix) && len(table.TableID) > len(prefix) {
+				wildcardTableID = prefix + "*"
+				break
+			}
+		}
+		if wildcardTableID == "" {
+			applied = append(applied, table)
+			continue
+		}
+		if _, ok := opts.wildcardShards[wildcardTableID]; !ok {
+			// NOTE(djeeno): The wildcard table is the copy of the shard, that has the client of the shard.
+			wildcard := *table
+			wildcard.TableID = wildcardTableID
+			applied = append(applied, &wildcard)
+		}
+		opts.wildcardShards[wildcardTableID] = append(opts.wildcardShards[wildcardTableID], table)
+	}
+	for _, prefix := range opts.Wildcards {
+		if _, ok := opts.wildcardShards[prefix+"*"]; !ok {
+			warnln("no tables match the prefix of -" + optNameWildcard + ": " + prefix)
+		}
+	}
+	opts.NameMapper = wildcardNameMapper(opts.NameMapper)
+	sortTables(applied)
+
+	return applied, opts
+}
+
+// wildcardNameMapper returns the NameMapper that maps the wildcard tables, e.g. "events_*", to the struct names of nameMapper of the prefixes
+// without the trailing "_", e.g. "Events", and the pseudo-column wildcardTableSuffixColumn to "TableSuffix".
+func wildcardNameMapper(nameMapper NameMapper) NameMapper {
+	if nameMapper == nil {
+		nameMapper = DefaultNameMapper
+	}
+	return func(tableID, columnName string) (name string) {
+		if !strings.HasSuffix(tableID, "*") {
+			return nameMapper(tableID, columnName)
+		}
+		switch columnName {
+		case "":
+			return nameMapper(strings.TrimRight(strings.TrimSuffix(tableID, "*"), "_"), "")
+		// NOTE(djeeno): The column names of the tables cannot start with "_TABLE_", so that the column is always the pseudo-column.
+		// ref. https://cloud.google.com/bigquery/docs/schemas#column_names
+		case wildcardTableSuffixColumn:
+			return "TableSuffix"
+		default:
+			return nameMapper(tableID, columnName)
+		}
+	}
+}
+
+// wildcardMetadata returns the metadata of the wildcard table, that has the union of the schemas of shards and the pseudo-column wildcardTableSuffixColumn.
+// The metadata of shards are of tablesMetadata, the metadata fetched in advance, or fetched by the client.
+func wildcardMetadata(ctx context.Context, client *bigquery.Client, wildcard *bigquery.Table, shards []*bigquery.Table, tablesMetadata map[string]*bigquery.TableMetadata, opts Options) (md *bigquery.TableMetadata, err error) {
+	metadata := make([]*bigquery.TableMetadata, 0, len(shards))
+	for _, shard := range shards {
+		shardMetadata, ok := tablesMetadata[shard.TableID]
+		if !ok {
+			start := time.Now()
+			shardMetadata, err = shard.Metadata(ctx)
+			traceln(shard.TableID, "table.Metadata", start)
+			if err != nil {
+				return nil, fmt.Errorf("table.Metadata: %s: %w", shard.TableID, err)
+			}
+		}
+		metadata = append(metadata, shardMetadata)
+	}
+	// NOTE(djeeno): The wildcard queries read the tables by the schema of the most recently created table, so that its columns come first.
+	// ref. https://cloud.google.com/bigquery/docs/querying-wildcard-tables#schema_used_for_query_evaluation
+	sort.SliceStable(metadata, func(i, j int) bool { return metadata[i].CreationTime.After(metadata[j].CreationTime) })
+
+	schemas := make([]bigquery.Schema, 0, len(metadata))
+	for _, shardMetadata := range metadata {
+		schemas = append(schemas, shardMetadata.Schema)
+	}
+	merged, err := mergeWildcardSchemas(schemas)
+	if err != nil {
+		return nil, fmt.Errorf("mergeWildcardSchemas: %s: %w", wildcard.TableID, err)
+	}
+	merged = append(merged, &bigquery.FieldSchema{
+		Name:        wildcardTableSuffixColumn,
+		Type:        bigquery.StringFieldType,
+		Required:    true,
+		Description: "The suffix of the table ID of the shard, e.g. \"20201101\" of \"" + strings.TrimSuffix(wildcard.TableID, "*") + "20201101\".",
+	})
+
+	return &bigquery.TableMetadata{
+		Name:   wildcard.TableID,
+		Schema: merged,
+		FullID: wildcard.ProjectID + ":" + wildcard.DatasetID + "." + wildcard.TableID,
+		Type:   bigquery.RegularTable,
+	}, nil
+}
+
+// mergeWildcardSchemas returns the union of the columns of schemas in the order of their appearances, and the RECORD columns are merged alike.
+// The columns that are NULLABLE or missing in some schemas are NULLABLE, and the description is of the first schema that has it.
+// It returns error if the types or the REPEATED modes of the columns of the same name differ.
+func mergeWildcardSchemas(schemas []bigquery.Schema) (merged bigquery.Schema, err error) {
+	columns := make(map[string]*bigquery.FieldSchema)
+	var records map[string][]bigquery.Schema
+	for i, schema := range schemas {
+		for _, column := range schema {
+			current, ok := columns[column.Name]
+			if !ok {
+				copied := *column
+				// NOTE(djeeno): The column missing in the previous schemas is NULL in their rows.
+				copied.Required = column.Required && i == 0
+				columns[column.Name] = &copied
+				merged = append(merged, &copied)
+				current = &copied
+			} else {
+				if current.Type != column.Type || current.Repeated != column.Repeated {
+					return nil, fmt.Errorf("column types of the shards conflict. column=%s types=%s,%s", column.Name, columnMode(current)+" "+string(current.Type), columnMode(column)+" "+string(column.Type))
+				}
+				current.Required = current.Required && column.Required
+				if current.Description == "" {
+					current.Description = column.Description
+				}
+			}
+			if column.Type == bigquery.RecordFieldType {
+				if records == nil {
+					records = make(map[string][]bigquery.Schema)
+				}
+				records[column.Name] = append(records[column.Name], column.Schema)
+			}
+		}
+		// NOTE(djeeno): The column missing in this schema is NULL in its rows.
+		for name, current := range columns {
+			if current.Required && !schemaHasColumn(schema, name) {
+				current.Required = false
+			}
+		}
+	}
+
+	for _, column := range merged {
+		if column.Type != bigquery.RecordFieldType {
+			continue
+		}
+		column.Schema, err = mergeWildcardSchemas(records[column.Name])
+		if err != nil {
+			return nil, fmt.Errorf("mergeWildcardSchemas: %s: %w", column.Name, err)
+		}
+	}
+
+	return merged, nil
+}
+
+// schemaHasColumn reports whether schema has the column name.
+func schemaHasColumn(schema bigquery.Schema, name string) bool {
+	for _, column := range schema {
+		if column.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// tableList is the fully-qualified table IDs of readTableList grouped by the datasets.
+type tableList struct {
+	// project is the project of the tables.
+	project string
+	// datasets is the datasets of the tables in the order of their first appearance.
+	datasets []string
+	// tables is the table IDs by the datasets.
+	tables map[string][]string
+}
+
+// readTableList reads the newline-separated fully-qualified table IDs from filePath, or from stdin if filePath is filePathStdin.
+// See parseTableList.
+func readTableList(filePath string) (list *tableList, err error) {
+	var content []byte
+	if filePath == filePathStdin {
+		content, err = ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("ioutil.ReadAll: %w", err)
+		}
+	} else {
+		content, err = readFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("readFile: %w", err)
+		}
+	}
+
+	list, err = parseTableList(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("parseTableList: %w", err)
+	}
+
+	return list, nil
+}
+
+// parseTableList parses the newline-separated fully-qualified table IDs, "project.dataset.table" or "project:dataset.table" (e.g. the output of bq).
+// The blank lines and the lines starting with "#" are ignored, and the duplicated table IDs are generated once.
+// It returns error if the tables are in the multiple projects, or no table ID is found.
+func parseTableList(content string) (list *tableList, err error) {
+	list = &tableList{tables: make(map[string][]string)}
+	seen := make(map[string]bool)
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// NOTE(djeeno): The table IDs of Standard SQL may be quoted by backticks, e.g. `project.dataset.table`.
+		// NOTE(djeeno): The project is split from the right, because the domain-scoped project has "." and ":", e.g. "example.com:project".
+		fullID := strings.Trim(line, "`")
+		var project, dataset, table string
+		if idx := strings.LastIndex(fullID, "."); idx >= 0 {
+			table = fullID[idx+1:]
+			if idx2 := strings.LastIndexAny(fullID[:idx], ".:"); idx2 >= 0 {
+				project, dataset = fullID[:idx2], fullID[idx2+1:idx]
+			}
+		}
+		if project == "" || dataset == "" || table == "" {
+			return nil, fmt.Errorf("table ID must be \"project.dataset.table\". line=%d tableID=%s", i+1, line)
+		}
+
+		if list.project == "" {
+			list.project = project
+		}
+		if project != list.project {
+			return nil, fmt.Errorf("tables must be in one project. line=%d project=%s tablesProject=%s", i+1, project, list.project)
+		}
+
+		if seen[dataset+"."+table] {
+			continue
+		}
+		seen[dataset+"."+table] = true
+		if _, ok := list.tables[dataset]; !ok {
+			list.datasets = append(list.datasets, dataset)
+		}
+		list.tables[dataset] = append(list.tables[dataset], table)
+	}
+
+	if len(list.datasets) == 0 {
+		return nil, fmt.Errorf("no table ID is found")
+	}
+
+	return list, nil
+}
+
+func getAllTables(ctx context.Context, client *bigquery.Client, projectID, datasetID string, skipForbidden bool) (tables []*bigquery.Table, truncated bool, err error) {
+	start := time.Now()
+	tables, truncated, err = collectTables(ctx, func(pageToken string) tableIterator {
+		tableIterator := client.DatasetInProject(projectID, datasetID).Tables(ctx)
+		tableIterator.PageInfo().Token = pageToken
+		return tableIterator
+	}, skipForbidden)
+	// NOTE(djeeno): The duration includes all the pages and the retries of collectTables.
+	traceln("", "Dataset.Tables: "+projectID+"."+datasetID+": "+strconv.Itoa(len(tables))+" tables", start)
+	if err != nil {
+		return nil, false, fmt.Errorf("collectTables: %w", err)
+	}
+	// NOTE(djeeno): The order of the iterator is not guaranteed, so that the tables are sorted to keep the generated file stable.
+	sortTables(tables)
+	return tables, truncated, nil
+}
+
+// tableIterator is the iterator of the tables, e.g. *bigquery.TableIterator.
+type tableIterator interface {
+	Next() (*bigquery.Table, error)
+	PageInfo() *iterator.PageInfo
+}
+
+// collectTables returns all the tables of the iterator of newTableIterator until iterator.Done.
+// The listing is resumed by the new iterator from the page token of the page failed on the rate limit errors. See nextWithRetry.
+// It returns no tables on any other error, e.g. the error of fetching the next page,
+// so that the caller does not generate the file of the partial tables as if they were all the tables of the dataset.
+// If skipForbidden, the 403 Forbidden error that is not of the rate limits is warned, and the tables fetched until it are returned with truncated,
+// so that the caller does not treat the tables not listed as removed, e.g. by -prune or -diff.
+func collectTables(ctx context.Context, newTableIterator func(pageToken string) tableIterator, skipForbidden bool) (tables []*bigquery.Table, truncated bool, err error) {
+	tableIterator := newTableIterator("")
+	for {
+		var table *bigquery.Table
+		err = nextWithRetry(ctx, func() (err error) {
+			table, err = tableIterator.Next()
+			return err
+		}, func() {
+			tableIterator = newTableIterator(tableIterator.PageInfo().Token)
+		})
+		if err != nil {
+			if err == iterator.Done {
+				break
+			}
+			if skipForbidden && isForbidden(err) && !isRateLimited(err) {
+				warnln("tableIterator.Next: " + strconv.Itoa(len(tables)) + " tables fetched: skip the rest of the tables: " + err.Error())
+				return tables, true, nil
+			}
+			return nil, false, fmt.Errorf("tableIterator.Next: %d tables fetched: %w", len(tables), err)
+		}
+		tables = append(tables, table)
 	}
+	return tables, false, nil
+}
 
-	var filePath string
-	filePath, err = getOptOrEnvOrDefault(optNameOutputFile, *optValueOutputPath, envNameOutputFile, defaultValueOutputFile)
-	if err != nil {
-		return fmt.Errorf("getOptOrEnvOrDefault: %w", err)
+const (
+	// listRetryAttempts is the maximum number of the retries of each page of the listing on the rate limit errors.
+	listRetryAttempts = 5
+	// listRetryBaseDelay is the delay of the first retry, that is doubled for each retry up to listRetryMaxDelay.
+	listRetryBaseDelay = time.Second
+	listRetryMaxDelay  = 32 * time.Second
+)
+
+// listRetrySleep waits for delay or until ctx is done. It is replaced in the tests not to wait.
+var listRetrySleep = func(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
+}
 
-	// set GOOGLE_APPLICATION_CREDENTIALS for Google Cloud SDK
-	if os.Getenv(envNameGoogleApplicationCredentials) != keyfile {
-		if err = os.Setenv(envNameGoogleApplicationCredentials, keyfile); err != nil {
-			return fmt.Errorf("os.Setenv: %w", err)
+// nextWithRetry calls next, the Next of the iterator of the datasets or the tables, and retries it on the rate limit errors
+// up to listRetryAttempts times with the delays of listRetryDelay. See isRateLimited.
+// The error of the iterator is sticky, so that resume must replace the iterator by the new one from the page token of the page failed.
+func nextWithRetry(ctx context.Context, next func() error, resume func()) (err error) {
+	for retries := 0; ; retries++ {
+		err = next()
+		if err == nil || err == iterator.Done || !isRateLimited(err) || retries >= listRetryAttempts {
+			return err
 		}
+
+		delay := listRetryDelay(retries, err)
+		warnln("listing is rate limited: retry in " + delay.String() + ": " + err.Error())
+		if err = listRetrySleep(ctx, delay); err != nil {
+			return fmt.Errorf("listRetrySleep: %w", err)
+		}
+		resume()
 	}
+}
 
-	client, err := bigquery.NewClient(ctx, project)
-	if err != nil {
-		return fmt.Errorf("bigquery.NewClient: %w", err)
+// isRateLimited reports whether err is the rate limit error of the BigQuery API, that is 429 Too Many Requests,
+// or 403 Forbidden of the reason quotaExceeded or rateLimitExceeded.
+// The client retries rateLimitExceeded by itself, but not quotaExceeded, e.g. of the quota of the API requests per user.
+// ref. https://cloud.google.com/bigquery/docs/error-messages
+func isRateLimited(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
 	}
-	defer func() {
-		if err = client.Close(); err != nil {
-			warnln("client.Close: " + err.Error())
+	if apiErr.Code == http.StatusTooManyRequests {
+		return true
+	}
+	for _, item := range apiErr.Errors {
+		if item.Reason == "quotaExceeded" || item.Reason == "rateLimitExceeded" {
+			return true
 		}
-	}()
-
-	generatedCode, err := Generate(ctx, client, dataset)
-	if err != nil {
-		return fmt.Errorf("Generate: %w", err)
 	}
+	return false
+}
 
-	// NOTE(djeeno): output
-	if err = ioutil.WriteFile(filePath, generatedCode, 0644); err != nil {
-		return fmt.Errorf("ioutil.WriteFile: %w", err)
+// listRetryDelay returns the delay of the retry of the retries-th retry on err.
+// It is the random delay up to the exponential backoff (full jitter), so that the concurrent listings do not retry at once,
+// or the Retry-After of the response plus the jitter up to listRetryBaseDelay if any.
+// ref. https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+func listRetryDelay(retries int, err error) time.Duration {
+	if retryAfter := retryAfterDelay(err, time.Now()); retryAfter > 0 {
+		return retryAfter + time.Duration(rand.Int63n(int64(listRetryBaseDelay)))
 	}
 
-	return nil
+	backoff := listRetryMaxDelay
+	if retries < 6 && listRetryBaseDelay<<uint(retries) < listRetryMaxDelay {
+		backoff = listRetryBaseDelay << uint(retries)
+	}
+	return time.Duration(rand.Int63n(int64(backoff))) + 1
 }
 
-func Generate(ctx context.Context, client *bigquery.Client, dataset string) (generatedCode []byte, err error) {
+// retryAfterDelay returns the delay of the Retry-After header of the response of err, the seconds or the HTTP date, from now,
+// or 0 if err has no Retry-After.
+// ref. https://tools.ietf.org/html/rfc7231#section-7.1.3
+func retryAfterDelay(err error, now time.Time) time.Duration {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Header == nil {
+		return 0
+	}
 
-	const head = `// Code generated by go run github.com/djeeno/bqschema-gen-go; DO NOT EDIT.
+	retryAfter := apiErr.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(retryAfter); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
 
-//go:generate go run github.com/djeeno/bqschema-gen-go
+// informationSchemaColumn is the row of INFORMATION_SCHEMA.COLUMNS.
+// ref. https://cloud.google.com/bigquery/docs/information-schema-columns
+type informationSchemaColumn struct {
+	TableName  string `bigquery:"table_name"`
+	ColumnName string `bigquery:"column_name"`
+	IsNullable string `bigquery:"is_nullable"`
+	DataType   string `bigquery:"data_type"`
+}
 
-package bqschema
+// informationSchemaDescription is the row of the descriptions of the tables and the columns.
+// FieldPath is empty for the description of the table.
+type informationSchemaDescription struct {
+	TableName   string `bigquery:"table_name"`
+	FieldPath   string `bigquery:"field_path"`
+	Description string `bigquery:"description"`
+}
 
-`
+// getInformationSchemaMetadata returns the metadata of the tables in project.dataset by the table IDs,
+// that have the schemas and the descriptions only, by querying INFORMATION_SCHEMA instead of fetching the metadata of each table.
+// The hidden pseudo-columns, e.g. _PARTITIONTIME and _PARTITIONDATE of the ingestion-time partitioned tables, are not in the schemas as table.Metadata.
+// The tables that have the columns of the types not supported by parseStandardSQLType are not in the result.
+func getInformationSchemaMetadata(ctx context.Context, client *bigquery.Client, project, dataset string) (metadata map[string]*bigquery.TableMetadata, err error) {
+	// NOTE(djeeno): ref. https://cloud.google.com/bigquery/docs/information-schema-column-field-paths
+	//               ref. https://cloud.google.com/bigquery/docs/information-schema-table-options
+	prefix := "`" + project + "." + dataset + ".INFORMATION_SCHEMA."
+	columnsQuery := "SELECT table_name, column_name, is_nullable, data_type FROM " + prefix + "COLUMNS` WHERE is_hidden = 'NO' ORDER BY table_name, ordinal_position"
+	descriptionsQuery := "SELECT table_name, field_path, description FROM " + prefix + "COLUMN_FIELD_PATHS` WHERE description IS NOT NULL" +
+		" UNION ALL SELECT table_name, '' AS field_path, option_value AS description FROM " + prefix + "TABLE_OPTIONS` WHERE option_name = 'description'"
 
-	tables, err := getAllTables(ctx, client, dataset)
+	it, err := client.Query(columnsQuery).Read(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("getAllTables: %w", err)
+		return nil, fmt.Errorf("client.Query: %w", err)
 	}
-
-	var tail string
-	var importPackages []string
-	for _, table := range tables {
-		var structCode string
-		var pkgs []string
-		structCode, pkgs, err = generateTableSchemaCode(ctx, table)
+	metadata = make(map[string]*bigquery.TableMetadata)
+	unsupported := make(map[string]bool)
+	for {
+		var column informationSchemaColumn
+		if err = it.Next(&column); err != nil {
+			if err == iterator.Done {
+				break
+			}
+			return nil, fmt.Errorf("it.Next: %w", err)
+		}
+		if unsupported[column.TableName] {
+			continue
+		}
+		var field *bigquery.FieldSchema
+		field, err = parseStandardSQLType(column.DataType)
 		if err != nil {
-			warnln("generateTableSchemaCode: " + err.Error())
+			warnTableln(column.TableName, "parseStandardSQLType: "+err.Error()+": fall back to the metadata of the table")
+			unsupported[column.TableName] = true
+			delete(metadata, column.TableName)
 			continue
 		}
-
-		if len(pkgs) > 0 {
-			importPackages = append(importPackages, pkgs...)
+		field.Name = column.ColumnName
+		field.Required = column.IsNullable == "NO" && !field.Repeated
+		md, ok := metadata[column.TableName]
+		if !ok {
+			md = &bigquery.TableMetadata{FullID: project + ":" + dataset + "." + column.TableName}
+			metadata[column.TableName] = md
 		}
-		tail = tail + structCode
+		md.Schema = append(md.Schema, field)
 	}
 
-	importCode := generateImportPackagesCode(importPackages)
-
-	// NOTE(djeeno): combine
-	code := head + importCode + tail
-
-	gen := []byte(code)
-
-	genFmt, err := format.Source(gen)
+	it, err = client.Query(descriptionsQuery).Read(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("format.Source: %w", err)
+		return nil, fmt.Errorf("client.Query: %w", err)
 	}
-
-	genImports, err := imports.Process("", genFmt, nil)
-	if err != nil {
-		return nil, fmt.Errorf("imports.Process: %w", err)
+	for {
+		var description informationSchemaDescription
+		if err = it.Next(&description); err != nil {
+			if err == iterator.Done {
+				break
+			}
+			return nil, fmt.Errorf("it.Next: %w", err)
+		}
+		if md, ok := metadata[description.TableName]; ok {
+			setInformationSchemaDescription(md, description.FieldPath, description.Description)
+		}
 	}
 
-	return genImports, nil
+	return metadata, nil
 }
 
-func generateImportPackagesCode(importPackages []string) (generatedCode string) {
-	importPackagesUniq := make(map[string]bool)
-	for _, pkg := range importPackages {
-		importPackagesUniq[pkg] = true
-	}
-
-	// NOTE(djeeno): fix order
-	importPackagesUniqSort := make([]string, len(importPackagesUniq))
-	idx := 0
-	for _, pkg := range importPackages {
-		if importPackagesUniq[pkg] {
-			importPackagesUniq[pkg] = false
-			importPackagesUniqSort[idx] = pkg
-			idx++
+// setInformationSchemaDescription sets description to the column of fieldPath in md, e.g. "record.column", or to md if fieldPath is empty.
+func setInformationSchemaDescription(md *bigquery.TableMetadata, fieldPath, description string) {
+	if fieldPath == "" {
+		// NOTE(djeeno): TABLE_OPTIONS.option_value is the string literal, e.g. "\"description\"".
+		if unquoted, err := strconv.Unquote(description); err == nil {
+			description = unquoted
 		}
+		md.Description = description
+		return
 	}
 
-	switch {
-	case len(importPackagesUniq) == 0:
-		generatedCode = ""
-	case len(importPackagesUniq) == 1:
-		for pkg := range importPackagesUniq {
-			generatedCode = "import \"" + pkg + "\"\n"
+	schemas := md.Schema
+	names := strings.Split(fieldPath, ".")
+	for i, name := range names {
+		var field *bigquery.FieldSchema
+		for _, schema := range schemas {
+			if schema.Name == name {
+				field = schema
+				break
+			}
 		}
-		generatedCode = generatedCode + "\n"
-	case len(importPackagesUniq) >= 2:
-		generatedCode = "import (\n"
-		for _, pkg := range importPackagesUniqSort {
-			generatedCode = generatedCode + "\t\"" + pkg + "\"\n"
+		if field == nil {
+			return
 		}
-		generatedCode = generatedCode + ")\n\n"
+		if i == len(names)-1 {
+			field.Description = description
+			return
+		}
+		schemas = field.Schema
 	}
-
-	return generatedCode
 }
 
-func generateTableSchemaCode(ctx context.Context, table *bigquery.Table) (generatedCode string, importPackages []string, err error) {
-	if len(table.TableID) == 0 {
-		return "", nil, fmt.Errorf("*bigquery.Table.TableID is empty. *bigquery.Table struct dump: %#v", table)
-	}
-	structName := capitalizeInitial(table.TableID)
+// standardSQLFieldTypes is the field types of the standard SQL data types in INFORMATION_SCHEMA.
+// ref. https://cloud.google.com/bigquery/docs/reference/standard-sql/data-types
+var standardSQLFieldTypes = map[string]bigquery.FieldType{
+	"STRING":    bigquery.StringFieldType,
+	"BYTES":     bigquery.BytesFieldType,
+	"INT64":     bigquery.IntegerFieldType,
+	"FLOAT64":   bigquery.FloatFieldType,
+	"BOOL":      bigquery.BooleanFieldType,
+	"TIMESTAMP": bigquery.TimestampFieldType,
+	"DATE":      bigquery.DateFieldType,
+	"TIME":      bigquery.TimeFieldType,
+	"DATETIME":  bigquery.DateTimeFieldType,
+	"NUMERIC":   bigquery.NumericFieldType,
+	"GEOGRAPHY": bigquery.GeographyFieldType,
+}
 
-	var md *bigquery.TableMetadata
-	md, err = table.Metadata(ctx)
+// parseStandardSQLType parses dataType, the standard SQL data type in INFORMATION_SCHEMA.COLUMNS, e.g. "ARRAY<STRUCT<id INT64 NOT NULL, name STRING>>",
+// and returns the field schema without the name.
+func parseStandardSQLType(dataType string) (field *bigquery.FieldSchema, err error) {
+	field, rest, err := parseStandardSQLTypePrefix(dataType)
 	if err != nil {
-		return "", nil, fmt.Errorf("table.Metadata: %w", err)
+		return nil, fmt.Errorf("parseStandardSQLTypePrefix: %w", err)
 	}
+	if strings.TrimSpace(rest) != "" {
+		return nil, fmt.Errorf("data type has the trailing characters. dataType=%s rest=%s", dataType, rest)
+	}
+	return field, nil
+}
 
-	// NOTE(djeeno): structs
-	generatedCode = "// " + structName + " is BigQuery Table `" + md.FullID + "` schema struct.\n" +
-		"// Description: " + md.Description + "\n" +
-		"type " + structName + " struct {\n"
-
-	schemas := []*bigquery.FieldSchema(md.Schema)
-
-	for _, schema := range schemas {
-		var goTypeStr, pkg string
-		goTypeStr, pkg, err = bigqueryFieldTypeToGoType(schema.Type)
+// parseStandardSQLTypePrefix parses the standard SQL data type at the beginning of s, and returns the rest of s.
+func parseStandardSQLTypePrefix(s string) (field *bigquery.FieldSchema, rest string, err error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, "ARRAY<"):
+		field, rest, err = parseStandardSQLTypePrefix(strings.TrimPrefix(s, "ARRAY<"))
 		if err != nil {
-			return "", nil, fmt.Errorf("bigqueryFieldTypeToGoType: %w", err)
+			return nil, "", fmt.Errorf("parseStandardSQLTypePrefix: %w", err)
 		}
-		if pkg != "" {
-			importPackages = append(importPackages, pkg)
+		rest = strings.TrimSpace(rest)
+		if !strings.HasPrefix(rest, ">") {
+			return nil, "", fmt.Errorf("ARRAY is not closed. s=%s", s)
+		}
+		// NOTE(djeeno): ARRAY cannot have ARRAY nor NOT NULL directly.
+		field.Repeated = true
+		field.Required = false
+		return field, rest[1:], nil
+	case strings.HasPrefix(s, "STRUCT<"):
+		field = &bigquery.FieldSchema{Type: bigquery.RecordFieldType}
+		rest = strings.TrimPrefix(s, "STRUCT<")
+		for {
+			rest = strings.TrimSpace(rest)
+			var name string
+			name, rest, err = parseStandardSQLFieldName(rest)
+			if err != nil {
+				return nil, "", fmt.Errorf("parseStandardSQLFieldName: %w", err)
+			}
+			var nested *bigquery.FieldSchema
+			nested, rest, err = parseStandardSQLTypePrefix(rest)
+			if err != nil {
+				return nil, "", fmt.Errorf("parseStandardSQLTypePrefix: %w", err)
+			}
+			nested.Name = name
+			rest = strings.TrimSpace(rest)
+			if strings.HasPrefix(rest, "NOT NULL") {
+				nested.Required = !nested.Repeated
+				rest = strings.TrimSpace(strings.TrimPrefix(rest, "NOT NULL"))
+			}
+			field.Schema = append(field.Schema, nested)
+			switch {
+			case strings.HasPrefix(rest, ","):
+				rest = rest[1:]
+			case strings.HasPrefix(rest, ">"):
+				return field, rest[1:], nil
+			default:
+				return nil, "", fmt.Errorf("STRUCT is not closed. s=%s", s)
+			}
+		}
+	default:
+		end := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsUpper(r) && !unicode.IsDigit(r) })
+		if end < 0 {
+			end = len(s)
 		}
-		generatedCode = generatedCode + "\t" + capitalizeInitial(schema.Name) + " " + goTypeStr + " `bigquery:\"" + schema.Name + "\"`\n"
+		fieldType, ok := standardSQLFieldTypes[s[:end]]
+		if !ok {
+			return nil, "", fmt.Errorf("data type not supported. s=%s", s)
+		}
+		rest = s[end:]
+		// NOTE(djeeno): The parameters of the parameterized data types, e.g. "STRING(10)" or "NUMERIC(10, 2)", are ignored.
+		if strings.HasPrefix(rest, "(") {
+			closing := strings.Index(rest, ")")
+			if closing < 0 {
+				return nil, "", fmt.Errorf("parameters are not closed. s=%s", s)
+			}
+			rest = rest[closing+1:]
+		}
+		return &bigquery.FieldSchema{Type: fieldType}, rest, nil
 	}
-	generatedCode = generatedCode + "}\n"
-
-	return generatedCode, importPackages, nil
 }
 
-func getAllTables(ctx context.Context, client *bigquery.Client, datasetID string) (tables []*bigquery.Table, err error) {
-	tableIterator := client.Dataset(datasetID).Tables(ctx)
-	for {
-		var table *bigquery.Table
-		table, err = tableIterator.Next()
-		if err != nil {
-			if err == iterator.Done {
-				break
-			}
-			return nil, fmt.Errorf("tableIterator.Next: %w", err)
+// parseStandardSQLFieldName parses the field name of STRUCT at the beginning of s, that may be quoted by backticks, and returns the rest of s.
+func parseStandardSQLFieldName(s string) (name, rest string, err error) {
+	if strings.HasPrefix(s, "`") {
+		closing := strings.Index(s[1:], "`")
+		if closing < 0 {
+			return "", "", fmt.Errorf("field name is not closed. s=%s", s)
 		}
-		tables = append(tables, table)
+		return s[1 : closing+1], s[closing+2:], nil
 	}
-	return tables, nil
+	end := strings.IndexFunc(s, unicode.IsSpace)
+	if end <= 0 {
+		return "", "", fmt.Errorf("field name not found. s=%s", s)
+	}
+	return s[:end], s[end:], nil
+}
+
+// sortTables sorts tables by the table IDs.
+func sortTables(tables []*bigquery.Table) {
+	sort.SliceStable(tables, func(i, j int) bool {
+		return tables[i].TableID < tables[j].TableID
+	})
+}
+
+// isForbidden reports whether err is a 403 Forbidden error of the BigQuery API.
+func isForbidden(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusForbidden
 }
 
 func readFile(path string) (content []byte, err error) {
@@ -269,7 +5732,28 @@ func readFile(path string) (content []byte, err error) {
 	return bytea, nil
 }
 
+// getOptOrEnvOrDefault returns the value of the required setting, that is optValue of the option optName, the environment variable envName,
+// or defaultValue, in that order of precedence. It returns error if all of them are empty. See getOptOrEnv for the optional settings.
 func getOptOrEnvOrDefault(optName, optValue, envName, defaultValue string) (value string, err error) {
+	value, err = getOptOrEnv(optName, optValue, envName)
+	if err != nil {
+		return "", fmt.Errorf("getOptOrEnv: %w", err)
+	}
+	if value != "" {
+		return value, nil
+	}
+
+	if defaultValue != "" {
+		infoln("use default option value: -" + optName + "=" + defaultValue)
+		return defaultValue, nil
+	}
+
+	return "", fmt.Errorf("set option -%s, or set environment variable %s", optName, envName)
+}
+
+// getOptOrEnv returns the value of the optional setting, that is optValue of the option optName or the environment variable envName,
+// or empty without error if both are empty.
+func getOptOrEnv(optName, optValue, envName string) (value string, err error) {
 	if optName == "" {
 		return "", fmt.Errorf("optName is empty")
 	}
@@ -285,31 +5769,163 @@ func getOptOrEnvOrDefault(optName, optValue, envName, defaultValue string) (valu
 		return envValue, nil
 	}
 
-	if defaultValue != "" {
-		infoln("use default option value: -" + optName + "=" + defaultValue)
-		return defaultValue, nil
-	}
-
-	return "", fmt.Errorf("set option -%s, or set environment variable %s", optName, envName)
+	return "", nil
 }
 
 func capitalizeInitial(s string) (capitalized string) {
 	if len(s) == 0 {
 		return ""
 	}
-	return strings.ToUpper(s[:1]) + s[1:]
+	// NOTE(djeeno): The initial is the first rune, that may be of multiple bytes, e.g. "é".
+	initial, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(initial)) + s[size:]
+}
+
+// sanitizeIdentifier replaces the characters of s that cannot be in Go identifiers by "_", e.g. "events-v2" to "events_v2".
+func sanitizeIdentifier(s string) (sanitized string) {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// exportedIdentifier returns s as an exported Go identifier.
+// The original casing is preserved except the initial, and "X" is prefixed if s does not start with a letter (e.g. "_id").
+func exportedIdentifier(s string) (identifier string) {
+	capitalized := capitalizeInitial(s)
+	if initial, _ := utf8.DecodeRuneInString(capitalized); !unicode.IsUpper(initial) {
+		return "X" + capitalized
+	}
+	return capitalized
+}
+
+// isExportedIdentifier reports whether s is a valid exported Go identifier.
+func isExportedIdentifier(s string) bool {
+	return token.IsIdentifier(s) && token.IsExported(s)
+}
+
+// uniqueIdentifier returns identifier, or identifier suffixed by "_2", "_3", ... if it is already used.
+// The returned identifier is marked as used.
+func uniqueIdentifier(identifier string, used map[string]bool) (unique string) {
+	unique = identifier
+	for i := 2; used[unique]; i++ {
+		unique = identifier + "_" + strconv.Itoa(i)
+	}
+	if unique != identifier {
+		warnln("identifier " + identifier + " is already used. use " + unique)
+	}
+	used[unique] = true
+	return unique
+}
+
+// logJSON writes the logs as the JSON lines. See logln.
+var logJSON bool
+
+// trace writes the durations of the BigQuery API calls. See traceln.
+var trace bool
+
+// logWriter is the writer of the JSON lines. The logs are written to stderr, because stdout may be the generated code.
+var logWriter io.Writer = os.Stderr
+
+// logLine is the JSON line of the log.
+type logLine struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Table   string `json:"table,omitempty"`
+	Message string `json:"message"`
+}
+
+// progressWriter is the terminal to print the progress of the tables to, or nil not to print it. See printProgress.
+var progressWriter io.Writer
+
+// progressMutex guards progressShown.
+var progressMutex sync.Mutex
+
+// progressShown reports whether the line of the progress is shown on progressWriter, and must be cleared before the logs.
+var progressShown bool
+
+// isTerminal reports whether file is the terminal, instead of the file, the pipe or the other character devices such as /dev/null.
+func isTerminal(file *os.File) bool {
+	return term.IsTerminal(int(file.Fd()))
+}
+
+// printProgress overwrites the line of the progress on progressWriter, e.g. "[12/300] generating orders". It does nothing if progressWriter is nil.
+func printProgress(current, total int, verb, tableID string) {
+	if progressWriter == nil {
+		return
+	}
+	progressMutex.Lock()
+	defer progressMutex.Unlock()
+
+	// NOTE(djeeno): "\r" and "\x1b[K" move the cursor to the beginning of the line and erase the line. "\x1b[36m" is cyan.
+	_, _ = fmt.Fprintf(progressWriter, "\r\x1b[K\x1b[36m[%d/%d]\x1b[0m %s %s", current, total, verb, tableID)
+	progressShown = true
+}
+
+// clearProgress erases the line of the progress on progressWriter if it is shown.
+func clearProgress() {
+	if progressWriter == nil {
+		return
+	}
+	progressMutex.Lock()
+	defer progressMutex.Unlock()
+
+	if progressShown {
+		_, _ = io.WriteString(progressWriter, "\r\x1b[K")
+		progressShown = false
+	}
+}
+
+// logln writes content of level, e.g. "INFO", about tableID to stderr. tableID may be empty.
+// The line of the progress is erased before the log. See printProgress.
+func logln(level, tableID, content string) {
+	clearProgress()
+	if !logJSON {
+		if tableID != "" {
+			content = content + ". tableID=" + tableID
+		}
+		log.Println(level + ": " + content)
+		return
+	}
+
+	line, err := json.Marshal(logLine{Time: time.Now().Format(time.RFC3339Nano), Level: level, Table: tableID, Message: content})
+	if err != nil {
+		log.Println("ERROR: json.Marshal: " + err.Error())
+		return
+	}
+	_, _ = logWriter.Write(append(line, '\n'))
 }
 
 func infoln(content string) {
-	log.Println("INFO: " + content)
+	logln("INFO", "", content)
 }
 
 func warnln(content string) {
-	log.Println("WARN: " + content)
+	logln("WARN", "", content)
+}
+
+// infoTableln writes the information about the table tableID, e.g. the table filtered out by the options.
+func infoTableln(tableID, content string) {
+	logln("INFO", tableID, content)
+}
+
+// warnTableln writes the warning about the table tableID, e.g. the skipped table.
+func warnTableln(tableID, content string) {
+	logln("WARN", tableID, content)
+}
+
+// traceln writes the duration of the BigQuery API call since start about tableID at the level DEBUG, if trace. tableID may be empty.
+func traceln(tableID, call string, start time.Time) {
+	if !trace {
+		return
+	}
+	logln("DEBUG", tableID, call+": took "+time.Since(start).String())
 }
 
 func errorln(content string) {
-	log.Println("ERROR: " + content)
+	logln("ERROR", "", content)
 }
 
 func exit(code int) {
@@ -331,7 +5947,93 @@ var (
 	typeOfRat      = reflect.TypeOf(&big.Rat{})
 )
 
+// bigqueryFieldTypes is the supported bigquery.FieldType.
+// NOTE(djeeno): ref. https://github.com/googleapis/google-cloud-go/blob/bigquery/v1.13.0/bigquery/schema.go#L158-L185
+var bigqueryFieldTypes = []bigquery.FieldType{
+	bigquery.StringFieldType,
+	bigquery.BytesFieldType,
+	bigquery.IntegerFieldType,
+	bigquery.FloatFieldType,
+	bigquery.BooleanFieldType,
+	bigquery.TimestampFieldType,
+	bigquery.RecordFieldType,
+	bigquery.DateFieldType,
+	bigquery.TimeFieldType,
+	bigquery.DateTimeFieldType,
+	bigquery.NumericFieldType,
+	bigquery.GeographyFieldType,
+}
+
+// unsupportedFieldTypes is the field types of BigQuery that bigquery.FieldType of cloud.google.com/go/bigquery v1.13.0 does not have.
+// The tables that have their columns are skipped as "bigquery.FieldType not supported.".
+// NOTE(djeeno): ref. https://cloud.google.com/bigquery/docs/reference/rest/v2/tables#TableFieldSchema.FIELDS.type
+var unsupportedFieldTypes = []bigquery.FieldType{
+	"BIGNUMERIC",
+	"JSON",
+	"INTERVAL",
+	"RANGE",
+}
+
+// writeFieldTypeList writes the table of bigqueryFieldTypes and their Go types of the REQUIRED and the NULLABLE columns, and unsupportedFieldTypes to w.
+// The NULLABLE Go types are the types of -nullable=null.
+func writeFieldTypeList(w io.Writer) (err error) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	if _, err = fmt.Fprintln(tw, "BIGQUERY TYPE\tGO TYPE\tNULLABLE GO TYPE"); err != nil {
+		return fmt.Errorf("fmt.Fprintln: %w", err)
+	}
+	for _, fieldType := range bigqueryFieldTypes {
+		goType, nullableGoType := "(nested struct)", "(nested struct)"
+		if fieldType != bigquery.RecordFieldType {
+			if goType, _, err = bigqueryFieldTypeToGoType(fieldType); err != nil {
+				return fmt.Errorf("bigqueryFieldTypeToGoType: %w", err)
+			}
+			if nullableGoType, _, _, err = bigqueryNullableFieldTypeToGoType(fieldType); err != nil {
+				return fmt.Errorf("bigqueryNullableFieldTypeToGoType: %w", err)
+			}
+		}
+		if _, err = fmt.Fprintln(tw, string(fieldType)+"\t"+goType+"\t"+nullableGoType); err != nil {
+			return fmt.Errorf("fmt.Fprintln: %w", err)
+		}
+	}
+	for _, fieldType := range unsupportedFieldTypes {
+		if _, err = fmt.Fprintln(tw, string(fieldType)+"\t(not supported)\t(not supported)"); err != nil {
+			return fmt.Errorf("fmt.Fprintln: %w", err)
+		}
+	}
+	if err = tw.Flush(); err != nil {
+		return fmt.Errorf("tw.Flush: %w", err)
+	}
+
+	return nil
+}
+
+// goTypeAndPackage is the Go type and its import path.
+type goTypeAndPackage struct {
+	goType string
+	pkg    string
+}
+
+// goTypesOfFieldTypes memoizes resolveGoTypeOfFieldType for bigqueryFieldTypes, so that reflect is not called for each column.
+var goTypesOfFieldTypes = func() map[bigquery.FieldType]goTypeAndPackage {
+	goTypes := make(map[bigquery.FieldType]goTypeAndPackage)
+	for _, bigqueryFieldType := range bigqueryFieldTypes {
+		if goType, pkg, err := resolveGoTypeOfFieldType(bigqueryFieldType); err == nil {
+			goTypes[bigqueryFieldType] = goTypeAndPackage{goType: goType, pkg: pkg}
+		}
+	}
+	return goTypes
+}()
+
 func bigqueryFieldTypeToGoType(bigqueryFieldType bigquery.FieldType) (goType string, pkg string, err error) {
+	t, ok := goTypesOfFieldTypes[bigqueryFieldType]
+	if !ok {
+		return "", "", fmt.Errorf("bigquery.FieldType not supported. bigquery.FieldType=%s", bigqueryFieldType)
+	}
+	return t.goType, t.pkg, nil
+}
+
+// resolveGoTypeOfFieldType returns the Go type of bigqueryFieldType and its import path. Use bigqueryFieldTypeToGoType instead.
+func resolveGoTypeOfFieldType(bigqueryFieldType bigquery.FieldType) (goType string, pkg string, err error) {
 	switch bigqueryFieldType {
 	// NOTE(djeeno): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/schema.go#L342-L343
 	case bigquery.BytesFieldType:
@@ -357,7 +6059,7 @@ func bigqueryFieldTypeToGoType(bigqueryFieldType bigquery.FieldType) (goType str
 
 	// NOTE(djeeno): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/schema.go#L368-L371
 	case bigquery.RecordFieldType:
-		// TODO(djeeno): support bigquery.RecordFieldType
+		// NOTE(djeeno): bigquery.RecordFieldType is generated as a nested struct by generateStructCode.
 		return "", "", fmt.Errorf("bigquery.FieldType not supported. bigquery.FieldType=%s", bigqueryFieldType)
 
 	// NOTE(djeeno): ref. https://github.com/googleapis/google-cloud-go/blob/f37f118c87d4d0a77a554515a430ae06e5852294/bigquery/schema.go#L394-L399
@@ -373,3 +6075,100 @@ func bigqueryFieldTypeToGoType(bigqueryFieldType bigquery.FieldType) (goType str
 		return "", "", fmt.Errorf("bigquery.FieldType not supported. bigquery.FieldType=%s", bigqueryFieldType)
 	}
 }
+
+// NOTE(djeeno): ref. https://github.com/googleapis/google-cloud-go/blob/bigquery/v1.13.0/bigquery/nulls.go#L347-L357
+var (
+	typeOfNullString    = reflect.TypeOf(bigquery.NullString{})
+	typeOfNullGeography = reflect.TypeOf(bigquery.NullGeography{})
+	typeOfNullInt64     = reflect.TypeOf(bigquery.NullInt64{})
+	typeOfNullFloat64   = reflect.TypeOf(bigquery.NullFloat64{})
+	typeOfNullBool      = reflect.TypeOf(bigquery.NullBool{})
+	typeOfNullTimestamp = reflect.TypeOf(bigquery.NullTimestamp{})
+	typeOfNullDate      = reflect.TypeOf(bigquery.NullDate{})
+	typeOfNullTime      = reflect.TypeOf(bigquery.NullTime{})
+	typeOfNullDateTime  = reflect.TypeOf(bigquery.NullDateTime{})
+)
+
+// nullTypes is the bigquery.NullXXX types by the Go type names.
+var nullTypes = map[string]reflect.Type{
+	typeOfNullString.String():    typeOfNullString,
+	typeOfNullGeography.String(): typeOfNullGeography,
+	typeOfNullInt64.String():     typeOfNullInt64,
+	typeOfNullFloat64.String():   typeOfNullFloat64,
+	typeOfNullBool.String():      typeOfNullBool,
+	typeOfNullTimestamp.String(): typeOfNullTimestamp,
+	typeOfNullDate.String():      typeOfNullDate,
+	typeOfNullTime.String():      typeOfNullTime,
+	typeOfNullDateTime.String():  typeOfNullDateTime,
+}
+
+// reflectTypes is the Go types of the non-RECORD columns by their names, e.g. "civil.Date". See reflectStructType.
+var reflectTypes = func() map[string]reflect.Type {
+	goTypes := make(map[string]reflect.Type)
+	for _, t := range []reflect.Type{
+		reflect.TypeOf(""),
+		reflect.TypeOf([]byte{}),
+		reflect.TypeOf(int64(0)),
+		reflect.TypeOf(float64(0)),
+		reflect.TypeOf(false),
+		typeOfGoTime,
+		typeOfDate,
+		typeOfTime,
+		typeOfDateTime,
+		typeOfRat,
+	} {
+		goTypes[t.String()] = t
+	}
+	for name, t := range nullTypes {
+		goTypes[name] = t
+	}
+	return goTypes
+}()
+
+// isTemporalType reports whether fieldType is TIMESTAMP, DATETIME, DATE or TIME, whose Go type is time.Time or the civil type.
+func isTemporalType(fieldType bigquery.FieldType) bool {
+	switch fieldType {
+	case bigquery.TimestampFieldType, bigquery.DateTimeFieldType, bigquery.DateFieldType, bigquery.TimeFieldType:
+		return true
+	default:
+		return false
+	}
+}
+
+// bigqueryNullableFieldTypeToGoType returns the Go type for a NULLABLE column and the struct tag option it needs.
+// The client accepts the "nullable" tag option only for []byte, *big.Rat and pointer-to-struct fields,
+// so the other types are represented by the bigquery.NullXXX types without the tag option.
+func bigqueryNullableFieldTypeToGoType(bigqueryFieldType bigquery.FieldType) (goType string, pkg string, tagOption string, err error) {
+	switch bigqueryFieldType {
+	// NOTE(djeeno): ref. https://github.com/googleapis/google-cloud-go/blob/bigquery/v1.13.0/bigquery/schema.go#L255-L256
+	case bigquery.BytesFieldType, bigquery.NumericFieldType:
+		goType, pkg, err = bigqueryFieldTypeToGoType(bigqueryFieldType)
+		if err != nil {
+			return "", "", "", fmt.Errorf("bigqueryFieldTypeToGoType: %w", err)
+		}
+		return goType, pkg, tagOptionNullable, nil
+
+	// NOTE(djeeno): ref. https://github.com/googleapis/google-cloud-go/blob/bigquery/v1.13.0/bigquery/schema.go#L243-L253
+	case bigquery.StringFieldType:
+		return typeOfNullString.String(), typeOfNullString.PkgPath(), "", nil
+	case bigquery.GeographyFieldType:
+		return typeOfNullGeography.String(), typeOfNullGeography.PkgPath(), "", nil
+	case bigquery.IntegerFieldType:
+		return typeOfNullInt64.String(), typeOfNullInt64.PkgPath(), "", nil
+	case bigquery.FloatFieldType:
+		return typeOfNullFloat64.String(), typeOfNullFloat64.PkgPath(), "", nil
+	case bigquery.BooleanFieldType:
+		return typeOfNullBool.String(), typeOfNullBool.PkgPath(), "", nil
+	case bigquery.TimestampFieldType:
+		return typeOfNullTimestamp.String(), typeOfNullTimestamp.PkgPath(), "", nil
+	case bigquery.DateFieldType:
+		return typeOfNullDate.String(), typeOfNullDate.PkgPath(), "", nil
+	case bigquery.TimeFieldType:
+		return typeOfNullTime.String(), typeOfNullTime.PkgPath(), "", nil
+	case bigquery.DateTimeFieldType:
+		return typeOfNullDateTime.String(), typeOfNullDateTime.PkgPath(), "", nil
+
+	default:
+		return "", "", "", fmt.Errorf("bigquery.FieldType not supported. bigquery.FieldType=%s", bigqueryFieldType)
+	}
+}
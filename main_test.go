@@ -1,21 +1,49 @@
 package main
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"text/template"
+	"time"
 
 	"cloud.google.com/go/bigquery"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
 )
 
 const (
 	// all
 	testEmptyString = ""
 
-	// generateTableSchemaCode, getAllTables
+	// tableSchemaMetadata, getAllTables
 	testPublicDataProjectID         = "bigquery-public-data"
 	testSupportedDatasetID          = "hacker_news"
 	testNotSupportedDatasetID       = "samples"
@@ -23,15 +51,33 @@ const (
 	testDatasetNotFound             = "datasetnotfound"
 	testSubStrFieldTypeNotSupported = "bigquery.FieldType not supported."
 
+	// generateTableMetadataCode
+	testTableID = "test_table"
+
+	// consoleURL
+	testConsoleURL = "https://console.cloud.google.com/bigquery?p=" + testPublicDataProjectID + "&d=" + testSupportedDatasetID + "&t=" + testTableID + "&page=table"
+
+	// GenerateFromSchemaFile
+	testSchemaFile   = "test/comments.json"
+	testTemplateFile = "test/interface.ts.tmpl"
+
+	// generateTableMetadataCode
+	testTableFullID = testPublicDataProjectID + ":" + testSupportedDatasetID + "." + testTableID
+	testEmbedType   = "*example.com/models.BaseModel"
+	testPolicyTag   = "projects/" + testPublicDataProjectID + "/locations/us/taxonomies/1/policyTags/2"
+
 	// getAllTables
 	testGoogleApplicationCredentials = "test/serviceaccountnotfound@projectnotfound.iam.gserviceaccount.com.json"
 
+	// writeGeneratedCode
+	testFileMode = os.FileMode(0644)
+
 	// readFile
 	testErrNoSuchFileOrDirectoryPath = "/no/such/file/or/directory"
 	testErrIsADirectoryPath          = "."
 	testProbablyExistsPath           = "go.mod"
 
-	// getOptOrEnvOrDefault
+	// getOptOrEnvOrDefault, getOptOrEnv
 	testOptName      = "test-opt-key"
 	testOptValue     = "testOptValue"
 	testEnvName      = "TEST_ENV_KEY"
@@ -83,7 +129,7 @@ func Test_Run(t *testing.T) {
 	})
 }
 
-func Test_Generate(t *testing.T) {
+func Test_generateTableFiles(t *testing.T) {
 	t.Run("正常系_testSupportedDatasetID_"+testSupportedDatasetID, func(t *testing.T) {
 		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
 			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
@@ -92,15 +138,50 @@ func Test_Generate(t *testing.T) {
 		var (
 			ctx       = context.Background()
 			client, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+			dir       = t.TempDir()
 		)
 
-		_, err := Generate(ctx, client, testSupportedDatasetID)
+		result, err := generateTableFiles(ctx, client, testPublicDataProjectID, testSupportedDatasetID, filepath.Join(dir, "{dataset}", "{table}_model.go"), make(map[string]string), testOutputConfig, Options{})
 		if err != nil {
-			t.Error(err)
+			t.Fatal(err)
+		}
+		files, err := filepath.Glob(filepath.Join(dir, testSupportedDatasetID, "*_model.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Structs == 0 || len(files) != result.Structs {
+			t.Errorf("generateTableFiles: unexpected result: Structs=%d files=%v", result.Structs, files)
 		}
 	})
 
-	t.Run("正常系_testNotSupportedDatasetID_"+testNotSupportedDatasetID, func(t *testing.T) {
+	t.Run("正常系_skipExisting", func(t *testing.T) {
+		client := testTableDataClient(t, testNullableRoundTripSchema, "[]")
+		outputTemplate := filepath.Join(t.TempDir(), "{table}_model.go")
+		opts := Options{Tables: []string{testTableID}}
+		result, err := generateTableFiles(context.Background(), client, testPublicDataProjectID, testSupportedDatasetID, outputTemplate, make(map[string]string), testOutputConfig, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := readFile(expandOutputTemplate(outputTemplate, testPublicDataProjectID, testSupportedDatasetID, testTableID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Structs != 1 || !strings.Contains(string(content), "type Test_table struct {") {
+			t.Errorf("generateTableFiles: Structs=%d content=%s", result.Structs, content)
+		}
+
+		config := testOutputConfig
+		config.skipExisting = true
+		result, err = generateTableFiles(context.Background(), client, testPublicDataProjectID, testSupportedDatasetID, outputTemplate, make(map[string]string), config, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Structs != 0 {
+			t.Errorf("generateTableFiles: the existing file is generated: Structs=%d", result.Structs)
+		}
+	})
+
+	t.Run("異常系_conflict", func(t *testing.T) {
 		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
 			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
 		}
@@ -108,211 +189,5004 @@ func Test_Generate(t *testing.T) {
 		var (
 			ctx       = context.Background()
 			client, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+			filePath  = filepath.Join(t.TempDir(), "{dataset}.go")
 		)
 
-		_, err := Generate(ctx, client, testNotSupportedDatasetID)
-		if err != nil {
+		if _, err := generateTableFiles(ctx, client, testPublicDataProjectID, testSupportedDatasetID, filePath, make(map[string]string), testOutputConfig, Options{}); err == nil {
 			t.Error(err)
 		}
+		if _, err := os.Stat(filepath.Join(filepath.Dir(filePath), testSupportedDatasetID+".go")); err == nil {
+			t.Error("generateTableFiles: file should not be written")
+		}
 	})
 }
 
-func Test_generateImportPackagesCode(t *testing.T) {
-	t.Run("正常系_import_nothing", func(t *testing.T) {
-		const (
-			// 正しい出力
-			testImportCode = ""
-		)
-		var (
-			testImportsSlice = []string{}
-		)
+func Test_pruneGeneratedFiles(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		dir := t.TempDir()
+		outputTemplate := filepath.Join(dir, "{dataset}", "{table}_model.go")
+		outputPaths := map[string]string{filepath.Join(dir, testSupportedDatasetID, "current_model.go"): "current"}
+		generated := []byte("// Code generated by go run github.com/djeeno/bqschema-gen-go; DO NOT EDIT.\n\npackage bqschema\n")
+		handWritten := []byte("package bqschema\n\n// Code generated by hand; DO NOT EDIT.\n")
+		files := map[string][]byte{
+			filepath.Join(dir, testSupportedDatasetID, "current_model.go"):      generated,
+			filepath.Join(dir, testSupportedDatasetID, "removed_model.go"):      generated,
+			filepath.Join(dir, testSupportedDatasetID, "hand_written_model.go"): handWritten,
+			filepath.Join(dir, testSupportedDatasetID, "other.go"):              generated,
+		}
+		for file, content := range files {
+			if err := writeGeneratedCode(file, testFileMode, content); err != nil {
+				t.Fatal(err)
+			}
+		}
 
-		generatedCode := generateImportPackagesCode(testImportsSlice)
-		if generatedCode != testImportCode {
-			t.Error()
+		pruned, err := pruneGeneratedFiles(outputTemplate, []datasetRef{{project: testPublicDataProjectID, dataset: testSupportedDatasetID}}, outputPaths, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		removed := filepath.Join(dir, testSupportedDatasetID, "removed_model.go")
+		if want := []string{removed}; !reflect.DeepEqual(pruned, want) {
+			t.Errorf("pruneGeneratedFiles: want=%v current=%v", want, pruned)
+		}
+		for file := range files {
+			if _, err := os.Stat(file); (err == nil) == (file == removed) {
+				t.Errorf("pruneGeneratedFiles: unexpected file state: %s: %v", file, err)
+			}
 		}
 	})
 
-	t.Run("正常系_import_time", func(t *testing.T) {
-		const (
-			// 正しい出力
-			testImportCode = "import \"time\"\n\n"
-		)
-		var (
-			testImportsSlice = []string{"time"}
-		)
-		generatedCode := generateImportPackagesCode(testImportsSlice)
+	t.Run("正常系_other_datasets_are_not_pruned", func(t *testing.T) {
+		// NOTE(djeeno): The meta characters of the glob in the directory are matched literally.
+		dir := filepath.Join(t.TempDir(), "models[v2]")
+		outputTemplate := filepath.Join(dir, "{project}", "{dataset}", "{table}.go")
+		generated := []byte("// Code generated by go run github.com/djeeno/bqschema-gen-go; DO NOT EDIT.\n\npackage bqschema\n")
+		removed := filepath.Join(dir, testPublicDataProjectID, testSupportedDatasetID, "removed.go")
+		files := []string{
+			removed,
+			filepath.Join(dir, testPublicDataProjectID, testNotSupportedDatasetID, "other_dataset.go"),
+			filepath.Join(dir, testProjectNotFound, testSupportedDatasetID, "other_project.go"),
+		}
+		for _, file := range files {
+			if err := writeGeneratedCode(file, testFileMode, generated); err != nil {
+				t.Fatal(err)
+			}
+		}
 
-		if generatedCode != testImportCode {
-			var (
-				rr      = strings.NewReplacer("\n", "\\n", "`", "\\`")
-				want    = rr.Replace(testImportCode)
-				current = rr.Replace(generatedCode)
-			)
-			t.Error("generateImportPackagesCode: want=`" + want + "` current=`" + current + "`")
+		pruned, err := pruneGeneratedFiles(outputTemplate, []datasetRef{{project: testPublicDataProjectID, dataset: testSupportedDatasetID}}, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{removed}; !reflect.DeepEqual(pruned, want) {
+			t.Errorf("pruneGeneratedFiles: want=%v current=%v", want, pruned)
+		}
+		for _, file := range files[1:] {
+			if _, err := os.Stat(file); err != nil {
+				t.Errorf("pruneGeneratedFiles: the file of the other dataset is pruned: %v", err)
+			}
 		}
 	})
 
-	t.Run("正常系_import_math/big_time", func(t *testing.T) {
-		const (
-			// 正しい出力
-			testImportCode = `import (
-	"math/big"
-	"time"
-)
+	t.Run("正常系_written_files_are_not_pruned", func(t *testing.T) {
+		dir := t.TempDir()
+		outputTemplate := filepath.Join(dir, "{table}.go")
+		current := filepath.Join(dir, "current.go")
+		config := outputConfig{lineEnding: lineEndingLF, fileMode: testFileMode, compileTest: true, written: make(map[string]bool)}
+		result, err := GenerateFromSchemaFile(testPublicDataProjectID, testSupportedDatasetID, "test/comments.json", Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// NOTE(djeeno): The companion test file "current_test.go" is generated and matches the glob "*.go" of outputTemplate.
+		if err := outputGeneratedCode(current, result.Bytes, config); err != nil {
+			t.Fatal(err)
+		}
+		removed := filepath.Join(dir, "removed.go")
+		if err := writeGeneratedCode(removed, testFileMode, result.Bytes); err != nil {
+			t.Fatal(err)
+		}
 
-`
+		pruned, err := pruneGeneratedFiles(outputTemplate, []datasetRef{{project: testPublicDataProjectID, dataset: testSupportedDatasetID}}, map[string]string{current: "current"}, config.written)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{removed}; !reflect.DeepEqual(pruned, want) {
+			t.Errorf("pruneGeneratedFiles: want=%v current=%v", want, pruned)
+		}
+		if _, err := os.Stat(compileTestFilePath(current)); err != nil {
+			t.Errorf("pruneGeneratedFiles: the written file is pruned: %v", err)
+		}
+	})
+}
+
+func Test_writtenFileKey(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, current := filepath.Join(wd, "models", "users.go"), writtenFileKey(filepath.Join(".", "models", "..", "models", "users.go")); want != current {
+		t.Errorf("writtenFileKey: want=%s current=%s", want, current)
+	}
+}
+
+func Test_expandOutputTemplate(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		want := filepath.Join("models", testPublicDataProjectID, testSupportedDatasetID, testTableID+"_model.go")
+		if outputPath := expandOutputTemplate(filepath.Join("models", "{project}", "{dataset}", "{table}_model.go"), testPublicDataProjectID, testSupportedDatasetID, testTableID); outputPath != want {
+			t.Error("expandOutputTemplate: want=" + want + " current=" + outputPath)
+		}
+	})
+
+	t.Run("正常系_wildcard", func(t *testing.T) {
+		for table, want := range map[string]string{"events_*": "events.go", "events*": "events.go"} {
+			if outputPath := expandOutputTemplate("{table}.go", testPublicDataProjectID, testSupportedDatasetID, table); outputPath != want {
+				t.Error("expandOutputTemplate: want=" + want + " current=" + outputPath)
+			}
+		}
+	})
+}
+
+func Test_convertLineEnding(t *testing.T) {
+	const testCode = "package bqschema\n\ntype A struct{}\n"
+
+	t.Run("正常系_lineEndingLF", func(t *testing.T) {
+		converted, err := convertLineEnding([]byte(testCode), lineEndingLF)
+		if err != nil {
+			t.Error(err)
+		}
+		if string(converted) != testCode {
+			t.Error("convertLineEnding: current=" + string(converted))
+		}
+	})
+
+	t.Run("正常系_lineEndingCRLF", func(t *testing.T) {
+		const want = "package bqschema\r\n\r\ntype A struct{}\r\n"
+		converted, err := convertLineEnding([]byte(testCode), lineEndingCRLF)
+		if err != nil {
+			t.Error(err)
+		}
+		if string(converted) != want {
+			t.Error("convertLineEnding: current=" + string(converted))
+		}
+	})
+
+	t.Run("正常系_lineEndingCRLF_raw_string", func(t *testing.T) {
+		const (
+			code = "package bqschema\n\n// Q is `q`.\nconst Q = `SELECT\n  1`\n"
+			want = "package bqschema\r\n\r\n// Q is `q`.\r\nconst Q = `SELECT\n  1`\r\n"
 		)
-		var (
-			testImportsSlice = []string{"math/big", "time"}
+		converted, err := convertLineEnding([]byte(code), lineEndingCRLF)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(converted) != want {
+			t.Errorf("convertLineEnding: want=%q current=%q", want, converted)
+		}
+	})
+
+	t.Run("正常系_lineEndingCRLF_not_Go", func(t *testing.T) {
+		const (
+			code = "export interface A {\n  a?: string;\n}\n// `a\nb`\n"
+			want = "export interface A {\r\n  a?: string;\r\n}\r\n// `a\r\nb`\r\n"
 		)
-		generatedCode := generateImportPackagesCode(testImportsSlice)
+		converted, err := convertLineEnding([]byte(code), lineEndingCRLF)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(converted) != want {
+			t.Errorf("convertLineEnding: want=%q current=%q", want, converted)
+		}
+	})
 
-		if generatedCode != testImportCode {
-			var (
-				rr      = strings.NewReplacer("\n", "\\n", "`", "\\`")
-				want    = rr.Replace(testImportCode)
-				current = rr.Replace(generatedCode)
-			)
-			t.Error("generateImportPackagesCode: want=`" + want + "` current=`" + current + "`")
+	t.Run("異常系_testEmptyString", func(t *testing.T) {
+		if _, err := convertLineEnding([]byte(testCode), testEmptyString); err == nil {
+			t.Error(err)
 		}
 	})
 }
 
-func Test_generateTableSchemaCode(t *testing.T) {
-	t.Run("正常系_testPublicDataProjectID_testPublicDataProjectID", func(t *testing.T) {
-		var (
-			ctx = context.Background()
-		)
+func Test_outputFilePath(t *testing.T) {
+	t.Run("正常系_filePath", func(t *testing.T) {
+		if path := outputFilePath(defaultValueOutputFile, testEmptyString, testSupportedDatasetID); path != defaultValueOutputFile {
+			t.Error("outputFilePath: want=" + defaultValueOutputFile + " current=" + path)
+		}
+	})
 
-		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
-			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
+	t.Run("正常系_outputDir", func(t *testing.T) {
+		want := filepath.Join("bqschema", testSupportedDatasetID+generatedFileSuffix)
+		if path := outputFilePath(testEmptyString, "bqschema", testSupportedDatasetID); path != want {
+			t.Error("outputFilePath: want=" + want + " current=" + path)
+		}
+	})
+}
+
+var testOutputConfig = outputConfig{lineEnding: lineEndingLF, fileMode: testFileMode}
+
+func Test_outputArchive(t *testing.T) {
+	t.Run("正常系_compileTest", func(t *testing.T) {
+		result, err := GenerateFromSchemaFile(testPublicDataProjectID, testSupportedDatasetID, testSchemaFile, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		zipPath := filepath.Join(t.TempDir(), "dist", "models.zip")
+		archive, err := createOutputArchive(zipPath, testFileMode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config := outputConfig{lineEnding: lineEndingLF, fileMode: testFileMode, compileTest: true, archive: archive}
+		if err = outputGeneratedCode(filepath.Join("models", "comments.go"), result.Bytes, config); err != nil {
+			t.Fatal(err)
+		}
+		if err = archive.close(true); err != nil {
+			t.Fatal(err)
+		}
+
+		reader, err := zip.OpenReader(zipPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+		var names []string
+		for _, file := range reader.File {
+			names = append(names, file.Name)
+			if file.Mode() != testFileMode {
+				t.Errorf("outputArchive: name=%s mode=%s", file.Name, file.Mode())
+			}
+		}
+		if expected := []string{"models/comments.go", "models/comments_test.go"}; !reflect.DeepEqual(expected, names) {
+			t.Errorf("outputArchive: expected(%v) != actual(%v)", expected, names)
+		}
+
+		file, err := reader.File[0].Open()
+		if err != nil {
+			t.Fatal(err)
 		}
+		defer file.Close()
+		var content bytes.Buffer
+		if _, err = content.ReadFrom(file); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(result.Bytes, content.Bytes()) {
+			t.Errorf("outputArchive: want=%q current=%q", result.Bytes, content.Bytes())
+		}
+	})
+
+	t.Run("異常系_out_of_archive", func(t *testing.T) {
+		zipPath := filepath.Join(t.TempDir(), "models.zip")
+		archive, err := createOutputArchive(zipPath, testFileMode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, filePath := range []string{"../models/comments.go", filepath.Join(t.TempDir(), "comments.go")} {
+			if err = archive.write(filePath, testFileMode, []byte("package bqschema\n")); err == nil {
+				t.Errorf("archive.write: expected error: path=%s", filePath)
+			}
+		}
+		// NOTE(djeeno): The archive is removed unless committed.
+		if err = archive.close(false); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = os.Stat(zipPath); !os.IsNotExist(err) {
+			t.Errorf("archive.close: the archive is not removed: %v", err)
+		}
+	})
+}
+
+func Test_outputGeneratedCode(t *testing.T) {
+	t.Run("正常系_compileTest", func(t *testing.T) {
+		result, err := GenerateFromSchemaFile(testPublicDataProjectID, testSupportedDatasetID, testSchemaFile, Options{Getters: true, SelectAll: true, Constants: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		filePath := filepath.Join(t.TempDir(), "comments.go")
+		if err := outputGeneratedCode(filePath, result.Bytes, outputConfig{lineEnding: lineEndingCRLF, fileMode: testFileMode, compileTest: true}); err != nil {
+			t.Fatal(err)
+		}
+		content, err := readFile(compileTestFilePath(filePath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			generatedCodeHeader + "\r\n",
+			"package bqschema\r\n",
+			"func TestCompile_comments(t *testing.T) {\r\n",
+			"\t_ = ProjectID\r\n",
+			"\tvar _ Comments\r\n",
+			"\t_ = CommentsSelectAll\r\n",
+			"\t_ = (*Comments).TableName\r\n",
+			"\t_ = (*Comments).GetId\r\n",
+			"\tvar _ CommentsGetter\r\n",
+		} {
+			if !strings.Contains(string(content), want) {
+				t.Errorf("outputGeneratedCode: want=%q current=%q", want, content)
+			}
+		}
+	})
 
+	t.Run("正常系_skipExisting", func(t *testing.T) {
 		var (
-			ngClient, _     = bigquery.NewClient(ctx, testPublicDataProjectID)
-			ngTableIterator = ngClient.Dataset(testSupportedDatasetID).Tables(ctx)
+			filePath    = filepath.Join(t.TempDir(), "comments.go")
+			newFilePath = filepath.Join(filepath.Dir(filePath), "new.go")
+			config      = outputConfig{lineEnding: lineEndingLF, fileMode: testFileMode, skipExisting: true}
 		)
+		if err := writeGeneratedCode(filePath, testFileMode, []byte(testOptValue)); err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range []string{filePath, newFilePath} {
+			if err := outputGeneratedCode(path, []byte(testDefaultValue), config); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for path, want := range map[string]string{filePath: testOptValue, newFilePath: testDefaultValue} {
+			content, err := readFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != want {
+				t.Error("outputGeneratedCode: want=" + want + " current=" + string(content))
+			}
+		}
+	})
+}
 
-		for {
-			table, err := ngTableIterator.Next()
-			if err == iterator.Done {
-				break
+func Test_skipExistingFile(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for _, tt := range []struct {
+			filePath string
+			config   outputConfig
+			want     bool
+		}{
+			{filePath: testProbablyExistsPath, config: outputConfig{skipExisting: true}, want: true},
+			{filePath: testProbablyExistsPath, config: outputConfig{}, want: false},
+			{filePath: testErrNoSuchFileOrDirectoryPath, config: outputConfig{skipExisting: true}, want: false},
+			{filePath: filePathStdout, config: outputConfig{skipExisting: true}, want: false},
+		} {
+			if current := skipExistingFile(tt.filePath, tt.config); current != tt.want {
+				t.Errorf("skipExistingFile: filePath=%s config=%+v want=%t current=%t", tt.filePath, tt.config, tt.want, current)
 			}
+		}
+	})
+}
+
+func Test_compileTestFilePath(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		if path := compileTestFilePath(filepath.Join("models", "users.go")); path != filepath.Join("models", "users_test.go") {
+			t.Error("compileTestFilePath: unexpected path: " + path)
+		}
+	})
+}
+
+func Test_generateCompileTestCode(t *testing.T) {
+	t.Run("異常系_not_Go", func(t *testing.T) {
+		if _, err := generateCompileTestCode(defaultValueOutputFile, []byte("interface Comments {}")); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_writeGeneratedCode(t *testing.T) {
+	t.Run("正常系_filePathStdout", func(t *testing.T) {
+		if err := writeGeneratedCode(filePathStdout, testFileMode, []byte(testEmptyString)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("正常系_file", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), defaultValueOutputFile)
+		if err := writeGeneratedCode(filePath, testFileMode, []byte(testCapitalized)); err != nil {
+			t.Error(err)
+		}
+		content, err := readFile(filePath)
+		if err != nil {
+			t.Error(err)
+		}
+		if string(content) != testCapitalized {
+			t.Error("writeGeneratedCode: want=" + testCapitalized + " current=" + string(content))
+		}
+	})
+
+	t.Run("正常系_fileMode", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), defaultValueOutputFile)
+		if err := writeGeneratedCode(filePath, testFileMode, []byte(testCapitalized)); err != nil {
+			t.Error(err)
+		}
+		// NOTE(djeeno): The permission of the existing file is changed as well.
+		if err := writeGeneratedCode(filePath, 0600, []byte(testCapitalized)); err != nil {
+			t.Error(err)
+		}
+		info, err := os.Stat(filePath)
+		if err != nil {
+			t.Error(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("writeGeneratedCode: want=%v current=%v", os.FileMode(0600), info.Mode().Perm())
+		}
+	})
+
+	t.Run("正常系_nested_directories", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "gen", "models", "sub", defaultValueOutputFile)
+		if err := writeGeneratedCode(filePath, testFileMode, []byte(testCapitalized)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_testErrIsADirectoryPath", func(t *testing.T) {
+		if err := writeGeneratedCode(testErrIsADirectoryPath, testFileMode, []byte(testEmptyString)); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_parseFileMode(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for fileMode, want := range map[string]os.FileMode{defaultValueFileMode: 0644, "0600": 0600, "640": 0640} {
+			mode, err := parseFileMode(fileMode)
 			if err != nil {
 				t.Error(err)
 			}
-			if _, _, err := generateTableSchemaCode(ctx, table); err != nil {
-				t.Error(err)
+			if mode != want {
+				t.Errorf("parseFileMode: want=%v current=%v", want, mode)
 			}
 		}
 	})
 
-	t.Run("異常系_testProjectNotFound_testDatasetNotFound", func(t *testing.T) {
-		var (
-			ctx     = context.Background()
-			ngTable = &bigquery.Table{
-				ProjectID: testProjectNotFound,
-				DatasetID: testDatasetNotFound,
-				TableID:   testEmptyString,
+	t.Run("異常系", func(t *testing.T) {
+		for _, fileMode := range []string{testEmptyString, "0644x", "0999", "01777"} {
+			if _, err := parseFileMode(fileMode); err == nil {
+				t.Error("parseFileMode: want error. fileMode=" + fileMode)
+			}
+		}
+	})
+}
+
+func Test_writeInitFile(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		initFile := filepath.Join(t.TempDir(), "bqschema", initFileName)
+		if err := writeInitFile(initFile, testPublicDataProjectID, testSupportedDatasetID, optNameOutputFile, defaultValueOutputFile, defaultValuePackage, false, testFileMode); err != nil {
+			t.Fatal(err)
+		}
+		content, err := readFile(initFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "package bqschema\n\n//go:generate go run github.com/djeeno/bqschema-gen-go -project " + testPublicDataProjectID + " -dataset " + testSupportedDatasetID + " -output " + defaultValueOutputFile + "\n"
+		if !strings.Contains(string(content), want) {
+			t.Error("writeInitFile: want=`" + want + "` current=`" + string(content) + "`")
+		}
+		if _, err := format.Source(content); err != nil {
+			t.Error(err)
+		}
+		if !hasInitFile(filepath.Dir(initFile)) {
+			t.Error("hasInitFile: want=true")
+		}
+	})
+
+	t.Run("正常系_force", func(t *testing.T) {
+		initFile := filepath.Join(t.TempDir(), initFileName)
+		if err := writeGeneratedCode(initFile, testFileMode, []byte(testCapitalized)); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeInitFile(initFile, testPublicDataProjectID, testSupportedDatasetID, optNameOutputFile, "my schema.go", defaultValuePackage, true, testFileMode); err != nil {
+			t.Fatal(err)
+		}
+		content, err := readFile(initFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(string(content), " -output \"my schema.go\"\n") {
+			t.Error("writeInitFile: the output file is not quoted: " + string(content))
+		}
+	})
+
+	t.Run("正常系_outputDir", func(t *testing.T) {
+		initFile := filepath.Join(t.TempDir(), "bqschema", initFileName)
+		if err := writeInitFile(initFile, testPublicDataProjectID, testSupportedDatasetID+",sales", optNameOutputDir, ".", defaultValuePackage, false, testFileMode); err != nil {
+			t.Fatal(err)
+		}
+		content, err := readFile(initFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := " -dataset " + testSupportedDatasetID + ",sales -" + optNameOutputDir + " .\n"; !strings.HasSuffix(string(content), want) {
+			t.Error("writeInitFile: want=`" + want + "` current=`" + string(content) + "`")
+		}
+	})
+
+	t.Run("正常系_package", func(t *testing.T) {
+		initFile := filepath.Join(t.TempDir(), "internal", "models", initFileName)
+		if err := writeInitFile(initFile, testPublicDataProjectID, testSupportedDatasetID, optNameOutputFile, defaultValueOutputFile, "models", false, testFileMode); err != nil {
+			t.Fatal(err)
+		}
+		content, err := readFile(initFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(content), "\npackage models\n") || !strings.HasSuffix(string(content), " -package models\n") {
+			t.Error("writeInitFile: the package name is not models: " + string(content))
+		}
+	})
+
+	t.Run("異常系_exists", func(t *testing.T) {
+		initFile := filepath.Join(t.TempDir(), initFileName)
+		if err := writeGeneratedCode(initFile, testFileMode, []byte(testCapitalized)); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeInitFile(initFile, testPublicDataProjectID, testSupportedDatasetID, optNameOutputFile, defaultValueOutputFile, defaultValuePackage, false, testFileMode); err == nil {
+			t.Error(err)
+		}
+		if content, _ := readFile(initFile); string(content) != testCapitalized {
+			t.Error("writeInitFile: the existing file is overwritten")
+		}
+		if hasInitFile(filepath.Dir(initFile)) {
+			t.Error("hasInitFile: want=false for the file not written by writeInitFile")
+		}
+	})
+}
+
+func Test_checkHelperImport(t *testing.T) {
+	// NOTE(djeeno): The nested layout of the consumer module, e.g. example.com/app/internal/models.
+	moduleDir := t.TempDir()
+	if err := writeGeneratedCode(filepath.Join(moduleDir, "go.mod"), testFileMode, []byte("module example.com/app\n\ngo 1.15\n")); err != nil {
+		t.Fatal(err)
+	}
+	filePath := filepath.Join(moduleDir, "internal", "models", defaultValueOutputFile)
+
+	t.Run("正常系_internal_models", func(t *testing.T) {
+		opts := Options{Package: "models", HelperInterfaces: true}
+		result, err := GenerateFromSchemaFile(testPublicDataProjectID, testSupportedDatasetID, testSchemaFile, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writeGeneratedCode(filePath, testFileMode, result.Bytes); err != nil {
+			t.Fatal(err)
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), filePath, nil, parser.ImportsOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if file.Name.Name != "models" {
+			t.Error("GenerateFromSchemaFile: want=models current=" + file.Name.Name)
+		}
+		// NOTE(djeeno): go generate of the directive must regenerate the file in the same package.
+		var directive string
+		for _, line := range strings.Split(string(result.Bytes), "\n") {
+			if strings.HasPrefix(line, generateDirective) {
+				directive = line
+			}
+		}
+		flagSet := flag.NewFlagSet(testCapitalized, flag.ContinueOnError)
+		packageValue := flagSet.String(optNamePackage, defaultValuePackage, "")
+		if err := flagSet.Parse(strings.Fields(strings.TrimPrefix(directive, generateDirective))); err != nil {
+			t.Fatal(err)
+		}
+		if *packageValue != file.Name.Name {
+			t.Errorf("GenerateFromSchemaFile: directive=%q want=%s current=%s", directive, file.Name.Name, *packageValue)
+		}
+		importPath, err := packageImportPath(filepath.Dir(filePath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if importPath != "example.com/app/internal/models" {
+			t.Error("packageImportPath: want=example.com/app/internal/models current=" + importPath)
+		}
+		for _, spec := range file.Imports {
+			if strings.Trim(spec.Path.Value, `"`) == importPath {
+				t.Error("GenerateFromSchemaFile: the generated package imports itself")
+			}
+		}
+		if err := checkHelperImport(filePath, opts); err != nil {
+			t.Error(err)
+		}
+		opts.HelperImport = "example.com/app/internal/bqtable"
+		if err := checkHelperImport(filePath, opts); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("正常系_out_of_module", func(t *testing.T) {
+		opts := Options{HelperInterfaces: true, HelperImport: "example.com/other/internal/bqtable"}
+		if err := checkHelperImport(filepath.Join(t.TempDir(), defaultValueOutputFile), opts); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_import_cycle", func(t *testing.T) {
+		opts := Options{HelperInterfaces: true, HelperImport: "example.com/app/internal/models"}
+		if err := checkHelperImport(filePath, opts); err == nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_internal_of_other_module", func(t *testing.T) {
+		opts := Options{HelperInterfaces: true, HelperImport: "example.com/other/internal/bqtable"}
+		if err := checkHelperImport(filePath, opts); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_Options_Validate(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for name, opts := range map[string]Options{
+			"zero":   {},
+			"values": {Nullable: nullableModeNull, NullableRecords: nullableRecordsPointer, TypeMap: map[string]string{"INTEGER": "int", "a.b": "cloud.google.com/go/civil.DateTime"}, ExcludeColumns: []string{"*.raw_*"}, Package: "models", TagKey: "db", PolicyTagStructTag: `pii:"true"`, FlattenRecords: true, FromRow: true, NullableTemporal: nullableTemporalPointer},
+		} {
+			if err := opts.Validate(); err != nil {
+				t.Errorf("Options.Validate: %s: %v", name, err)
+			}
+		}
+	})
+
+	t.Run("異常系_all_problems", func(t *testing.T) {
+		opts := Options{
+			Nullable:       "pointer",
+			TypeMap:        map[string]string{"RECORD": "int", "a.b": "civil."},
+			ExcludeColumns: []string{"payload"},
+			Package:        "my-models",
+			FlattenRecords: true,
+		}
+		err := opts.Validate()
+		var optionsError *OptionsError
+		if !errors.As(err, &optionsError) {
+			t.Fatalf("Options.Validate: want *OptionsError, current=%v", err)
+		}
+		if len(optionsError.Problems) != 6 {
+			t.Errorf("Options.Validate: want 6 problems, current=%v", optionsError.Problems)
+		}
+		for _, want := range []string{"6 problems of the options: ", "-" + optNameNullable + "=pointer", "key=a.b", "pattern=payload", "name=my-models", "-" + optNameFromRow} {
+			if !strings.Contains(err.Error(), want) {
+				t.Error("Options.Validate: want=`" + want + "` current=`" + err.Error() + "`")
+			}
+		}
+	})
+
+	t.Run("異常系_QueryMethod", func(t *testing.T) {
+		if err := (Options{QueryMethod: true, TagKey: defaultValueTagKey}).Validate(); err != nil {
+			t.Errorf("Options.Validate: %v", err)
+		}
+		for name, opts := range map[string]Options{
+			"TagKey":                   {QueryMethod: true, TagKey: "db"},
+			"FlattenRecords":           {QueryMethod: true, FlattenRecords: true, FromRow: true},
+			"NullableTemporal_pointer": {QueryMethod: true, NullableTemporal: nullableTemporalPointer, FromRow: true},
+		} {
+			if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), "-"+optNameQueryMethod) {
+				t.Errorf("Options.Validate: %s: want the error of -%s, current=%v", name, optNameQueryMethod, err)
+			}
+		}
+	})
+
+	t.Run("異常系_HelperInterfaces_NoTableNameMethod", func(t *testing.T) {
+		opts := Options{HelperInterfaces: true, NoTableNameMethod: true}
+		if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), "-"+optNameNoTableNameMethod) {
+			t.Errorf("Options.Validate: want the error of -%s, current=%v", optNameNoTableNameMethod, err)
+		}
+	})
+
+	t.Run("異常系_InformationSchema_AsOf", func(t *testing.T) {
+		opts := Options{InformationSchema: true, AsOf: time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)}
+		if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), "-"+optNameAsOf) {
+			t.Errorf("Options.Validate: want the error of -%s, current=%v", optNameAsOf, err)
+		}
+	})
+
+	t.Run("異常系_InformationSchema_policy_tags", func(t *testing.T) {
+		for _, opts := range []Options{
+			{InformationSchema: true, PolicyTagComment: "Policy tags"},
+			{InformationSchema: true, PolicyTagStructTag: `pii:"true"`},
+		} {
+			if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), "-"+optNameInformationSchema) {
+				t.Errorf("Options.Validate: want the error of -%s, current=%v", optNameInformationSchema, err)
+			}
+		}
+	})
+}
+
+func Test_validatePackageName(t *testing.T) {
+	for _, name := range []string{"", "models", "internal"} {
+		if err := validatePackageName(name); err != nil {
+			t.Error(err)
+		}
+	}
+	for _, name := range []string{"_", "my-models", "1models", "func"} {
+		if err := validatePackageName(name); err == nil {
+			t.Error("validatePackageName: want error. name=" + name)
+		}
+	}
+}
+
+func Test_notifyInterrupt(t *testing.T) {
+	t.Run("正常系_interrupt", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("WARN: os.Interrupt cannot be sent on windows")
+		}
+		ctx, stop := notifyInterrupt(context.Background())
+		defer stop()
+
+		process, err := os.FindProcess(os.Getpid())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := process.Signal(os.Interrupt); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(10 * time.Second):
+			t.Error("notifyInterrupt: the context is not canceled on interrupt")
+		}
+	})
+
+	t.Run("正常系_stop", func(t *testing.T) {
+		ctx, stop := notifyInterrupt(context.Background())
+		stop()
+		if ctx.Err() == nil {
+			t.Error("notifyInterrupt: the context is not canceled by stop")
+		}
+	})
+}
+
+func Test_loadEnvFile(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		envFile := filepath.Join(t.TempDir(), ".env")
+		content := "# comment\n\n" +
+			envNameGCloudProjectID + "=" + testPublicDataProjectID + "\n" +
+			"export " + envNameBigQueryDataset + " = \"" + testSupportedDatasetID + "\"\n" +
+			envNameOutputFile + "='" + testOptValue + "'\r\n"
+		if err := writeGeneratedCode(envFile, testFileMode, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		backup := os.Getenv(envNameOutputFile)
+		defer func() {
+			_ = os.Unsetenv(envNameGCloudProjectID)
+			_ = os.Unsetenv(envNameBigQueryDataset)
+			_ = os.Setenv(envNameOutputFile, backup)
+		}()
+		_ = os.Unsetenv(envNameGCloudProjectID)
+		_ = os.Unsetenv(envNameBigQueryDataset)
+		_ = os.Setenv(envNameOutputFile, defaultValueOutputFile)
+
+		if err := loadEnvFile(envFile); err != nil {
+			t.Fatal(err)
+		}
+		for key, want := range map[string]string{
+			envNameGCloudProjectID: testPublicDataProjectID,
+			envNameBigQueryDataset: testSupportedDatasetID,
+			envNameOutputFile:      defaultValueOutputFile,
+		} {
+			if value := os.Getenv(key); value != want {
+				t.Error("loadEnvFile: key=" + key + " want=" + want + " current=" + value)
+			}
+		}
+	})
+
+	t.Run("異常系_invalid_line", func(t *testing.T) {
+		envFile := filepath.Join(t.TempDir(), ".env")
+		if err := writeGeneratedCode(envFile, testFileMode, []byte("INVALID\n")); err != nil {
+			t.Fatal(err)
+		}
+		if err := loadEnvFile(envFile); err == nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_testErrNoSuchFileOrDirectoryPath", func(t *testing.T) {
+		if err := loadEnvFile(testErrNoSuchFileOrDirectoryPath); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_clientOptions(t *testing.T) {
+	t.Run("正常系_testEmptyString", func(t *testing.T) {
+		if opts := clientOptions(testEmptyString, testEmptyString, testEmptyString, nil); len(opts) != 0 {
+			t.Errorf("clientOptions: current=%v", opts)
+		}
+	})
+
+	t.Run("正常系_accessToken", func(t *testing.T) {
+		if opts := clientOptions(testOptValue, testGoogleApplicationCredentials, testEmptyString, nil); len(opts) != 1 {
+			t.Errorf("clientOptions: current=%v", opts)
+		}
+	})
+
+	t.Run("正常系_accessToken_scopes", func(t *testing.T) {
+		if opts := clientOptions(testOptValue, testEmptyString, testEmptyString, []string{bigquery.Scope}); len(opts) != 1 {
+			t.Errorf("clientOptions: current=%v", opts)
+		}
+	})
+
+	t.Run("正常系_keyfile", func(t *testing.T) {
+		if opts := clientOptions(testEmptyString, testGoogleApplicationCredentials, testEmptyString, []string{bigquery.Scope}); len(opts) != 2 {
+			t.Errorf("clientOptions: current=%v", opts)
+		}
+	})
+
+	t.Run("正常系_scopes", func(t *testing.T) {
+		if opts := clientOptions(testEmptyString, testEmptyString, testEmptyString, []string{bigquery.Scope}); len(opts) != 1 {
+			t.Errorf("clientOptions: current=%v", opts)
+		}
+	})
+
+	t.Run("正常系_quotaProject", func(t *testing.T) {
+		if opts := clientOptions(testEmptyString, testGoogleApplicationCredentials, testPublicDataProjectID, nil); len(opts) != 2 {
+			t.Errorf("clientOptions: current=%v", opts)
+		}
+	})
+}
+
+func Test_credentialsFileProjectID(t *testing.T) {
+	t.Run("正常系_service_account", func(t *testing.T) {
+		project, err := credentialsFileProjectID(testGoogleApplicationCredentials)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if project != testProjectNotFound {
+			t.Error("credentialsFileProjectID: want=" + testProjectNotFound + " current=" + project)
+		}
+	})
+
+	for name, tt := range map[string]struct {
+		content string
+		want    string
+	}{
+		"authorized_user":                  {content: `{"type": "authorized_user", "client_id": "id", "client_secret": "secret", "refresh_token": "token", "quota_project_id": "` + testPublicDataProjectID + `"}`, want: testPublicDataProjectID},
+		"authorized_user_no_quota_project": {content: `{"type": "authorized_user", "client_id": "id", "client_secret": "secret", "refresh_token": "token"}`, want: testEmptyString},
+		"external_account":                 {content: `{"type": "external_account", "audience": "audience"}`, want: testEmptyString},
+	} {
+		tt := tt
+		t.Run("正常系_"+name, func(t *testing.T) {
+			keyfile := filepath.Join(t.TempDir(), "credentials.json")
+			if err := writeGeneratedCode(keyfile, testFileMode, []byte(tt.content)); err != nil {
+				t.Fatal(err)
+			}
+			project, err := credentialsFileProjectID(keyfile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if project != tt.want {
+				t.Error("credentialsFileProjectID: want=" + tt.want + " current=" + project)
+			}
+		})
+	}
+
+	t.Run("異常系_json.Unmarshal", func(t *testing.T) {
+		if _, err := credentialsFileProjectID(testTemplateFile); err == nil || !strings.Contains(err.Error(), "json.Unmarshal") {
+			t.Error("credentialsFileProjectID: want json.Unmarshal error current=", err)
+		}
+	})
+
+	t.Run("異常系_testErrNoSuchFileOrDirectoryPath", func(t *testing.T) {
+		if _, err := credentialsFileProjectID(testErrNoSuchFileOrDirectoryPath); err == nil {
+			t.Error("credentialsFileProjectID: want error")
+		}
+	})
+}
+
+func Test_parseDatasetKeyFiles(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		keyFiles, err := parseDatasetKeyFiles("analytics=analytics.json,billing=" + testGoogleApplicationCredentials)
+		if err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(keyFiles, map[string]string{"analytics": "analytics.json", "billing": testGoogleApplicationCredentials}) {
+			t.Errorf("parseDatasetKeyFiles: unexpected key files: %v", keyFiles)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for _, s := range []string{"analytics", "analytics=", "=analytics.json", "analytics=a.json,analytics=b.json"} {
+			if _, err := parseDatasetKeyFiles(s); err == nil {
+				t.Error("parseDatasetKeyFiles: want error. s=" + s)
+			}
+		}
+	})
+}
+
+func Test_parseDatasetRefs(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		refs, err := parseDatasetRefs("analytics,prod.analytics,example.com:stage:analytics,example.com:dev.analytics")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []datasetRef{
+			{dataset: "analytics"},
+			{project: "prod", dataset: "analytics"},
+			{project: "example.com:stage", dataset: "analytics"},
+			{project: "example.com:dev", dataset: "analytics"},
+		}
+		if !reflect.DeepEqual(refs, want) {
+			t.Errorf("parseDatasetRefs: want=%v current=%v", want, refs)
+		}
+		if isQualifiedDatasetRefs(refs) {
+			t.Error("isQualifiedDatasetRefs: want false for the dataset ID without the project")
+		}
+		if !isQualifiedDatasetRefs(refs[1:]) || isQualifiedDatasetRefs(nil) {
+			t.Error("isQualifiedDatasetRefs: unexpected result")
+		}
+		if !hasMultipleProjects(refs[1:]) || hasMultipleProjects(refs[:1]) {
+			t.Error("hasMultipleProjects: unexpected result")
+		}
+		if name := refs[2].fileName(true); name != "example.com_stage.analytics" {
+			t.Errorf("fileName: want=example.com_stage.analytics current=%s", name)
+		}
+		if name := refs[2].fileName(false); name != "analytics" {
+			t.Errorf("fileName: want=analytics current=%s", name)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for _, s := range []string{testEmptyString, "analytics,", ".analytics", "prod.", "prod.analytics,prod.analytics"} {
+			if _, err := parseDatasetRefs(s); err == nil {
+				t.Error("parseDatasetRefs: want error. s=" + s)
+			}
+		}
+	})
+}
+
+func Test_datasetClients(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		ctx := context.Background()
+		client, err := bigquery.NewClient(ctx, testPublicDataProjectID, option.WithoutAuthentication())
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyFile := filepath.Join(t.TempDir(), "key.json")
+		if err := writeGeneratedCode(keyFile, testFileMode, []byte(`{"type": "authorized_user", "client_id": "id", "client_secret": "secret", "refresh_token": "token"}`)); err != nil {
+			t.Fatal(err)
+		}
+		prodKeyFile := filepath.Join(t.TempDir(), "prod.json")
+		if err := writeGeneratedCode(prodKeyFile, testFileMode, []byte(`{"type": "authorized_user", "client_id": "id", "client_secret": "secret", "refresh_token": "token"}`)); err != nil {
+			t.Fatal(err)
+		}
+		clients := &datasetClients{client: client, billingProject: testPublicDataProjectID, keyFiles: map[string]string{"analytics": keyFile, "billing": keyFile, "prod.analytics": prodKeyFile}}
+		defer clients.close()
+
+		if c, err := clients.of(ctx, datasetRef{project: testPublicDataProjectID, dataset: testSupportedDatasetID}); err != nil || c != client {
+			t.Errorf("clients.of: want the default client. err=%v", err)
+		}
+		analytics, err := clients.of(ctx, datasetRef{project: "dev", dataset: "analytics"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if analytics == client {
+			t.Error("clients.of: want the client of the key file")
+		}
+		if billing, err := clients.of(ctx, datasetRef{project: "dev", dataset: "billing"}); err != nil || billing != analytics {
+			t.Errorf("clients.of: want the client shared by the key file. err=%v", err)
+		}
+		// NOTE(djeeno): The key file of "project.dataset" precedes the key file of the dataset ID.
+		if prod, err := clients.of(ctx, datasetRef{project: "prod", dataset: "analytics"}); err != nil || prod == client || prod == analytics {
+			t.Errorf("clients.of: want the client of the key file of the project. err=%v", err)
+		}
+	})
+
+	t.Run("異常系_no_such_key_file", func(t *testing.T) {
+		ctx := context.Background()
+		clients := &datasetClients{billingProject: testPublicDataProjectID, keyFiles: map[string]string{"analytics": testErrNoSuchFileOrDirectoryPath}}
+		if _, err := clients.of(ctx, datasetRef{project: testPublicDataProjectID, dataset: "analytics"}); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_mkdirIfNotExist(t *testing.T) {
+	t.Run("正常系_testErrIsADirectoryPath", func(t *testing.T) {
+		if err := mkdirIfNotExist(testErrIsADirectoryPath); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("正常系_nested_directories", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "gen", "models", "sub")
+		if err := mkdirIfNotExist(dir); err != nil {
+			t.Error(err)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_file", func(t *testing.T) {
+		if err := mkdirIfNotExist(testSchemaFile); err == nil || !strings.Contains(err.Error(), "not a directory") {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_Generate(t *testing.T) {
+	t.Run("正常系_testSupportedDatasetID_"+testSupportedDatasetID, func(t *testing.T) {
+		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
+			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
+		}
+
+		var (
+			ctx       = context.Background()
+			client, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+		)
+
+		result, err := Generate(ctx, client, testPublicDataProjectID, testSupportedDatasetID, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Structs == 0 || len(result.Skipped) != 0 || len(result.Bytes) == 0 {
+			t.Errorf("Generate: unexpected result: Structs=%d Skipped=%v", result.Structs, result.Skipped)
+		}
+	})
+
+	t.Run("正常系_testNotSupportedDatasetID_"+testNotSupportedDatasetID, func(t *testing.T) {
+		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
+			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
+		}
+
+		var (
+			ctx       = context.Background()
+			client, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+		)
+
+		result, err := Generate(ctx, client, testPublicDataProjectID, testNotSupportedDatasetID, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Structs == 0 {
+			t.Errorf("Generate: unexpected result: Structs=%d Skipped=%v", result.Structs, result.Skipped)
+		}
+	})
+
+	t.Run("正常系_Partitioning_not_matched", func(t *testing.T) {
+		client := testTableDataClient(t, testNullableRoundTripSchema, "[]")
+
+		// NOTE(djeeno): The table of the fake client is not partitioned.
+		result, err := Generate(context.Background(), client, testPublicDataProjectID, testSupportedDatasetID, Options{Partitioning: partitioningPartitioned})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Structs != 0 || !reflect.DeepEqual(result.Skipped, []string{testTableID}) {
+			t.Errorf("Generate: unexpected result: Structs=%d Skipped=%v", result.Structs, result.Skipped)
+		}
+	})
+}
+
+func Test_schemaVersion(t *testing.T) {
+	schemas := bigquery.Schema{
+		{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+		{Name: "user", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+			{Name: "name", Type: bigquery.StringFieldType},
+		}},
+	}
+	described := bigquery.Schema{
+		{Name: "id", Type: bigquery.IntegerFieldType, Required: true, Description: "the id"},
+		{Name: "user", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+			{Name: "name", Type: bigquery.StringFieldType, Description: "the name"},
+		}},
+	}
+	changed := bigquery.Schema{
+		{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+		{Name: "user", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+			{Name: "name", Type: bigquery.StringFieldType, Repeated: true},
+		}},
+	}
+
+	t.Run("正常系_deterministic", func(t *testing.T) {
+		version := schemaVersion(map[string]string{"a": tableSchemaHash("a", schemas), "b": tableSchemaHash("b", schemas)})
+		for i := 0; i < 10; i++ {
+			if current := schemaVersion(map[string]string{"b": tableSchemaHash("b", schemas), "a": tableSchemaHash("a", described)}); current != version {
+				t.Fatalf("schemaVersion: want=%s current=%s", version, current)
+			}
+		}
+		if len(version) != sha256.Size*2 {
+			t.Errorf("schemaVersion: unexpected version: %s", version)
+		}
+	})
+
+	t.Run("正常系_changed", func(t *testing.T) {
+		for name, hash := range map[string]string{
+			"type":     tableSchemaHash("a", changed),
+			"table_id": tableSchemaHash("c", schemas),
+		} {
+			if tableSchemaHash("a", schemas) == hash {
+				t.Errorf("tableSchemaHash: the hash is not changed by %s", name)
+			}
+		}
+		if schemaVersion(map[string]string{"a": tableSchemaHash("a", schemas)}) == schemaVersion(nil) {
+			t.Error("schemaVersion: the version is not changed by the table")
+		}
+	})
+
+	t.Run("正常系_GenerateFromSchemaFile", func(t *testing.T) {
+		result, err := GenerateFromSchemaFile(testPublicDataProjectID, testSupportedDatasetID, testSchemaFile, Options{SchemaVersion: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.SchemaVersion == "" {
+			t.Fatal("GenerateFromSchemaFile: SchemaVersion is empty")
+		}
+		if want := "const SchemaVersion = \"" + result.SchemaVersion + "\"\n"; !strings.Contains(string(result.Bytes), want) {
+			t.Error("GenerateFromSchemaFile: want=`" + want + "` current=`" + string(result.Bytes) + "`")
+		}
+	})
+}
+
+func Test_GenerateSchemaJSON(t *testing.T) {
+	t.Run("正常系_testSupportedDatasetID_"+testSupportedDatasetID, func(t *testing.T) {
+		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
+			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
+		}
+
+		var (
+			ctx       = context.Background()
+			client, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+		)
+
+		result, err := GenerateSchemaJSON(ctx, client, testPublicDataProjectID, testSupportedDatasetID, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var schemas map[string]json.RawMessage
+		if err = json.Unmarshal(result.Bytes, &schemas); err != nil {
+			t.Fatal(err)
+		}
+		if result.Structs == 0 || len(schemas) != result.Structs {
+			t.Errorf("GenerateSchemaJSON: unexpected result: Structs=%d Skipped=%v", result.Structs, result.Skipped)
+		}
+		for tableID, schema := range schemas {
+			if _, err = bigquery.SchemaFromJSON(schema); err != nil {
+				t.Errorf("bigquery.SchemaFromJSON: tableID=%s: %v", tableID, err)
+			}
+		}
+	})
+
+	t.Run("正常系_ExcludeColumns_RequiredOnly", func(t *testing.T) {
+		schema := bigquery.Schema{
+			{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+			{Name: "password", Type: bigquery.StringFieldType, Required: true},
+			{Name: "nullable_string", Type: bigquery.StringFieldType},
+			{Name: "record", Type: bigquery.RecordFieldType, Required: true, Schema: bigquery.Schema{
+				{Name: "name", Type: bigquery.StringFieldType, Required: true},
+				{Name: "secret", Type: bigquery.StringFieldType, Required: true},
+			}},
+		}
+		client := testTableDataClient(t, schema, "[]")
+		opts := Options{ExcludeColumns: []string{"*.password", testTableID + ".record.secret"}, RequiredOnly: true}
+
+		result, err := GenerateSchemaJSON(context.Background(), client, testPublicDataProjectID, testSupportedDatasetID, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var schemas map[string]json.RawMessage
+		if err = json.Unmarshal(result.Bytes, &schemas); err != nil {
+			t.Fatal(err)
+		}
+		actual, err := bigquery.SchemaFromJSON(schemas[testTableID])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Structs != 1 || len(actual) != 2 || actual[0].Name != "id" || actual[1].Name != "record" ||
+			len(actual[1].Schema) != 1 || actual[1].Schema[0].Name != "name" {
+			t.Error("GenerateSchemaJSON: current=" + string(result.Bytes))
+		}
+	})
+
+	t.Run("異常系_invalid_options", func(t *testing.T) {
+		_, err := GenerateSchemaJSON(context.Background(), nil, testPublicDataProjectID, testSupportedDatasetID, Options{Nullable: "pointer"})
+		var optionsError *OptionsError
+		if !errors.As(err, &optionsError) {
+			t.Fatalf("GenerateSchemaJSON: want *OptionsError, current=%v", err)
+		}
+	})
+}
+
+func Test_schemaJSONFields(t *testing.T) {
+	schemas := bigquery.Schema{
+		{Name: "id", Type: bigquery.IntegerFieldType, Required: true, Description: "the id"},
+		{Name: "email", Type: bigquery.StringFieldType, PolicyTags: &bigquery.PolicyTagList{Names: []string{testPolicyTag}}},
+		{Name: "items", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+			{Name: "name", Type: bigquery.StringFieldType},
+		}},
+	}
+
+	t.Run("正常系_SchemaFromJSON", func(t *testing.T) {
+		content, err := json.Marshal(schemaJSONFields(schemas))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `"policyTags":{"names":["` + testPolicyTag + `"]}`; !strings.Contains(string(content), want) {
+			t.Error("schemaJSONFields: want=`" + want + "` current=`" + string(content) + "`")
+		}
+
+		actual, err := bigquery.SchemaFromJSON(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// NOTE(djeeno): bigquery.SchemaFromJSON of v1.13.0 does not read the policy tags.
+		expected := bigquery.Schema{schemas[0], {Name: "email", Type: bigquery.StringFieldType}, schemas[2]}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("schemaJSONFields: expected(%v) != actual(%v)", expected, actual)
+		}
+	})
+}
+
+func Test_GenerateFromSchemaFile(t *testing.T) {
+	t.Run("正常系_testSchemaFile", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testGeneratedCode = `// Code generated by go run github.com/djeeno/bqschema-gen-go; DO NOT EDIT.
+
+//go:generate go run github.com/djeeno/bqschema-gen-go
+
+package bqschema
+
+import "time"
+
+// Comments is BigQuery Table ` + "`bigquery-public-data:hacker_news.comments`" + ` schema struct.
+// Description:
+type Comments struct {
+	Id      int64          ` + "`bigquery:\"id\"`" + `
+	By      string         ` + "`bigquery:\"by\"`" + `
+	Time_ts time.Time      ` + "`bigquery:\"time_ts\"`" + `
+	Author  CommentsAuthor ` + "`bigquery:\"author\"`" + `
+}
+
+// TableName returns the ID of the BigQuery table of Comments.
+func (Comments) TableName() string {
+	return "comments"
+}
+
+// CommentsAuthor is RECORD column ` + "`author`" + ` schema struct.
+type CommentsAuthor struct {
+	Name string ` + "`bigquery:\"name\"`" + `
+}
+`
+		)
+		result, err := GenerateFromSchemaFile(testPublicDataProjectID, testSupportedDatasetID, testSchemaFile, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Structs != 1 || string(result.Bytes) != testGeneratedCode {
+			t.Error("GenerateFromSchemaFile: want=`" + testGeneratedCode + "` current=`" + string(result.Bytes) + "`")
+		}
+	})
+
+	t.Run("正常系_testTemplateFile", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testGeneratedCode = `// Comments is BigQuery Table ` + "`bigquery-public-data:hacker_news.comments`" + ` schema struct.
+// Description:
+export interface Comments {
+  id: number;
+  by?: string;
+  time_ts?: string;
+  author?: CommentsAuthor;
+}
+
+// CommentsAuthor is RECORD column ` + "`author`" + ` schema struct.
+export interface CommentsAuthor {
+  name?: string;
+}
+`
+		)
+		structTemplate, err := parseTemplateFile(testTemplateFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := GenerateFromSchemaFile(testPublicDataProjectID, testSupportedDatasetID, testSchemaFile, Options{Template: structTemplate, SelectAll: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(result.Bytes) != testGeneratedCode {
+			t.Error("GenerateFromSchemaFile: want=`" + testGeneratedCode + "` current=`" + string(result.Bytes) + "`")
+		}
+	})
+
+	t.Run("異常系_testErrNoSuchFileOrDirectoryPath", func(t *testing.T) {
+		if _, err := GenerateFromSchemaFile(testEmptyString, testEmptyString, testErrNoSuchFileOrDirectoryPath, Options{}); err == nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_invalid_json", func(t *testing.T) {
+		if _, err := GenerateFromSchemaFile(testEmptyString, testEmptyString, testProbablyExistsPath, Options{}); err == nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_invalid_options", func(t *testing.T) {
+		_, err := GenerateFromSchemaFile(testPublicDataProjectID, testSupportedDatasetID, testSchemaFile, Options{Nullable: "pointer"})
+		var optionsError *OptionsError
+		if !errors.As(err, &optionsError) {
+			t.Fatalf("GenerateFromSchemaFile: want *OptionsError, current=%v", err)
+		}
+		if want := "1 problem of the options: "; !strings.Contains(err.Error(), want) {
+			t.Error("GenerateFromSchemaFile: want=`" + want + "` current=`" + err.Error() + "`")
+		}
+	})
+}
+
+func Test_Diff(t *testing.T) {
+	t.Run("正常系_testSupportedDatasetID_"+testSupportedDatasetID, func(t *testing.T) {
+		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
+			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
+		}
+
+		var (
+			ctx       = context.Background()
+			client, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+			filePath  = filepath.Join(t.TempDir(), defaultValueOutputFile)
+		)
+
+		result, err := generateFile(ctx, client, testPublicDataProjectID, testSupportedDatasetID, filePath, testOutputConfig, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		drifts, err := Diff(ctx, client, testPublicDataProjectID, testSupportedDatasetID, filePath, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Skipped) == 0 && len(drifts) != 0 {
+			t.Errorf("Diff: unexpected drifts: %v", drifts)
+		}
+	})
+
+	t.Run("正常系_unsupported_field_type", func(t *testing.T) {
+		schema := append(bigquery.Schema{{Name: "payload", Type: "JSON"}}, testNullableRoundTripSchema...)
+		client := testTableDataClient(t, schema, "[]")
+		filePath := filepath.Join(t.TempDir(), defaultValueOutputFile)
+		structCode, pkgs, err := generateTableMetadataCode(testTable, &bigquery.TableMetadata{FullID: testTableID, Schema: testNullableRoundTripSchema}, nil, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, structCode, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writeGeneratedCode(filePath, testFileMode, code); err != nil {
+			t.Fatal(err)
+		}
+
+		// NOTE(djeeno): The table is skipped as Generate skips it, instead of failing Diff or being reported as removed.
+		drifts, err := Diff(context.Background(), client, testPublicDataProjectID, testSupportedDatasetID, filePath, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(drifts) != 0 {
+			t.Errorf("Diff: unexpected drifts: %v", drifts)
+		}
+	})
+
+	t.Run("正常系_metadata_error", func(t *testing.T) {
+		fields, err := json.Marshal(schemaJSONFields(testNullableRoundTripSchema))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tablesPath := "/projects/" + testPublicDataProjectID + "/datasets/" + testSupportedDatasetID + "/tables"
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case tablesPath:
+				_, _ = io.WriteString(w, `{"tables": [`+
+					`{"tableReference": {"projectId": "`+testPublicDataProjectID+`", "datasetId": "`+testSupportedDatasetID+`", "tableId": "broken"}},`+
+					`{"tableReference": {"projectId": "`+testPublicDataProjectID+`", "datasetId": "`+testSupportedDatasetID+`", "tableId": "`+testTableID+`"}}]}`)
+			case tablesPath + "/" + testTableID:
+				_, _ = io.WriteString(w, `{"schema": {"fields": `+string(fields)+`}}`)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer server.Close()
+		client, err := bigquery.NewClient(context.Background(), testPublicDataProjectID, option.WithEndpoint(server.URL+"/"), option.WithoutAuthentication())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+
+		filePath := filepath.Join(t.TempDir(), defaultValueOutputFile)
+		structCode, pkgs, err := generateTableMetadataCode(testTable, &bigquery.TableMetadata{FullID: testTableID, Schema: bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType}}}, nil, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, structCode, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writeGeneratedCode(filePath, testFileMode, code); err != nil {
+			t.Fatal(err)
+		}
+
+		// NOTE(djeeno): The table that fails is skipped, and the drifts of the other table are still reported.
+		drifts, err := Diff(context.Background(), client, testPublicDataProjectID, testSupportedDatasetID, filePath, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(drifts) == 0 {
+			t.Error("Diff: want the drifts of " + testTableID)
+		}
+		for _, drift := range drifts {
+			if drift.TableID != testTableID {
+				t.Errorf("Diff: unexpected drift: %v", drift)
+			}
+		}
+	})
+
+	t.Run("正常系_Partitioning", func(t *testing.T) {
+		client := testTableDataClient(t, testNullableRoundTripSchema, "[]")
+		filePath := filepath.Join(t.TempDir(), defaultValueOutputFile)
+		code, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, nil, "", Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writeGeneratedCode(filePath, testFileMode, code); err != nil {
+			t.Fatal(err)
+		}
+
+		// NOTE(djeeno): The unpartitioned table is skipped as Generate skips it, instead of being reported as added.
+		drifts, err := Diff(context.Background(), client, testPublicDataProjectID, testSupportedDatasetID, filePath, Options{Partitioning: partitioningPartitioned})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(drifts) != 0 {
+			t.Errorf("Diff: unexpected drifts: %v", drifts)
+		}
+	})
+
+	t.Run("異常系_listing_cut_short_by_SkipForbidden", func(t *testing.T) {
+		tablesPath := "/projects/" + testPublicDataProjectID + "/datasets/" + testSupportedDatasetID + "/tables"
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Path != tablesPath {
+				http.NotFound(w, r)
+				return
+			}
+			// NOTE(djeeno): The first page is listed, and the next page is forbidden.
+			if r.URL.Query().Get("pageToken") == "" {
+				_, _ = io.WriteString(w, `{"nextPageToken": "page2", "tables": [{"tableReference": {"projectId": "`+testPublicDataProjectID+`", "datasetId": "`+testSupportedDatasetID+`", "tableId": "`+testTableID+`"}}]}`)
+				return
+			}
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = io.WriteString(w, `{"error": {"code": 403, "message": "denied", "errors": [{"reason": "accessDenied"}]}}`)
+		}))
+		defer server.Close()
+		client, err := bigquery.NewClient(context.Background(), testPublicDataProjectID, option.WithEndpoint(server.URL+"/"), option.WithoutAuthentication())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+
+		filePath := filepath.Join(t.TempDir(), defaultValueOutputFile)
+		structCode, pkgs, err := generateTableMetadataCode(testTable, &bigquery.TableMetadata{FullID: testTableID, Schema: testNullableRoundTripSchema}, nil, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, structCode, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writeGeneratedCode(filePath, testFileMode, code); err != nil {
+			t.Fatal(err)
+		}
+
+		// NOTE(djeeno): The tables of the next page must not be reported as removed.
+		if _, err := Diff(context.Background(), client, testPublicDataProjectID, testSupportedDatasetID, filePath, Options{SkipForbidden: true}); err == nil || !strings.Contains(err.Error(), "cut short") {
+			t.Errorf("Diff: current=%v", err)
+		}
+	})
+
+	t.Run("異常系_invalid_options", func(t *testing.T) {
+		_, err := Diff(context.Background(), nil, testPublicDataProjectID, testSupportedDatasetID, testProbablyExistsPath, Options{Nullable: "pointer"})
+		var optionsError *OptionsError
+		if !errors.As(err, &optionsError) {
+			t.Fatalf("Diff: want *OptionsError, current=%v", err)
+		}
+	})
+}
+
+func Test_GenerateAdded(t *testing.T) {
+	t.Run("正常系_testPublicDataProjectID_"+testPublicDataProjectID+"_testSupportedDatasetID_"+testSupportedDatasetID, func(t *testing.T) {
+		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
+			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
+		}
+
+		var (
+			ctx       = context.Background()
+			client, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+			filePath  = filepath.Join(t.TempDir(), defaultValueOutputFile)
+		)
+
+		// NOTE(djeeno): The generated file has only comments, so that the columns of comments are all added.
+		result, err := GenerateFromSchemaFile(testPublicDataProjectID, testSupportedDatasetID, testSchemaFile, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writeGeneratedCode(filePath, testFileMode, result.Bytes); err != nil {
+			t.Fatal(err)
+		}
+		result, err = GenerateAdded(ctx, client, testPublicDataProjectID, testSupportedDatasetID, filePath, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Structs == 0 || !strings.Contains(string(result.Bytes), "type StoriesAdded struct {") || strings.Contains(string(result.Bytes), generateDirective) {
+			t.Error("GenerateAdded: current=" + string(result.Bytes))
+		}
+	})
+
+	t.Run("正常系_added_columns", func(t *testing.T) {
+		client := testTableDataClient(t, testNullableRoundTripSchema, "[]")
+		filePath := filepath.Join(t.TempDir(), defaultValueOutputFile)
+		structCode, pkgs, err := generateTableMetadataCode(testTable, &bigquery.TableMetadata{FullID: testTableID, Schema: testNullableRoundTripSchema[:2]}, nil, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, structCode, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writeGeneratedCode(filePath, testFileMode, code); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := GenerateAdded(context.Background(), client, testPublicDataProjectID, testSupportedDatasetID, filePath, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Structs != 1 || !strings.Contains(string(result.Bytes), "type Test_tableAdded struct {") ||
+			!strings.Contains(string(result.Bytes), `bigquery:"nullable_int"`) || strings.Contains(string(result.Bytes), `bigquery:"nullable_string"`) {
+			t.Error("GenerateAdded: current=" + string(result.Bytes))
+		}
+	})
+
+	t.Run("正常系_no_added_columns", func(t *testing.T) {
+		client := testTableDataClient(t, testNullableRoundTripSchema, "[]")
+		filePath := filepath.Join(t.TempDir(), defaultValueOutputFile)
+		structCode, pkgs, err := generateTableMetadataCode(testTable, &bigquery.TableMetadata{FullID: testTableID, Schema: testNullableRoundTripSchema}, nil, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, structCode, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writeGeneratedCode(filePath, testFileMode, code); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := GenerateAdded(context.Background(), client, testPublicDataProjectID, testSupportedDatasetID, filePath, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Structs != 0 || len(result.Skipped) != 0 || strings.Contains(string(result.Bytes), "Test_tableAdded") {
+			t.Errorf("GenerateAdded: Structs=%d Skipped=%v current=%s", result.Structs, result.Skipped, result.Bytes)
+		}
+	})
+
+	t.Run("異常系_invalid_options", func(t *testing.T) {
+		_, err := GenerateAdded(context.Background(), nil, testPublicDataProjectID, testSupportedDatasetID, testProbablyExistsPath, Options{Nullable: "pointer"})
+		var optionsError *OptionsError
+		if !errors.As(err, &optionsError) {
+			t.Fatalf("GenerateAdded: want *OptionsError, current=%v", err)
+		}
+	})
+}
+
+func Test_addedColumns(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		schema := bigquery.Schema{
+			{Name: "id", Type: bigquery.IntegerFieldType},
+			{Name: "email", Type: bigquery.StringFieldType},
+			{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+				{Name: "city", Type: bigquery.StringFieldType},
+				{Name: "zip", Type: bigquery.StringFieldType},
+			}},
+			{Name: "profile", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+				{Name: "name", Type: bigquery.StringFieldType},
+			}},
+		}
+		generated := map[string]string{"id": "int64", "address": typeOfRecordColumn, "address.city": "string", "profile": typeOfRecordColumn, "profile.name": "string"}
+		added := addedColumns("", schema, generated)
+		want := bigquery.Schema{
+			{Name: "email", Type: bigquery.StringFieldType},
+			{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+				{Name: "zip", Type: bigquery.StringFieldType},
+			}},
+		}
+		if !reflect.DeepEqual(added, want) {
+			t.Errorf("addedColumns: want=%v current=%v", want, added)
+		}
+		if len(schema[2].Schema) != 2 {
+			t.Error("addedColumns: the RECORD column of schema is modified")
+		}
+		if added := addedColumns("", schema, nil); !reflect.DeepEqual(added, schema) {
+			t.Errorf("addedColumns: want all the columns. current=%v", added)
+		}
+	})
+}
+
+func Test_addedNameMapper(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+				{Name: "zip", Type: bigquery.StringFieldType},
+			}},
+		}}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{NameMapper: addedNameMapper(nil)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"type Test_tableAdded struct {\n\tAddress Test_tableAddedAddress `bigquery:\"address\"`\n}\n",
+			"type Test_tableAddedAddress struct {\n\tZip string `bigquery:\"zip\"`\n}\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+}
+
+func Test_reflectStructType(t *testing.T) {
+	t.Run("正常系_testNullableRoundTripSchema", func(t *testing.T) {
+		opts := Options{Nullable: nullableModeNull}
+		fields, _, _, err := resolveStructFields(testTable, &bigquery.TableMetadata{}, "Test_table", "", testNullableRoundTripSchema, newGeneration(nil, opts), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		structType, err := reflectStructType(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		schema, err := bigquery.InferSchema(reflect.New(structType).Elem().Interface())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(schema, testNullableRoundTripSchema) {
+			t.Errorf("reflectStructType: want=%v current=%v", testNullableRoundTripSchema, schema)
+		}
+	})
+
+	t.Run("正常系_testNullableRecordsSchema", func(t *testing.T) {
+		opts := Options{NullableRecords: nullableRecordsPointer}
+		fields, _, _, err := resolveStructFields(testTable, &bigquery.TableMetadata{}, "Test_table", "", testNullableRecordsSchema, newGeneration(nil, opts), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		structType, err := reflectStructType(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		current, err := bigquery.InferSchema(reflect.New(structType).Elem().Interface())
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := bigquery.InferSchema(testNullableRecords{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(current, want) {
+			t.Errorf("reflectStructType: want=%v current=%v", want, current)
+		}
+	})
+
+	t.Run("正常系_overridden_and_flattened", func(t *testing.T) {
+		schema := bigquery.Schema{
+			{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+			{Name: "count", Type: bigquery.IntegerFieldType, Required: true},
+			{Name: "values", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{{Name: "value", Type: bigquery.IntegerFieldType}}},
+		}
+		opts := Options{FlattenRecords: true, TypeMap: map[string]string{testTableID + ".count": "int32"}}
+		fields, _, _, err := resolveStructFields(testTable, &bigquery.TableMetadata{}, "Test_table", "", schema, newGeneration(nil, opts), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		structType, err := reflectStructType(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if structType.NumField() != 1 || structType.Field(0).Name != "Id" {
+			t.Errorf("reflectStructType: current=%v", structType)
+		}
+	})
+}
+
+func Test_validateTables(t *testing.T) {
+	t.Run("正常系_testPublicDataProjectID_testSupportedDatasetID", func(t *testing.T) {
+
+		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
+			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
+		}
+
+		var (
+			ctx       = context.Background()
+			client, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+		)
+
+		failures, err := validateTables(ctx, client, testPublicDataProjectID, testSupportedDatasetID, Options{Nullable: nullableModeNull})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(failures) != 0 {
+			t.Errorf("validateTables: failures=%v", failures)
+		}
+	})
+}
+
+func Test_diffTables(t *testing.T) {
+	t.Run("正常系_round_trip_TagKey", func(t *testing.T) {
+		opts := Options{Nullable: nullableModeNull, TagKey: "db"}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, &bigquery.TableMetadata{Schema: testNullableRoundTripSchema, FullID: testTableFullID}, nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "`db:\"" + testNullableRoundTripSchema[0].Name + "\"`"; !strings.Contains(generatedCode, want) || strings.Contains(generatedCode, "`bigquery:") {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+		code, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, generatedCode, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filePath := filepath.Join(t.TempDir(), defaultValueOutputFile)
+		if err := writeGeneratedCode(filePath, testFileMode, code); err != nil {
+			t.Fatal(err)
+		}
+		generated, err := parseGeneratedFile(filePath, structTagKey(opts))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		live := map[string]map[string]string{testTableID: {}}
+		if err := schemaColumnTypes(testTableID, "", testNullableRoundTripSchema, newGeneration(nil, opts), opts, live[testTableID]); err != nil {
+			t.Fatal(err)
+		}
+		if drifts := diffTables(generated, live); len(drifts) != 0 {
+			t.Errorf("diffTables: unexpected drifts: %v", drifts)
+		}
+	})
+
+	t.Run("正常系_round_trip", func(t *testing.T) {
+		schema := append(append(bigquery.Schema{}, testNullableRoundTripSchema...), testNullableRecordsSchema...)
+		opts := Options{Nullable: nullableModeNull, NullableRecords: nullableRecordsPointer}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, &bigquery.TableMetadata{Schema: schema, FullID: testTableFullID}, nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, generatedCode, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filePath := filepath.Join(t.TempDir(), defaultValueOutputFile)
+		if err := writeGeneratedCode(filePath, testFileMode, code); err != nil {
+			t.Fatal(err)
+		}
+		generated, err := parseGeneratedFile(filePath, defaultValueTagKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		live := map[string]map[string]string{testTableID: {}}
+		if err := schemaColumnTypes(testTableID, "", schema, newGeneration(nil, opts), opts, live[testTableID]); err != nil {
+			t.Fatal(err)
+		}
+		if drifts := diffTables(generated, live); len(drifts) != 0 {
+			t.Errorf("diffTables: unexpected drifts: %v", drifts)
+		}
+
+		live[testTableID]["required_record.name"] = "string"
+		delete(live[testTableID], "nullable_num")
+		live[testTableID]["nullable_int"] = "int64"
+		live["new_table"] = map[string]string{}
+		generated[testTableID+"_old"] = map[string]string{}
+		want := []string{
+			"table `new_table` is added",
+			"table `test_table`: column `nullable_int` is changed: bigquery.NullInt64 -> int64",
+			"table `test_table`: column `nullable_num` is removed: *big.Rat",
+			"table `test_table`: column `required_record.name` is added: string",
+			"table `test_table_old` is removed",
+		}
+		var current []string
+		for _, drift := range diffTables(generated, live) {
+			current = append(current, drift.String())
+		}
+		if !reflect.DeepEqual(current, want) {
+			t.Errorf("diffTables: want=%v current=%v", want, current)
+		}
+	})
+}
+
+func Test_generateFileCode(t *testing.T) {
+	t.Run("正常系_omitGenerateDirective", func(t *testing.T) {
+		code, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, nil, testEmptyString, Options{omitGenerateDirective: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := generatedCodeHeader + "\n\npackage bqschema\n"; string(code) != want {
+			t.Error("generateFileCode: want=`" + want + "` current=`" + string(code) + "`")
+		}
+	})
+
+	t.Run("正常系_gofmt_clean", func(t *testing.T) {
+		schema := append(append(bigquery.Schema{}, testNullableRoundTripSchema...), testNullableRecordsSchema...)
+		opts := Options{Nullable: nullableModeNull, NullableRecords: nullableRecordsPointer, FromRow: true, Embed: testEmbedType, Constants: true, ConsoleURL: true}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, &bigquery.TableMetadata{Schema: schema, FullID: testTableFullID}, nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// NOTE: indented with spaces and tabs
+		generatedCode = generatedCode + "type Mixed struct {\n    A int `bigquery:\"a\"`\n\t  B string `bigquery:\"b\"`\n}\n"
+
+		code, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, generatedCode, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		formatted, err := format.Source(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(code, formatted) {
+			t.Error("generateFileCode: not gofmt-clean: " + string(code))
+		}
+		if !strings.Contains(string(code), "\tA int    `bigquery:\"a\"`\n\tB string `bigquery:\"b\"`\n") {
+			t.Error("generateFileCode: not indented with tabs: " + string(code))
+		}
+	})
+}
+
+func Test_structsWriter(t *testing.T) {
+	var (
+		otherTable = &bigquery.Table{ProjectID: testPublicDataProjectID, DatasetID: testSupportedDatasetID, TableID: "other_table"}
+		schemas    = []bigquery.Schema{
+			append(append(bigquery.Schema{}, testNullableRoundTripSchema...), testNullableRecordsSchema...),
+			testWideSchema,
+			{},
+		}
+	)
+
+	for name, opts := range map[string]Options{
+		"default":               {},
+		"nullable":              {Nullable: nullableModeNull, NullableRecords: nullableRecordsPointer, FromRow: true, Embed: testEmbedType, Constants: true, ConsoleURL: true},
+		"omitGenerateDirective": {Getters: true, SelectAll: true, omitGenerateDirective: true},
+		"partial":               {partial: true},
+		"scaffold":              {Scaffold: true, Constants: true},
+	} {
+		opts := opts
+		t.Run("正常系_same_as_generateFileCode_"+name, func(t *testing.T) {
+			for _, lineEnding := range []string{lineEndingLF, lineEndingCRLF} {
+				w, err := newStructsWriter(lineEnding)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer w.close()
+
+				var structsCode string
+				var importPackages []string
+				for i, table := range []*bigquery.Table{testTable, otherTable} {
+					structCode, pkgs, err := generateTableMetadataCode(table, &bigquery.TableMetadata{Schema: schemas[i]}, nil, opts)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if err := w.write(structCode, pkgs); err != nil {
+						t.Fatal(err)
+					}
+					structsCode = structsCode + structCode
+					importPackages = append(importPackages, pkgs...)
+				}
+
+				want, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, importPackages, structsCode, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want, err = convertLineEnding(want, lineEnding); err != nil {
+					t.Fatal(err)
+				}
+
+				filePath := filepath.Join(t.TempDir(), "bqschema.go")
+				if err := w.writeFile(filePath, testFileMode, testPublicDataProjectID, testSupportedDatasetID, opts); err != nil {
+					t.Fatal(err)
+				}
+				current, err := readFile(filePath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(want, current) {
+					t.Error("structsWriter: want=`" + string(want) + "` current=`" + string(current) + "`")
+				}
+				if opts.partial && (!bytes.HasPrefix(current, []byte(partialOutputHeader)) || !isGeneratedCode(current)) {
+					t.Error("structsWriter: the partial output is not marked: " + string(current))
+				}
+				if opts.Scaffold && (!bytes.HasPrefix(current, []byte(scaffoldCodeHeader)) || isGeneratedCode(current) || bytes.Contains(current, []byte(generateDirective))) {
+					t.Error("structsWriter: the scaffold is marked as the generated code: " + string(current))
+				}
+			}
+		})
+	}
+
+	t.Run("正常系_no_structs", func(t *testing.T) {
+		w, err := newStructsWriter(lineEndingLF)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.close()
+
+		filePath := filepath.Join(t.TempDir(), "bqschema.go")
+		if err := w.writeFile(filePath, testFileMode, testPublicDataProjectID, testSupportedDatasetID, Options{}); err != nil {
+			t.Fatal(err)
+		}
+		current, err := readFile(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, nil, testEmptyString, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(want, current) {
+			t.Error("structsWriter: want=`" + string(want) + "` current=`" + string(current) + "`")
+		}
+	})
+
+	t.Run("正常系_mergeFile", func(t *testing.T) {
+		var (
+			addedTable = &bigquery.Table{ProjectID: testPublicDataProjectID, DatasetID: testSupportedDatasetID, TableID: "added_table"}
+			updated    = bigquery.Schema{{Name: "id", Type: bigquery.StringFieldType, Required: true}}
+		)
+		for _, lineEnding := range []string{lineEndingLF, lineEndingCRLF} {
+			filePath := filepath.Join(t.TempDir(), "bqschema.go")
+			write := func(tables []*bigquery.Table, schemas []bigquery.Schema, opts Options) (structsCode string, importPackages []string) {
+				w, err := newStructsWriter(lineEnding)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer w.close()
+				for i, table := range tables {
+					structCode, pkgs, err := generateTableMetadataCode(table, &bigquery.TableMetadata{Schema: schemas[i]}, nil, opts)
+					if err != nil {
+						t.Fatal(err)
+					}
+					structCode = mergeBlockCode(table.TableID, structCode)
+					if err := w.write(structCode, pkgs); err != nil {
+						t.Fatal(err)
+					}
+					structsCode = structsCode + structCode
+					importPackages = append(importPackages, pkgs...)
+				}
+				if err := w.mergeFile(filePath, testFileMode, testPublicDataProjectID, testSupportedDatasetID, opts); err != nil {
+					t.Fatal(err)
+				}
+				return structsCode, importPackages
+			}
+
+			// NOTE(djeeno): The file that does not exist is written as is.
+			_, _ = write([]*bigquery.Table{testTable, otherTable}, []bigquery.Schema{testNullableRoundTripSchema, testWideSchema}, Options{Nullable: nullableModeNull})
+			// NOTE(djeeno): testTable is replaced by the struct without the nullable packages, otherTable is kept, and addedTable is appended.
+			updatedCode, updatedPackages := write([]*bigquery.Table{testTable, addedTable}, []bigquery.Schema{updated, updated}, Options{Nullable: nullableModeNull, Tables: []string{testTable.TableID, addedTable.TableID}})
+
+			otherCode, otherPackages, err := generateTableMetadataCode(otherTable, &bigquery.TableMetadata{Schema: testWideSchema}, nil, Options{Nullable: nullableModeNull})
+			if err != nil {
+				t.Fatal(err)
+			}
+			blocks := strings.SplitAfterN(updatedCode, mergeMarkerEnd+testTable.TableID+"\n", 2)
+			want, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, append(updatedPackages, otherPackages...),
+				blocks[0]+"\n"+mergeBlockCode(otherTable.TableID, otherCode)+"\n"+blocks[1], Options{Nullable: nullableModeNull})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want, err = convertLineEnding(want, lineEnding); err != nil {
+				t.Fatal(err)
+			}
+			current, err := readFile(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(want, current) {
+				t.Error("structsWriter.mergeFile: want=`" + string(want) + "` current=`" + string(current) + "`")
+			}
+		}
+	})
+
+	t.Run("異常系_mergeFile_no_markers", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "bqschema.go")
+		if err := writeGeneratedCode(filePath, testFileMode, []byte(generatedCodeHeader+"\n\npackage bqschema\n")); err != nil {
+			t.Fatal(err)
+		}
+		w, err := newStructsWriter(lineEndingLF)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.close()
+
+		if err := w.mergeFile(filePath, testFileMode, testPublicDataProjectID, testSupportedDatasetID, Options{Tables: []string{testTable.TableID}}); err == nil || !strings.Contains(err.Error(), "no markers") {
+			t.Error("structsWriter.mergeFile: want no markers error current=", err)
+		}
+	})
+
+	t.Run("異常系_format.Source", func(t *testing.T) {
+		w, err := newStructsWriter(lineEndingLF)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.close()
+
+		if err := w.write("type {", nil); err == nil || !strings.Contains(err.Error(), "format.Source") {
+			t.Error("structsWriter.write: want format.Source error current=", err)
+		}
+	})
+}
+
+func Test_newMergeGeneration(t *testing.T) {
+	t.Run("正常系_kept_blocks", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), defaultValueOutputFile)
+		existing := generatedCodeHeader + "\n\npackage bqschema\n\nimport uuid2 \"github.com/google/uuid\"\n\n" +
+			mergeBlockCode(testTableID, "type Test_table struct{}\n\ntype Test_tableRequired_record struct{}\n") +
+			"\n// NOTE: the code between the blocks is kept\nvar Test_tableCol = uuid2.Nil\n\n" +
+			mergeBlockCode("other_table", "type Other_table struct{}\n\ntype Test_tableNullable_record struct{}\n")
+		if err := writeGeneratedCode(filePath, testFileMode, []byte(existing)); err != nil {
+			t.Fatal(err)
+		}
+
+		gen, err := newMergeGeneration(filePath, []*bigquery.Table{testTable}, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// NOTE: the names of the replaced block of testTable are not reserved
+		if expected := map[string]bool{"Test_tableCol": true, "Other_table": true, "Test_tableNullable_record": true, "Test_table": true}; !reflect.DeepEqual(expected, gen.typeNames) {
+			t.Errorf("newMergeGeneration: expected(%v) != actual(%v)", expected, gen.typeNames)
+		}
+		if name := gen.importNames["github.com/google/uuid"]; name != "uuid2" {
+			t.Error("newMergeGeneration: want=uuid2 current=" + name)
+		}
+
+		generatedCode, _, err := generateTableMetadataCode(testTable, &bigquery.TableMetadata{Schema: testNullableRecordsSchema}, gen, Options{ColumnsVar: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"type Test_table struct {\n",
+			"type Test_tableRequired_record struct {\n",
+			"type Test_tableNullable_record_2 struct {\n",
+			"var Test_tableCol_2 = struct {\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_not_exist", func(t *testing.T) {
+		gen, err := newMergeGeneration(filepath.Join(t.TempDir(), defaultValueOutputFile), []*bigquery.Table{testTable}, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(gen.typeNames, map[string]bool{"Test_table": true}) {
+			t.Errorf("newMergeGeneration: current=%v", gen.typeNames)
+		}
+	})
+}
+
+func Test_splitMergeBlocks(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		code := "package bqschema\n\n" + mergeBlockCode("a", "type A struct{}\n") + "\n// kept\n" + mergeBlockCode("b", "type B struct{}\n")
+		blocks, err := splitMergeBlocks(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []mergeBlock{
+			{code: "package bqschema\n\n"},
+			{tableID: "a", code: mergeBlockCode("a", "type A struct{}\n")},
+			{code: "\n// kept\n"},
+			{tableID: "b", code: mergeBlockCode("b", "type B struct{}\n")},
+		}
+		if !reflect.DeepEqual(want, blocks) {
+			t.Errorf("splitMergeBlocks: want=%#v current=%#v", want, blocks)
+		}
+	})
+
+	for name, code := range map[string]string{
+		"not_closed": mergeMarkerBegin + "a\ntype A struct{}\n",
+		"nested":     mergeMarkerBegin + "a\n" + mergeBlockCode("b", "type B struct{}\n") + mergeMarkerEnd + "a\n",
+		"mismatched": mergeMarkerBegin + "a\ntype A struct{}\n" + mergeMarkerEnd + "b\n",
+		"duplicated": mergeBlockCode("a", "type A struct{}\n") + mergeBlockCode("a", "type A struct{}\n"),
+	} {
+		code := code
+		t.Run("異常系_"+name, func(t *testing.T) {
+			if _, err := splitMergeBlocks(code); err == nil {
+				t.Error("splitMergeBlocks: want error current=nil")
+			}
+		})
+	}
+}
+
+func Test_generateConstantsCode(t *testing.T) {
+	t.Run("正常系_testPublicDataProjectID_testSupportedDatasetID", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testConstantsCode = `// ProjectID is the BigQuery project ID that the code is generated from.
+// DatasetID is the BigQuery dataset ID that the code is generated from.
+const (
+	ProjectID = "bigquery-public-data"
+	DatasetID = "hacker_news"
+)
+
+`
+		)
+		generatedCode := generateConstantsCode(testPublicDataProjectID, testSupportedDatasetID)
+
+		if generatedCode != testConstantsCode {
+			var (
+				rr      = strings.NewReplacer("\n", "\\n", "`", "\\`")
+				want    = rr.Replace(testConstantsCode)
+				current = rr.Replace(generatedCode)
+			)
+			t.Error("generateConstantsCode: want=`" + want + "` current=`" + current + "`")
+		}
+	})
+}
+
+func Test_importName(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		importNames := reserveImportNames(Options{HelperInterfaces: true})
+		for _, tt := range []struct {
+			importPath, want string
+		}{
+			{importPath: "github.com/a/uuid", want: "uuid"},
+			{importPath: "github.com/b/uuid", want: "uuid2"},
+			{importPath: "github.com/c/uuid", want: "uuid3"},
+			{importPath: "github.com/b/uuid", want: "uuid2"},
+			{importPath: "github.com/x/bqtable", want: "bqtable2"},
+			{importPath: typeOfDate.PkgPath(), want: "civil"},
+		} {
+			if name := importName(tt.importPath, importNames); name != tt.want {
+				t.Errorf("importName: importPath=%s want=%s current=%s", tt.importPath, tt.want, name)
+			}
+		}
+		if name := importName("github.com/b/uuid", nil); name != "uuid" {
+			t.Errorf("importName: want=uuid current=%s", name)
+		}
+	})
+}
+
+func Test_generateImportPackagesCode(t *testing.T) {
+	t.Run("正常系_import_nothing", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testImportCode = ""
+		)
+		var (
+			testImportsSlice = []string{}
+		)
+
+		generatedCode := generateImportPackagesCode(testImportsSlice)
+		if generatedCode != testImportCode {
+			t.Error()
+		}
+	})
+
+	t.Run("正常系_import_time", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testImportCode = "import \"time\"\n\n"
+		)
+		var (
+			testImportsSlice = []string{"time"}
+		)
+		generatedCode := generateImportPackagesCode(testImportsSlice)
+
+		if generatedCode != testImportCode {
+			var (
+				rr      = strings.NewReplacer("\n", "\\n", "`", "\\`")
+				want    = rr.Replace(testImportCode)
+				current = rr.Replace(generatedCode)
+			)
+			t.Error("generateImportPackagesCode: want=`" + want + "` current=`" + current + "`")
+		}
+	})
+
+	t.Run("正常系_import_math/big_time", func(t *testing.T) {
+		const (
+			// 正しい出力
+			testImportCode = `import (
+	"math/big"
+	"time"
+)
+
+`
+		)
+		var (
+			testImportsSlice = []string{"math/big", "time"}
+		)
+		generatedCode := generateImportPackagesCode(testImportsSlice)
+
+		if generatedCode != testImportCode {
+			var (
+				rr      = strings.NewReplacer("\n", "\\n", "`", "\\`")
+				want    = rr.Replace(testImportCode)
+				current = rr.Replace(generatedCode)
+			)
+			t.Error("generateImportPackagesCode: want=`" + want + "` current=`" + current + "`")
+		}
+	})
+
+	t.Run("正常系_import_alias", func(t *testing.T) {
+		for importPackages, want := range map[string]string{
+			"uuid2 github.com/b/uuid":                   "import uuid2 \"github.com/b/uuid\"\n\n",
+			"github.com/a/uuid,uuid2 github.com/b/uuid": "import (\n\t\"github.com/a/uuid\"\n\tuuid2 \"github.com/b/uuid\"\n)\n\n",
+		} {
+			if generatedCode := generateImportPackagesCode(strings.Split(importPackages, ",")); generatedCode != want {
+				t.Errorf("generateImportPackagesCode: want=%q current=%q", want, generatedCode)
+			}
+		}
+	})
+}
+
+func Test_matchPartitioning(t *testing.T) {
+	unpartitioned := &bigquery.TableMetadata{}
+	ingestionTime := &bigquery.TableMetadata{TimePartitioning: &bigquery.TimePartitioning{Type: bigquery.HourPartitioningType}}
+	column := &bigquery.TableMetadata{TimePartitioning: &bigquery.TimePartitioning{Field: "created_at"}}
+	integerRange := &bigquery.TableMetadata{RangePartitioning: &bigquery.RangePartitioning{Field: "id"}}
+
+	t.Run("正常系", func(t *testing.T) {
+		for _, partitioning := range []string{"", partitioningPartitioned, partitioningUnpartitioned, partitioningIngestionTime, partitioningColumn, partitioningRange} {
+			var actual []bool
+			for _, md := range []*bigquery.TableMetadata{unpartitioned, ingestionTime, column, integerRange} {
+				actual = append(actual, matchPartitioning(md, partitioning))
+			}
+			expected := map[string][]bool{
+				"":                        {true, true, true, true},
+				partitioningPartitioned:   {false, true, true, true},
+				partitioningUnpartitioned: {true, false, false, false},
+				partitioningIngestionTime: {false, true, false, false},
+				partitioningColumn:        {false, false, true, false},
+				partitioningRange:         {false, false, false, true},
+			}[partitioning]
+			if !reflect.DeepEqual(expected, actual) {
+				t.Errorf("matchPartitioning: partitioning=%s expected(%v) != actual(%v)", partitioning, expected, actual)
+			}
+		}
+	})
+
+	t.Run("異常系_validatePartitioning", func(t *testing.T) {
+		if err := validatePartitioning("daily"); err == nil {
+			t.Errorf("validatePartitioning: expected error")
+		}
+	})
+}
+
+func Test_partitioningComment(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for _, tt := range []struct {
+			md       *bigquery.TableMetadata
+			expected string
+		}{
+			{&bigquery.TableMetadata{}, ""},
+			{&bigquery.TableMetadata{TimePartitioning: &bigquery.TimePartitioning{}}, "Partitioning: DAY by ingestion time (_PARTITIONTIME)."},
+			{
+				&bigquery.TableMetadata{TimePartitioning: &bigquery.TimePartitioning{Type: bigquery.MonthPartitioningType, Field: "created_at", Expiration: 24 * time.Hour}, RequirePartitionFilter: true},
+				"Partitioning: MONTH by column `created_at`. Partitions expire after 24h0m0s. The queries require the partition filter.",
+			},
+			{
+				&bigquery.TableMetadata{RangePartitioning: &bigquery.RangePartitioning{Field: "id", Range: &bigquery.RangePartitioningRange{Start: 0, End: 100, Interval: 10}}},
+				"Partitioning: integer range by column `id` from 0 to 100 by 10.",
+			},
+		} {
+			if actual := partitioningComment(tt.md); tt.expected != actual {
+				t.Errorf("partitioningComment: expected(%s) != actual(%s)", tt.expected, actual)
+			}
+		}
+	})
+}
+
+func Test_asOfMetadata(t *testing.T) {
+	asOf := time.Now().Add(-time.Hour)
+
+	t.Run("正常系_testPublicDataProjectID", func(t *testing.T) {
+		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
+			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
+		}
+
+		var (
+			ctx         = context.Background()
+			okClient, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+			table       = okClient.Dataset(testSupportedDatasetID).Table("comments")
+		)
+		md, err := table.Metadata(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if asOfMD := asOfMetadata(ctx, okClient, table, md, asOf); len(asOfMD.Schema) == 0 {
+			t.Error("asOfMetadata: the schema is empty")
+		}
+	})
+
+	t.Run("正常系_fall_back_View", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Type: bigquery.ViewTable, Schema: testNullableRoundTripSchema}
+		if asOfMD := asOfMetadata(context.Background(), nil, testTable, md, asOf); asOfMD != md {
+			t.Error("asOfMetadata: want the current metadata of the view")
+		}
+	})
+}
+
+func Test_mergeColumnAnnotations(t *testing.T) {
+	policyTags := &bigquery.PolicyTagList{Names: []string{testPolicyTag}}
+	current := bigquery.Schema{
+		{Name: "id", Type: bigquery.IntegerFieldType, Description: "the id", PolicyTags: policyTags},
+		{Name: "user", Type: bigquery.RecordFieldType, Description: "the user", Schema: bigquery.Schema{
+			{Name: "name", Type: bigquery.StringFieldType, Description: "the name"},
+		}},
+	}
+	asOf := bigquery.Schema{
+		{Name: "id", Type: bigquery.StringFieldType},
+		{Name: "user", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+			{Name: "name", Type: bigquery.StringFieldType},
+			{Name: "removed", Type: bigquery.StringFieldType},
+		}},
+	}
+
+	merged := mergeColumnAnnotations(asOf, current)
+	want := bigquery.Schema{
+		{Name: "id", Type: bigquery.StringFieldType, Description: "the id", PolicyTags: policyTags},
+		{Name: "user", Type: bigquery.RecordFieldType, Description: "the user", Schema: bigquery.Schema{
+			{Name: "name", Type: bigquery.StringFieldType, Description: "the name"},
+			{Name: "removed", Type: bigquery.StringFieldType},
+		}},
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("mergeColumnAnnotations: want=%+v current=%+v", want, merged)
+	}
+	if asOf[0].Description != "" || asOf[1].Schema[0].Description != "" {
+		t.Error("mergeColumnAnnotations: the schemas are modified")
+	}
+}
+
+// testGenerateTableSchemaCode generates the code of the schema struct of table by tableSchemaMetadata as generateTables.
+func testGenerateTableSchemaCode(ctx context.Context, client *bigquery.Client, table *bigquery.Table) (err error) {
+	md, err := tableSchemaMetadata(ctx, client, table, nil, Options{})
+	if err != nil {
+		return fmt.Errorf("tableSchemaMetadata: %w", err)
+	}
+	if _, _, err = generateTableMetadataCode(table, md, nil, Options{}); err != nil {
+		return fmt.Errorf("generateTableMetadataCode: %w", err)
+	}
+	return nil
+}
+
+func Test_tableSchemaMetadata(t *testing.T) {
+	t.Run("正常系_testPublicDataProjectID_testPublicDataProjectID", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+		)
+
+		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
+			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
+		}
+
+		var (
+			ngClient, _     = bigquery.NewClient(ctx, testPublicDataProjectID)
+			ngTableIterator = ngClient.Dataset(testSupportedDatasetID).Tables(ctx)
+		)
+
+		for {
+			table, err := ngTableIterator.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			if err := testGenerateTableSchemaCode(ctx, ngClient, table); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	t.Run("異常系_testProjectNotFound_testDatasetNotFound", func(t *testing.T) {
+		var (
+			ctx     = context.Background()
+			ngTable = &bigquery.Table{
+				ProjectID: testProjectNotFound,
+				DatasetID: testDatasetNotFound,
+				TableID:   testEmptyString,
+			}
+		)
+		if err := testGenerateTableSchemaCode(ctx, nil, ngTable); err == nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_testProjectNotFound_testNotSupportedDatasetID", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+		)
+
+		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
+			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
+		}
+
+		var (
+			ngClient, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+			ngTable, _  = ngClient.Dataset(testNotSupportedDatasetID).Tables(ctx).Next()
+		)
+
+		ngTable.ProjectID = testProjectNotFound
+		if err := testGenerateTableSchemaCode(ctx, nil, ngTable); err == nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_testPublicDataProjectID_testNotSupportedDatasetID_testSubStrFieldTypeNotSupported", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+		)
+
+		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
+			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
+		}
+
+		var (
+			ngClient, _     = bigquery.NewClient(ctx, testPublicDataProjectID)
+			ngTableIterator = ngClient.Dataset(testNotSupportedDatasetID).Tables(ctx)
+		)
+
+		for {
+			table, err := ngTableIterator.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			if err := testGenerateTableSchemaCode(ctx, ngClient, table); err != nil {
+				// NOTE(djeeno): "bigquery.FieldType not supported." 以外のエラーが出たら Fail
+				if !strings.Contains(err.Error(), testSubStrFieldTypeNotSupported) {
+					t.Error(err)
+				}
+				// NOTE(djeeno): ここまで来たら、確認したいことは確認済み。
+				// ref. https://github.com/djeeno/bqschema-gen-go/blob/260524ce0ae2dd5bdcbdd57446cdd8c140326ca4/main.go#L212
+				return
+			}
+		}
+	})
+}
+
+// testNullableRoundTrip is the struct that generateTableMetadataCode should generate from testNullableRoundTripSchema with nullableModeNull.
+type testNullableRoundTrip struct {
+	Required_string string              `bigquery:"required_string"`
+	Nullable_string bigquery.NullString `bigquery:"nullable_string"`
+	Nullable_int    bigquery.NullInt64  `bigquery:"nullable_int"`
+	Nullable_bytes  []byte              `bigquery:"nullable_bytes,nullable"`
+	Nullable_num    *big.Rat            `bigquery:"nullable_num,nullable"`
+}
+
+var testNullableRoundTripSchema = bigquery.Schema{
+	{Name: "required_string", Type: bigquery.StringFieldType, Required: true},
+	{Name: "nullable_string", Type: bigquery.StringFieldType},
+	{Name: "nullable_int", Type: bigquery.IntegerFieldType},
+	{Name: "nullable_bytes", Type: bigquery.BytesFieldType},
+	{Name: "nullable_num", Type: bigquery.NumericFieldType},
+}
+
+var testTable = &bigquery.Table{ProjectID: testPublicDataProjectID, DatasetID: testSupportedDatasetID, TableID: testTableID}
+
+// testNullableRecords is the struct that generateTableMetadataCode should generate from testNullableRecordsSchema with nullableRecordsPointer.
+type testNullableRecords struct {
+	Required_record testNullableRecordsRequired_record   `bigquery:"required_record"`
+	Nullable_record *testNullableRecordsNullable_record  `bigquery:"nullable_record,nullable"`
+	Repeated_record []testNullableRecordsRepeated_record `bigquery:"repeated_record"`
+}
+
+type testNullableRecordsRequired_record struct {
+	Id int64 `bigquery:"id"`
+}
+
+type testNullableRecordsNullable_record struct {
+	Id int64 `bigquery:"id"`
+}
+
+type testNullableRecordsRepeated_record struct {
+	Ids []int64 `bigquery:"ids"`
+}
+
+var testNullableRecordsSchema = bigquery.Schema{
+	{Name: "required_record", Type: bigquery.RecordFieldType, Required: true, Schema: bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType, Required: true}}},
+	{Name: "nullable_record", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType, Required: true}}},
+	{Name: "repeated_record", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{{Name: "ids", Type: bigquery.IntegerFieldType, Repeated: true}}},
+}
+
+// testTypeCheck type-checks code, the generated file, by the export data of its imports that `go list -export` builds,
+// so that the generated methods, e.g. FromRow, are checked as the compiler does without the module of the generated package.
+func testTypeCheck(t *testing.T, code []byte) {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, defaultValueOutputFile, code, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"list", "-export", "-deps", "-f", "{{.ImportPath}}={{.Export}}"}
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		args = append(args, importPath)
+	}
+	exports := make(map[string]string)
+	if len(file.Imports) > 0 {
+		out, err := exec.Command("go", args...).Output()
+		if err != nil {
+			t.Fatalf("go list: %v", err)
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			if i := strings.Index(line, "="); i > 0 {
+				exports[line[:i]] = line[i+1:]
+			}
+		}
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "gc", func(importPath string) (io.ReadCloser, error) {
+		return os.Open(exports[importPath])
+	})}
+	if _, err := conf.Check(file.Name.Name, fset, []*ast.File{file}, nil); err != nil {
+		t.Error("types.Config.Check: " + err.Error() + ": " + string(code))
+	}
+}
+
+// testTableDataClient returns the client of the fake BigQuery API that has the table testTableID of schema and rows,
+// the JSON array of the rows of tabledata.list, so that the tests load the rows by bigquery.RowIterator without BigQuery.
+// ref. https://cloud.google.com/bigquery/docs/reference/rest/v2/tabledata/list
+func testTableDataClient(t *testing.T, schema bigquery.Schema, rows string) *bigquery.Client {
+	t.Helper()
+	fields, err := json.Marshal(schemaJSONFields(schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tablePath := "/projects/" + testPublicDataProjectID + "/datasets/" + testSupportedDatasetID + "/tables/" + testTableID
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case path.Dir(tablePath):
+			_, _ = io.WriteString(w, `{"tables": [{"tableReference": {"projectId": "`+testPublicDataProjectID+`", "datasetId": "`+testSupportedDatasetID+`", "tableId": "`+testTableID+`"}}]}`)
+		case tablePath:
+			_, _ = io.WriteString(w, `{"schema": {"fields": `+string(fields)+`}}`)
+		case tablePath + "/data":
+			_, _ = io.WriteString(w, `{"rows": `+rows+`}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := bigquery.NewClient(context.Background(), testPublicDataProjectID, option.WithEndpoint(server.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func Test_generateTableMetadataCode(t *testing.T) {
+	t.Run("正常系_nullableModeNone", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: testNullableRoundTripSchema}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{Nullable: nullableModeNone})
+		if err != nil {
+			t.Error(err)
+		}
+		if strings.Contains(generatedCode, ","+tagOptionNullable) {
+			t.Error("generateTableMetadataCode: unexpected tag option: " + generatedCode)
+		}
+	})
+
+	t.Run("正常系_nullableModeNull_round_trip", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: testNullableRoundTripSchema}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, Options{Nullable: nullableModeNull})
+		if err != nil {
+			t.Error(err)
+		}
+
+		// NOTE(djeeno): the generated fields must be the same as testNullableRoundTrip.
+		rt := reflect.TypeOf(testNullableRoundTrip{})
+		for i := 0; i < rt.NumField(); i++ {
+			f := rt.Field(i)
+			want := f.Name + " " + f.Type.String() + " `bigquery:\"" + f.Tag.Get("bigquery") + "\"`"
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+		if !reflect.DeepEqual(pkgs, []string{typeOfNullString.PkgPath(), typeOfNullInt64.PkgPath(), "math/big"}) {
+			t.Errorf("generateTableMetadataCode: unexpected packages: %v", pkgs)
+		}
+
+		// NOTE(djeeno): the client infers the same schema from the generated struct.
+		inferred, err := bigquery.InferSchema(testNullableRoundTrip{})
+		if err != nil {
+			t.Error(err)
+		}
+		for i, f := range inferred {
+			if f.Name != testNullableRoundTripSchema[i].Name || f.Required != testNullableRoundTripSchema[i].Required {
+				t.Errorf("bigquery.InferSchema: want=%#v current=%#v", testNullableRoundTripSchema[i], f)
+			}
+		}
+
+		// NOTE(djeeno): the client saves the generated struct with the original schema.
+		nullString := bigquery.NullString{StringVal: testCapitalized, Valid: true}
+		saver := &bigquery.StructSaver{
+			Schema: testNullableRoundTripSchema,
+			Struct: &testNullableRoundTrip{Nullable_string: nullString},
+		}
+		row, _, err := saver.Save()
+		if err != nil {
+			t.Error(err)
+		}
+		if row["nullable_string"] != nullString || row["nullable_int"] != (bigquery.NullInt64{}) {
+			t.Errorf("saver.Save: unexpected row: %#v", row)
+		}
+
+		// NOTE(djeeno): the client loads the rows of NULL and not NULL into the generated struct.
+		client := testTableDataClient(t, testNullableRoundTripSchema,
+			`[{"f": [{"v": "a"}, {"v": "b"}, {"v": "1"}, {"v": "Yw=="}, {"v": "0.5"}]}, {"f": [{"v": "a"}, {"v": null}, {"v": null}, {"v": null}, {"v": null}]}]`)
+		rows := client.Dataset(testSupportedDatasetID).Table(testTableID).Read(context.Background())
+		var notNull, null testNullableRoundTrip
+		if err := rows.Next(&notNull); err != nil {
+			t.Fatal(err)
+		}
+		if !notNull.Nullable_string.Valid || notNull.Nullable_string.StringVal != "b" || notNull.Nullable_int.Int64 != 1 || string(notNull.Nullable_bytes) != "c" || notNull.Nullable_num.Cmp(big.NewRat(1, 2)) != 0 {
+			t.Errorf("rows.Next: unexpected row: %#v", notNull)
+		}
+		if err := rows.Next(&null); err != nil {
+			t.Fatal(err)
+		}
+		if null.Nullable_string.Valid || null.Nullable_int.Valid || null.Nullable_bytes != nil || null.Nullable_num != nil {
+			t.Errorf("rows.Next: unexpected row of NULL: %#v", null)
+		}
+	})
+
+	t.Run("正常系_nullableRecordsValue", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: testNullableRecordsSchema}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{NullableRecords: nullableRecordsValue})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"Nullable_record Test_tableNullable_record `bigquery:\"nullable_record\"`",
+			"\n// Test_tableNullable_record is RECORD column `nullable_record` schema struct.\ntype Test_tableNullable_record struct {\n\tId int64 `bigquery:\"id\"`\n}\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_nullableRecordsPointer_round_trip", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: testNullableRecordsSchema}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{NullableRecords: nullableRecordsPointer})
+		if err != nil {
+			t.Error(err)
+		}
+
+		// NOTE(djeeno): the generated fields must be the same as testNullableRecords, except the struct name prefix.
+		rr := strings.NewReplacer("main.testNullableRecords", "Test_table")
+		rt := reflect.TypeOf(testNullableRecords{})
+		for i := 0; i < rt.NumField(); i++ {
+			f := rt.Field(i)
+			want := f.Name + " " + rr.Replace(f.Type.String()) + " `bigquery:\"" + f.Tag.Get("bigquery") + "\"`"
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+		if !strings.Contains(generatedCode, "Ids []int64 `bigquery:\"ids\"`") {
+			t.Error("generateTableMetadataCode: current=`" + generatedCode + "`")
+		}
+
+		// NOTE(djeeno): the client infers the same schema from the generated struct.
+		inferred, err := bigquery.InferSchema(testNullableRecords{})
+		if err != nil {
+			t.Error(err)
+		}
+		for i, f := range inferred {
+			want := testNullableRecordsSchema[i]
+			if f.Name != want.Name || f.Type != want.Type || f.Required != want.Required || f.Repeated != want.Repeated {
+				t.Errorf("bigquery.InferSchema: want=%#v current=%#v", want, f)
+			}
+		}
+
+		// NOTE(djeeno): the client saves nil of the NULL record as NULL.
+		saver := &bigquery.StructSaver{Schema: testNullableRecordsSchema, Struct: &testNullableRecords{}}
+		row, _, err := saver.Save()
+		if err != nil {
+			t.Error(err)
+		}
+		if row["nullable_record"] != nil {
+			t.Errorf("saver.Save: unexpected row: %#v", row)
+		}
+
+		// NOTE(djeeno): the client loads NULL of the record as nil, and the record as the pointer.
+		client := testTableDataClient(t, testNullableRecordsSchema,
+			`[{"f": [{"v": {"f": [{"v": "1"}]}}, {"v": {"f": [{"v": "2"}]}}, {"v": []}]}, {"f": [{"v": {"f": [{"v": "1"}]}}, {"v": null}, {"v": []}]}]`)
+		rows := client.Dataset(testSupportedDatasetID).Table(testTableID).Read(context.Background())
+		var notNull, null testNullableRecords
+		if err := rows.Next(&notNull); err != nil {
+			t.Fatal(err)
+		}
+		if notNull.Nullable_record == nil || notNull.Nullable_record.Id != 2 {
+			t.Errorf("rows.Next: unexpected row: %#v", notNull)
+		}
+		if err := rows.Next(&null); err != nil {
+			t.Fatal(err)
+		}
+		if null.Nullable_record != nil {
+			t.Errorf("rows.Next: unexpected row of NULL: %#v", null)
+		}
+	})
+
+	t.Run("正常系_ProjectPrefix", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType, Required: true}}}
+		// NOTE(djeeno): "-" of the project ID cannot be in the struct name.
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{DatasetPrefix: true, ProjectPrefix: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if want := "type Bigquery_public_data_Hacker_news_Test_table struct {"; !strings.Contains(generatedCode, want) {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_DatasetPrefix", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: testNullableRecordsSchema}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{DatasetPrefix: true})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"type Hacker_news_Test_table struct {",
+			"type Hacker_news_Test_tableNullable_record struct {",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_DeprecatedMarker", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "old_id", Type: bigquery.IntegerFieldType, Description: "[DEPRECATED] use\nnew_id"},
+			{Name: "new_id", Type: bigquery.IntegerFieldType, Description: "ID"},
+		}}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{DeprecatedMarker: "[DEPRECATED]"})
+		if err != nil {
+			t.Error(err)
+		}
+		want := "\t// Deprecated: use new_id\n\tOld_id int64 `bigquery:\"old_id\"`\n\tNew_id int64 `bigquery:\"new_id\"`\n"
+		if !strings.Contains(generatedCode, want) {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_NoDescription", func(t *testing.T) {
+		md := &bigquery.TableMetadata{FullID: testTableFullID, Description: "auto-generated\nblob", Schema: bigquery.Schema{
+			{Name: "id", Type: bigquery.IntegerFieldType, Description: "ID"},
+		}}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{NoDescription: true})
+		if err != nil {
+			t.Error(err)
+		}
+		want := "// Test_table is BigQuery Table `" + testTableFullID + "` schema struct.\ntype Test_table struct {\n"
+		if !strings.Contains(generatedCode, want) || strings.Contains(generatedCode, "Description:") {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+
+		structTemplate := template.Must(template.New("test").Parse("{{range .Comments}}{{.}}\n{{end}}{{range .Fields}}{{printf \"%q\" .Description}}\n{{end}}"))
+		generatedCode, _, err = generateTableMetadataCode(testTable, md, nil, Options{NoDescription: true, Template: structTemplate})
+		if err != nil {
+			t.Error(err)
+		}
+		if want := "Test_table is BigQuery Table `" + testTableFullID + "` schema struct.\n\"\"\n"; generatedCode != want {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_LossyTypes", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "location", Type: bigquery.GeographyFieldType, Required: true},
+			{Name: "area", Type: bigquery.GeographyFieldType},
+			{Name: "boundary", Type: bigquery.GeographyFieldType, Required: true},
+			{Name: "name", Type: bigquery.StringFieldType, Required: true},
+		}}
+		opts := Options{
+			Nullable:   nullableModeNull,
+			LossyTypes: []bigquery.FieldType{bigquery.GeographyFieldType},
+			TypeMap:    map[string]string{testTableID + ".boundary": "example.com/geo.Polygon"},
+		}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, opts)
+		if err != nil {
+			t.Error(err)
+		}
+		want := "\t// NOTE: GEOGRAPHY stored as string\n\tLocation string `bigquery:\"location\"`\n" +
+			"\t// NOTE: GEOGRAPHY stored as bigquery.NullGeography\n\tArea bigquery.NullGeography `bigquery:\"area\"`\n" +
+			"\tBoundary geo.Polygon `bigquery:\"boundary\"`\n" +
+			"\tName string `bigquery:\"name\"`\n"
+		if !strings.Contains(generatedCode, want) {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_HelperInterfaces", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+			{Name: "user", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+				{Name: "name", Type: bigquery.StringFieldType},
+			}},
+		}}
+		for helperImport, want := range map[string]string{
+			testEmptyString:                  defaultValueHelperImport,
+			"example.com/fork/go-bqtable.v2": "example.com/fork/go-bqtable.v2",
+		} {
+			generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, Options{HelperInterfaces: true, HelperImport: helperImport})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(pkgs, []string{want}) {
+				t.Errorf("generateTableMetadataCode: unexpected packages: %v", pkgs)
+			}
+			// NOTE(djeeno): The nested structs are not the tables.
+			if strings.Count(generatedCode, "var _ bqtable.Table = Test_table{}\n") != 1 || strings.Count(generatedCode, "bqtable.Table") != 1 {
+				t.Error("generateTableMetadataCode: current=`" + generatedCode + "`")
+			}
+		}
+
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, Options{HelperImport: defaultValueHelperImport, Getters: true, FromRow: true, Stringer: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pkg := range pkgs {
+			if pkg == defaultValueHelperImport {
+				t.Errorf("generateTableMetadataCode: unexpected packages: %v", pkgs)
+			}
+		}
+		if strings.Contains(generatedCode, "bqtable.") {
+			t.Error("generateTableMetadataCode: current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_ExcludeColumns", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+			{Name: "payload", Type: bigquery.StringFieldType},
+			{Name: "day", Type: bigquery.DateFieldType},
+			{Name: "user", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+				{Name: "name", Type: bigquery.StringFieldType},
+				{Name: "raw_profile", Type: bigquery.StringFieldType},
+			}},
+		}}
+		opts := Options{ExcludeColumns: []string{testTable.TableID + ".payload", "*.day", "*.user.raw_*"}}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, notWant := range []string{"Payload", "Day", "Raw_profile"} {
+			if strings.Contains(generatedCode, "\t"+notWant+" ") {
+				t.Error("generateTableMetadataCode: the excluded column is generated: " + notWant + " current=`" + generatedCode + "`")
+			}
+		}
+		if want := "// Excluded columns: payload, day, user.raw_profile\n"; !strings.Contains(generatedCode, want) {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+		// NOTE(djeeno): civil is imported for the excluded DATE column if the imports are not recomputed.
+		if len(pkgs) != 0 {
+			t.Errorf("generateTableMetadataCode: unexpected packages: %v", pkgs)
+		}
+	})
+
+	t.Run("正常系_ClusteringComment", func(t *testing.T) {
+		md := &bigquery.TableMetadata{
+			Schema:           bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType, Required: true}},
+			TimePartitioning: &bigquery.TimePartitioning{Type: bigquery.DayPartitioningType, Field: "created_at"},
+			Clustering:       &bigquery.Clustering{Fields: []string{"customer_id", "id"}},
+		}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{PartitioningComment: true, ClusteringComment: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "// Partitioning: DAY by column `created_at`.\n// Clustered by: customer_id, id\n"; !strings.Contains(generatedCode, want) {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+
+		generatedCode, _, err = generateTableMetadataCode(testTable, md, nil, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(generatedCode, "Clustered by") || strings.Contains(generatedCode, "Partitioning:") {
+			t.Error("generateTableMetadataCode: current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_MaterializedView", func(t *testing.T) {
+		// NOTE(djeeno): The schema of the materialized view is of the result of the query, that may have the RECORD columns of the aggregation.
+		md := &bigquery.TableMetadata{
+			Type:             bigquery.MaterializedView,
+			FullID:           testPublicDataProjectID + ":" + testSupportedDatasetID + ".test_mv",
+			MaterializedView: &bigquery.MaterializedViewDefinition{Query: "SELECT customer_id, ARRAY_AGG(STRUCT(id, amount)) AS orders FROM orders GROUP BY customer_id"},
+			Schema: bigquery.Schema{
+				{Name: "customer_id", Type: bigquery.IntegerFieldType},
+				{Name: "orders", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+					{Name: "id", Type: bigquery.IntegerFieldType},
+					{Name: "amount", Type: bigquery.NumericFieldType},
+				}},
+			},
+		}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{TableTypeComment: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{"// Materialized view\n", "\tOrders []Test_tableOrders ", "\tAmount *big.Rat "} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_FieldsComment", func(t *testing.T) {
+		newMetadata := func(nameType bigquery.FieldType) *bigquery.TableMetadata {
+			return &bigquery.TableMetadata{Schema: bigquery.Schema{
+				{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+				{Name: "user", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+					{Name: "name", Type: nameType},
+				}},
+			}}
+		}
+		fieldsComment := regexp.MustCompile(`// fields: (\d+), schema: ([0-9a-f]{8})\n`)
+
+		generatedCode, _, err := generateTableMetadataCode(testTable, newMetadata(bigquery.StringFieldType), nil, Options{FieldsComment: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		comments := fieldsComment.FindAllStringSubmatch(generatedCode, -1)
+		if len(comments) != 2 || comments[0][1] != "2" || comments[1][1] != "1" {
+			t.Fatal("generateTableMetadataCode: current=`" + generatedCode + "`")
+		}
+
+		// NOTE(djeeno): The hashes of the struct of the changed column and of the table struct that has it change.
+		changedCode, _, err := generateTableMetadataCode(testTable, newMetadata(bigquery.BytesFieldType), nil, Options{FieldsComment: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		changed := fieldsComment.FindAllStringSubmatch(changedCode, -1)
+		if len(changed) != 2 || changed[0][2] == comments[0][2] || changed[1][2] == comments[1][2] {
+			t.Errorf("generateTableMetadataCode: before=%v after=%v", comments, changed)
+		}
+	})
+
+	t.Run("正常系_IdentityMethods", func(t *testing.T) {
+		md := &bigquery.TableMetadata{FullID: testPublicDataProjectID + ":" + testSupportedDatasetID + ".test_table", Schema: bigquery.Schema{
+			{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+		}}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, Options{IdentityMethods: true, HelperInterfaces: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"func (Test_table) ProjectID() string {\n\treturn \"" + testTable.ProjectID + "\"\n}\n",
+			"func (Test_table) DatasetID() string {\n\treturn \"" + testTable.DatasetID + "\"\n}\n",
+			"func (Test_table) FullID() string {\n\treturn \"" + md.FullID + "\"\n}\n",
+			"var _ bqtable.Identity = Test_table{}\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+		if _, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, generatedCode, Options{}); err != nil {
+			t.Error(err)
+		}
+
+		generatedCode, _, err = generateTableMetadataCode(testTable, &bigquery.TableMetadata{Schema: md.Schema}, nil, Options{IdentityMethods: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "\treturn \"" + testTable.ProjectID + ":" + testTable.DatasetID + "." + testTable.TableID + "\"\n"; !strings.Contains(generatedCode, want) {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("異常系_IdentityMethods_conflict", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "FullID", Type: bigquery.StringFieldType},
+		}}
+		if _, _, err := generateTableMetadataCode(testTable, md, nil, Options{IdentityMethods: true}); err == nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("正常系_QueryMethod", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+			{Name: "payload", Type: bigquery.StringFieldType},
+		}}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, Options{QueryMethod: true, ExcludeColumns: []string{"*.payload"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "func (Test_table) Query(client *bigquery.Client) *bigquery.Query {\n" +
+			"\treturn client.Query(\"SELECT `id` FROM `" + testTable.ProjectID + "." + testTable.DatasetID + "." + testTable.TableID + "`\")\n}\n"
+		if !strings.Contains(generatedCode, want) {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+		generatedFile, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, generatedCode, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(generatedFile), "\"cloud.google.com/go/bigquery\"") {
+			t.Error("generateFileCode: current=`" + string(generatedFile) + "`")
+		}
+	})
+
+	t.Run("正常系_ColumnsVar", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "user_id", Type: bigquery.IntegerFieldType, Required: true},
+			{Name: "User_id", Type: bigquery.StringFieldType},
+			{Name: "123", Type: bigquery.StringFieldType},
+		}}
+		opts := Options{ColumnsVar: true}
+		gen := &generation{typeNames: map[string]bool{"Test_table": true, "Test_tableCol": true}, importNames: reserveImportNames(opts)}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, gen, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// NOTE(djeeno): The field names are deduplicated and sanitized as the fields of the struct, and the variable name is unique in the file.
+		want := "var Test_tableCol_2 = struct {\n\tUser_id string\n\tUser_id_2 string\n\tX123 string\n}{\n" +
+			"\tUser_id: \"user_id\",\n\tUser_id_2: \"User_id\",\n\tX123: \"123\",\n}\n"
+		if !strings.Contains(generatedCode, want) {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_report", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+			{Name: "payload", Type: bigquery.StringFieldType},
+			{Name: "area", Type: bigquery.GeographyFieldType},
+			{Name: "user", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+				{Name: "name", Type: bigquery.StringFieldType},
+			}},
+		}}
+		opts := Options{
+			TypeMap:        map[string]string{testTable.TableID + ".id": "github.com/google/uuid.UUID"},
+			LossyTypes:     []bigquery.FieldType{bigquery.GeographyFieldType},
+			ExcludeColumns: []string{"*.payload"},
+			report:         &mappingReport{},
+		}
+		if _, _, err := generateTableMetadataCode(testTable, md, nil, opts); err != nil {
+			t.Fatal(err)
+		}
+		// NOTE(djeeno): The columns of the nested struct follow the RECORD column.
+		tableName := queryTableName(testTable)
+		want := []mappedColumn{
+			{table: tableName, column: "id", structName: "Test_table", field: "Id", goType: "uuid.UUID", note: "overridden"},
+			{table: tableName, column: "area", structName: "Test_table", field: "Area", goType: "string", note: "lossy: GEOGRAPHY stored as string"},
+			{table: tableName, column: "user", structName: "Test_table", field: "User", goType: "Test_tableUser", note: "nested struct"},
+			{table: tableName, column: "user.name", structName: "Test_tableUser", field: "Name", goType: "string"},
+		}
+		if !reflect.DeepEqual(opts.report.columns, want) {
+			t.Errorf("generateTableMetadataCode: want=%+v current=%+v", want, opts.report.columns)
+		}
+		if want := []string{tableName + ".payload"}; !reflect.DeepEqual(opts.report.excluded, want) {
+			t.Errorf("generateTableMetadataCode: want=%v current=%v", want, opts.report.excluded)
+		}
+	})
+
+	t.Run("異常系_report_conflict", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "Query", Type: bigquery.StringFieldType},
+		}}
+		opts := Options{QueryMethod: true, report: &mappingReport{}}
+		if _, _, err := generateTableMetadataCode(testTable, md, nil, opts); err == nil {
+			t.Error("generateTableMetadataCode: expected error")
+		}
+		if len(opts.report.columns) != 0 {
+			t.Errorf("generateTableMetadataCode: the columns of the skipped table are reported: %+v", opts.report.columns)
+		}
+	})
+
+	t.Run("異常系_QueryMethod_conflict", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "Query", Type: bigquery.StringFieldType},
+		}}
+		if _, _, err := generateTableMetadataCode(testTable, md, nil, Options{QueryMethod: true}); err == nil {
+			t.Error("generateTableMetadataCode: expected error")
+		}
+	})
+
+	t.Run("正常系_BigQueryNameMethod", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "user_id", Type: bigquery.IntegerFieldType, Required: true},
+			{Name: "record", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{{Name: "nested_column", Type: bigquery.StringFieldType}}},
+		}}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, newGeneration([]*bigquery.Table{testTable}, Options{}), Options{BigQueryNameMethod: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"func (Test_table) BigQueryName(field string) string {\n\tswitch field {\n\tcase \"User_id\":\n\t\treturn \"user_id\"\n\tcase \"Record\":\n\t\treturn \"record\"\n\t}\n\treturn \"\"\n}\n",
+			"func (Test_tableRecord) BigQueryName(field string) string {\n\tswitch field {\n\tcase \"Nested_column\":\n\t\treturn \"nested_column\"\n\t}\n\treturn \"\"\n}\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+
+		// NOTE(djeeno): The struct without fields has no switch.
+		generatedCode, _, err = generateTableMetadataCode(testTable, &bigquery.TableMetadata{}, nil, Options{BigQueryNameMethod: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "func (Test_table) BigQueryName(field string) string {\n\treturn \"\"\n}\n"; !strings.Contains(generatedCode, want) {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("異常系_BigQueryNameMethod_conflict", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "BigQueryName", Type: bigquery.StringFieldType},
+		}}
+		if _, _, err := generateTableMetadataCode(testTable, md, nil, Options{BigQueryNameMethod: true}); err == nil {
+			t.Error("generateTableMetadataCode: expected error")
+		}
+	})
+
+	t.Run("正常系_TypeMap_import_alias", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "a", Type: bigquery.StringFieldType, Required: true},
+			{Name: "b", Type: bigquery.StringFieldType, Required: true},
+			{Name: "c", Type: bigquery.StringFieldType, Repeated: true},
+			{Name: "d", Type: bigquery.DateFieldType, Required: true},
+			{Name: "e", Type: bigquery.DateFieldType, Required: true},
+		}}
+		opts := Options{TypeMap: map[string]string{
+			"test_table.a": "github.com/a/uuid.UUID",
+			"test_table.b": "*github.com/b/uuid.UUID",
+			"test_table.c": "github.com/b/uuid.UUID",
+			// NOTE: the package of the same name as the default package is aliased, even if the default package is used after it.
+			"test_table.d": "github.com/x/civil.Date",
+		}}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"A uuid.UUID `bigquery:\"a\"`",
+			"B *uuid2.UUID `bigquery:\"b\"`",
+			"C []uuid2.UUID `bigquery:\"c\"`",
+			"D civil2.Date `bigquery:\"d\"`",
+			"E civil.Date `bigquery:\"e\"`",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+		if want := []string{"github.com/a/uuid", "uuid2 github.com/b/uuid", "uuid2 github.com/b/uuid", "civil2 github.com/x/civil", typeOfDate.PkgPath()}; !reflect.DeepEqual(pkgs, want) {
+			t.Errorf("generateTableMetadataCode: want=%v current=%v", want, pkgs)
+		}
+
+		// NOTE(djeeno): The aliases are the same for the same order of the columns.
+		generatedCodeAgain, _, err := generateTableMetadataCode(testTable, md, nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if generatedCode != generatedCodeAgain {
+			t.Error("generateTableMetadataCode: the aliases are not deterministic: `" + generatedCodeAgain + "`")
+		}
+
+		generatedFileCode, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, generatedCode, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{"\t\"github.com/a/uuid\"\n", "\tuuid2 \"github.com/b/uuid\"\n", "\tcivil2 \"github.com/x/civil\"\n", "\t\"cloud.google.com/go/civil\"\n"} {
+			if !strings.Contains(string(generatedFileCode), want) {
+				t.Error("generateFileCode: want=`" + want + "` current=`" + string(generatedFileCode) + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_RequiredOnly_omit_civil_import", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+			{Name: "created_at", Type: bigquery.DateTimeFieldType},
+		}}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, Options{RequiredOnly: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if len(pkgs) != 0 || strings.Contains(generatedCode, "Created_at") {
+			t.Errorf("generateTableMetadataCode: packages=%v current=`%s`", pkgs, generatedCode)
+		}
+
+		fileCode, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, generatedCode, Options{})
+		if err != nil {
+			t.Error(err)
+		}
+		if strings.Contains(string(fileCode), typeOfDateTime.PkgPath()) {
+			t.Error("generateFileCode: current=`" + string(fileCode) + "`")
+		}
+	})
+
+	t.Run("正常系_ConsoleURL", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: testNullableRoundTripSchema}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{ConsoleURL: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(generatedCode, "// Console: "+testConsoleURL+"\n") {
+			t.Error("generateTableMetadataCode: current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_case_sensitive_column_names", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "UserName", Type: bigquery.StringFieldType},
+			{Name: "userName2", Type: bigquery.StringFieldType},
+			{Name: "ID", Type: bigquery.IntegerFieldType},
+			{Name: "id", Type: bigquery.IntegerFieldType},
+			{Name: "Id", Type: bigquery.IntegerFieldType},
+			{Name: "_id", Type: bigquery.IntegerFieldType},
+		}}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"UserName string `bigquery:\"UserName\"`",
+			"UserName2 string `bigquery:\"userName2\"`",
+			"ID int64 `bigquery:\"ID\"`",
+			"Id int64 `bigquery:\"id\"`",
+			"Id_2 int64 `bigquery:\"Id\"`",
+			"X_id int64 `bigquery:\"_id\"`",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_NameMapper", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: testNullableRoundTripSchema}
+		nameMapper := func(tableID, columnName string) string {
+			if columnName == "" {
+				return "Table" + DefaultNameMapper(tableID, columnName)
+			}
+			return "Column" + DefaultNameMapper(tableID, columnName)
+		}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{NameMapper: nameMapper})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"type TableTest_table struct {",
+			"ColumnRequired_string string `bigquery:\"required_string\"`",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("異常系_NameMapper_struct_name_not_exported", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: testNullableRoundTripSchema}
+		nameMapper := func(tableID, columnName string) string {
+			if columnName == "" {
+				return tableID
+			}
+			return DefaultNameMapper(tableID, columnName)
+		}
+		if _, _, err := generateTableMetadataCode(testTable, md, nil, Options{NameMapper: nameMapper}); err == nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_NameMapper_field_name_not_identifier", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: testNullableRoundTripSchema}
+		nameMapper := func(tableID, columnName string) string {
+			if columnName == "" {
+				return DefaultNameMapper(tableID, columnName)
+			}
+			return "Invalid-" + columnName
+		}
+		if _, _, err := generateTableMetadataCode(testTable, md, nil, Options{NameMapper: nameMapper}); err == nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("正常系_TypeMap_TIMESTAMP_to_civil.DateTime", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "created_at", Type: bigquery.TimestampFieldType},
+			{Name: "updated_at", Type: bigquery.TimestampFieldType},
+		}}
+		opts := Options{TypeMap: map[string]string{testTableID + ".created_at": typeOfDateTime.PkgPath() + ".DateTime"}}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, opts)
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"Created_at civil.DateTime `bigquery:\"created_at\"`",
+			"Updated_at time.Time `bigquery:\"updated_at\"`",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+		if !reflect.DeepEqual(pkgs, []string{typeOfDateTime.PkgPath(), typeOfGoTime.PkgPath()}) {
+			t.Errorf("generateTableMetadataCode: unexpected packages: %v", pkgs)
+		}
+	})
+
+	t.Run("正常系_FromRow", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: append(append(bigquery.Schema{}, testNullableRoundTripSchema...), testNullableRecordsSchema...)}
+		opts := Options{Nullable: nullableModeNull, NullableRecords: nullableRecordsPointer, FromRow: true}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, opts)
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"func (s *Test_table) FromRow(row map[string]bigquery.Value) error {",
+			"func (s *Test_tableRepeated_record) FromRow(row map[string]bigquery.Value) error {",
+			"s.Nullable_int = bigquery.NullInt64{Int64: x, Valid: true}",
+			"s.Nullable_record = &Test_tableNullable_record{}",
+			"if err := s.Repeated_record[i].FromRow(x); err != nil {",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+		code, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, generatedCode, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testTypeCheck(t, code)
+	})
+
+	t.Run("正常系_NullableTemporal_pointer", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "nullable_timestamp", Type: bigquery.TimestampFieldType},
+			{Name: "required_timestamp", Type: bigquery.TimestampFieldType, Required: true},
+			{Name: "repeated_timestamp", Type: bigquery.TimestampFieldType, Repeated: true},
+			{Name: "nullable_datetime", Type: bigquery.DateTimeFieldType},
+			{Name: "required_datetime", Type: bigquery.DateTimeFieldType, Required: true},
+			{Name: "nullable_date", Type: bigquery.DateFieldType},
+			{Name: "nullable_time", Type: bigquery.TimeFieldType},
+			{Name: "nullable_int", Type: bigquery.IntegerFieldType},
+		}}
+		opts := Options{Nullable: nullableModeNull, NullableTemporal: nullableTemporalPointer, FromRow: true}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"Nullable_timestamp *time.Time `bigquery:\"nullable_timestamp\"`",
+			"Required_timestamp time.Time `bigquery:\"required_timestamp\"`",
+			"Repeated_timestamp []time.Time `bigquery:\"repeated_timestamp\"`",
+			"Nullable_datetime *civil.DateTime `bigquery:\"nullable_datetime\"`",
+			"Required_datetime civil.DateTime `bigquery:\"required_datetime\"`",
+			"Nullable_date *civil.Date `bigquery:\"nullable_date\"`",
+			"Nullable_time *civil.Time `bigquery:\"nullable_time\"`",
+			// NOTE: the other types are of Nullable
+			"Nullable_int bigquery.NullInt64 `bigquery:\"nullable_int\"`",
+			"\t\tx, ok := v.(time.Time)\n",
+			"s.Nullable_timestamp = &x\n",
+			"s.Required_timestamp = x\n",
+			"s.Nullable_date = &x\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+		code, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, generatedCode, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testTypeCheck(t, code)
+	})
+
+	t.Run("正常系_FlattenRecords", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: append(bigquery.Schema{
+			{Name: "values", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{{Name: "value", Type: bigquery.IntegerFieldType}}},
+		}, testNullableRecordsSchema...)}
+		opts := Options{Nullable: nullableModeNull, FromRow: true, FlattenRecords: true}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"// NOTE: flattened from the field value of the REPEATED RECORD column. Load it by FromRow\n",
+			"Values []bigquery.NullInt64 `bigquery:\"values\"`",
+			"\t\t\tif v, ok := x[\"value\"]; ok && v != nil {\n",
+			"s.Values[i] = bigquery.NullInt64{Int64: x, Valid: true}",
+			// NOTE: the records that have multiple fields are not flattened
+			"Repeated_record []Test_tableRepeated_record `bigquery:\"repeated_record\"`",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+		if strings.Contains(generatedCode, "type Test_tableValues struct") {
+			t.Error("generateTableMetadataCode: flattened record struct is generated: " + generatedCode)
+		}
+		code, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, generatedCode, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testTypeCheck(t, code)
+
+		// NOTE: opt-in
+		generatedCode, _, err = generateTableMetadataCode(testTable, md, nil, Options{Nullable: nullableModeNull, FromRow: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(generatedCode, "Values []Test_tableValues `bigquery:\"values\"`") {
+			t.Error("generateTableMetadataCode: flattened without FlattenRecords: " + generatedCode)
+		}
+	})
+
+	t.Run("正常系_TypeMap_INTEGER_to_int", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+			{Name: "ids", Type: bigquery.IntegerFieldType, Repeated: true},
+			{Name: "parent", Type: bigquery.IntegerFieldType},
+			{Name: "count", Type: bigquery.IntegerFieldType, Required: true},
+		}}
+		opts := Options{Nullable: nullableModeNull, TypeMap: map[string]string{"INTEGER": "int", testTableID + ".count": "int32"}}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, opts)
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"Id int `bigquery:\"id\"`",
+			"Ids []int `bigquery:\"ids\"`",
+			"Parent bigquery.NullInt64 `bigquery:\"parent\"`",
+			"Count int32 `bigquery:\"count\"`",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_TypeMap_FLOAT_to_float32", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "score", Type: bigquery.FloatFieldType, Required: true},
+			{Name: "scores", Type: bigquery.FloatFieldType, Repeated: true},
+		}}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, Options{TypeMap: map[string]string{string(bigquery.FloatFieldType): "float32"}})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"Score float32 `bigquery:\"score\"`",
+			"Scores []float32 `bigquery:\"scores\"`",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+		if len(pkgs) != 0 {
+			t.Errorf("generateTableMetadataCode: unexpected packages: %v", pkgs)
+		}
+		// NOTE(djeeno): The client infers FLOAT from float32, so that the struct is loadable from and savable to the table.
+		schema, err := bigquery.InferSchema(struct {
+			Score  float32   `bigquery:"score"`
+			Scores []float32 `bigquery:"scores"`
+		}{})
+		if err != nil {
+			t.Error(err)
+		}
+		if schema[0].Type != bigquery.FloatFieldType || schema[1].Type != bigquery.FloatFieldType {
+			t.Errorf("bigquery.InferSchema: want=%s current=%s,%s", bigquery.FloatFieldType, schema[0].Type, schema[1].Type)
+		}
+	})
+
+	t.Run("正常系_TypeMap_GEOGRAPHY_import", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "location", Type: bigquery.GeographyFieldType, Required: true},
+			{Name: "path", Type: bigquery.GeographyFieldType, Repeated: true},
+		}}
+		opts := Options{TypeMap: map[string]string{string(bigquery.GeographyFieldType): "github.com/twpayne/go-geom.T"}}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// NOTE(djeeno): The default type of GEOGRAPHY, string, has no import, so that the import is of the override only.
+		if !reflect.DeepEqual(pkgs, []string{"github.com/twpayne/go-geom", "github.com/twpayne/go-geom"}) {
+			t.Errorf("generateTableMetadataCode: unexpected packages: %v", pkgs)
+		}
+		code, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, generatedCode, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"import \"github.com/twpayne/go-geom\"\n",
+			"\tLocation geom.T   `bigquery:\"location\"`\n",
+			"\tPath     []geom.T `bigquery:\"path\"`\n",
+		} {
+			if !strings.Contains(string(code), want) {
+				t.Error("generateFileCode: want=`" + want + "` current=`" + string(code) + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_TypeResolver_fallback", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "created_at", Type: bigquery.TimestampFieldType},
+			{Name: "updated_at", Type: bigquery.TimestampFieldType},
+			{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+		}}
+		opts := Options{
+			TypeMap: map[string]string{testTableID + ".created_at": typeOfDateTime.PkgPath() + ".DateTime"},
+			TypeResolver: TypeResolverFunc(func(field *bigquery.FieldSchema) (string, string, error) {
+				if field.Type == bigquery.StringFieldType {
+					return "json.RawMessage", "encoding/json", nil
+				}
+				return "", "", nil
+			}),
+			Nullable: nullableModeNull,
+		}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, opts)
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"Created_at civil.DateTime `bigquery:\"created_at\"`",
+			"Updated_at bigquery.NullTimestamp `bigquery:\"updated_at\"`",
+			"Tags []json.RawMessage `bigquery:\"tags\"`",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+		if !reflect.DeepEqual(pkgs, []string{typeOfDateTime.PkgPath(), typeOfNullTimestamp.PkgPath(), "encoding/json"}) {
+			t.Errorf("generateTableMetadataCode: unexpected packages: %v", pkgs)
+		}
+	})
+
+	t.Run("正常系_TypeResolver_once_per_column", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+			{Name: "user", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+				{Name: "name", Type: bigquery.StringFieldType},
+				{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+					{Name: "city", Type: bigquery.StringFieldType},
+				}},
+			}},
+		}}
+		calls := make(map[string]int)
+		opts := Options{
+			TypeResolver: TypeResolverFunc(func(field *bigquery.FieldSchema) (string, string, error) {
+				calls[field.Name]++
+				return "", "", nil
+			}),
+			Getters:  true,
+			FromRow:  true,
+			Stringer: true,
+		}
+		if _, _, err := generateTableMetadataCode(testTable, md, nil, opts); err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(calls, map[string]int{"id": 1, "user": 1, "name": 1, "address": 1, "city": 1}) {
+			t.Errorf("generateTableMetadataCode: unexpected calls: %v", calls)
+		}
+
+		calls = make(map[string]int)
+		columnTypes := make(map[string]string)
+		if err := schemaColumnTypes(testTableID, "", md.Schema, newGeneration(nil, opts), opts, columnTypes); err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(calls, map[string]int{"id": 1, "user": 1, "name": 1, "address": 1, "city": 1}) {
+			t.Errorf("schemaColumnTypes: unexpected calls: %v", calls)
+		}
+		if columnTypes["user.address.city"] != "string" {
+			t.Errorf("schemaColumnTypes: unexpected column types: %v", columnTypes)
+		}
+	})
+
+	t.Run("異常系_TypeResolver_error", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: testNullableRecordsSchema}
+		if _, _, err := generateTableMetadataCode(testTable, md, nil, Options{TypeResolver: DefaultTypeResolver{}}); err == nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("正常系_nested_struct_name_collision", func(t *testing.T) {
+		id := bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType}}
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "b", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{{Name: "c", Type: bigquery.RecordFieldType, Schema: id}}},
+			{Name: "bC", Type: bigquery.RecordFieldType, Schema: id},
+		}}
+		tables := []*bigquery.Table{testTable, {TableID: testTableID + "B"}}
+		var generatedCodes []string
+		for i := 0; i < 2; i++ {
+			generatedCode, _, err := generateTableMetadataCode(testTable, md, newGeneration(tables, Options{}), Options{})
+			if err != nil {
+				t.Error(err)
+			}
+			generatedCodes = append(generatedCodes, generatedCode)
+		}
+		for _, want := range []string{
+			"B Test_tableB_2 `bigquery:\"b\"`",
+			"C Test_tableB_2C `bigquery:\"c\"`",
+			"BC Test_tableBC `bigquery:\"bC\"`",
+		} {
+			if !strings.Contains(generatedCodes[0], want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCodes[0] + "`")
+			}
+		}
+		if generatedCodes[0] != generatedCodes[1] {
+			t.Error("generateTableMetadataCode: not stable: " + generatedCodes[1])
+		}
+	})
+
+	t.Run("正常系_nested_struct_name_collision_in_table", func(t *testing.T) {
+		id := bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType}}
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "b", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{{Name: "c", Type: bigquery.RecordFieldType, Schema: id}}},
+			{Name: "bC", Type: bigquery.RecordFieldType, Schema: id},
+		}}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"C Test_tableBC `bigquery:\"c\"`",
+			"BC Test_tableBC_2 `bigquery:\"bC\"`",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_Embed", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: testNullableRecordsSchema}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, Options{Embed: testEmbedType})
+		if err != nil {
+			t.Error(err)
+		}
+		if want := "type Test_table struct {\n\t*models.BaseModel\n"; !strings.Contains(generatedCode, want) {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+		if strings.Count(generatedCode, "models.BaseModel") != 1 {
+			t.Error("generateTableMetadataCode: nested structs should not embed: " + generatedCode)
+		}
+		if !reflect.DeepEqual(pkgs, []string{"example.com/models"}) {
+			t.Errorf("generateTableMetadataCode: unexpected packages: %v", pkgs)
+		}
+	})
+
+	t.Run("正常系_TableName_of_sanitized_table_ID", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: testNullableRoundTripSchema}
+		generatedCode, _, err := generateTableMetadataCode(&bigquery.Table{TableID: "123_events-v2"}, md, nil, Options{})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"type X123_events_v2 struct {",
+			"func (X123_events_v2) TableName() string {\n\treturn \"123_events-v2\"\n}",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+
+		generatedCode, _, err = generateTableMetadataCode(&bigquery.Table{TableID: "123_events-v2"}, md, nil, Options{NoTableNameMethod: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if strings.Contains(generatedCode, "TableName") {
+			t.Error("generateTableMetadataCode: TableName with NoTableNameMethod: current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("異常系_TableName_conflicts", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{{Name: "TableName", Type: bigquery.StringFieldType}}}
+		if _, _, err := generateTableMetadataCode(testTable, md, nil, Options{}); err == nil || !strings.Contains(err.Error(), "-"+optNameNoTableNameMethod) {
+			t.Errorf("generateTableMetadataCode: want the error of -%s, current=%v", optNameNoTableNameMethod, err)
+		}
+		if _, _, err := generateTableMetadataCode(testTable, md, nil, Options{NoTableNameMethod: true}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("正常系_Getters", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: append(append(bigquery.Schema{}, testNullableRoundTripSchema...), testNullableRecordsSchema...)}
+		opts := Options{Nullable: nullableModeNull, NullableRecords: nullableRecordsPointer, Getters: true}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, opts)
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"type Test_tableGetter interface {\n\tGetRequired_string() string\n",
+			"\tGetNullable_int() bigquery.NullInt64\n",
+			"\tGetNullable_record() *Test_tableNullable_record\n",
+			"var _ Test_tableGetter = (*Test_table)(nil)\n",
+			"func (s *Test_table) GetRepeated_record() []Test_tableRepeated_record {\n\tif s == nil {\n\t\tvar zero []Test_tableRepeated_record\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+		if strings.Contains(generatedCode, "Test_tableRequired_recordGetter") {
+			t.Error("generateTableMetadataCode: nested structs should not have getters: " + generatedCode)
+		}
+		if _, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, generatedCode, opts); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_Getters_conflict", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+			{Name: "getId", Type: bigquery.IntegerFieldType, Required: true},
+		}}
+		if _, _, err := generateTableMetadataCode(testTable, md, nil, Options{Getters: true}); err == nil || !strings.Contains(err.Error(), "getter GetId") {
+			t.Errorf("generateTableMetadataCode: want the error of GetId, current=%v", err)
+		}
+	})
+
+	t.Run("正常系_no_fields", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: testNullableRecordsSchema[1:]}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{RequiredOnly: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if want := "type Test_table struct {\n}\n"; !strings.Contains(generatedCode, want) {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("異常系_no_fields_Strict", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: testNullableRecordsSchema[1:]}
+		if _, _, err := generateTableMetadataCode(testTable, md, nil, Options{RequiredOnly: true, Strict: true}); err == nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("正常系_Stringer", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: append(append(bigquery.Schema{}, testNullableRoundTripSchema...), testNullableRecordsSchema...)}
+		opts := Options{Nullable: nullableModeNull, NullableRecords: nullableRecordsPointer, Stringer: true}
+		generatedCode, pkgs, err := generateTableMetadataCode(testTable, md, nil, opts)
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"func (s Test_table) String() string {\n\treturn fmt.Sprintf(\"Test_table{Required_string: %v, Nullable_string: %v, ",
+			"Nullable_record: %v, Repeated_record: %v}\", s.Required_string, s.Nullable_string, ",
+			"func (s Test_tableNullable_record) String() string {\n\treturn fmt.Sprintf(\"Test_tableNullable_record{Id: %v}\", s.Id)\n}\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+		if _, err := generateFileCode(testPublicDataProjectID, testSupportedDatasetID, pkgs, generatedCode, opts); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_Stringer_conflict", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{{Name: "string", Type: bigquery.StringFieldType}}}
+		if _, _, err := generateTableMetadataCode(testTable, md, nil, Options{Stringer: true}); err == nil || !strings.Contains(err.Error(), "-"+optNameStringer) {
+			t.Errorf("generateTableMetadataCode: want the error of -%s, current=%v", optNameStringer, err)
+		}
+	})
+
+	t.Run("異常系_FromRow_conflict", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{{Name: "fromRow", Type: bigquery.StringFieldType}}}
+		if _, _, err := generateTableMetadataCode(&bigquery.Table{TableID: "t"}, md, nil, Options{FromRow: true}); err == nil || !strings.Contains(err.Error(), "-"+optNameFromRow) {
+			t.Errorf("generateTableMetadataCode: want the error of -%s, current=%v", optNameFromRow, err)
+		}
+	})
+
+	t.Run("正常系_PolicyTags", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "email", Type: bigquery.StringFieldType, PolicyTags: &bigquery.PolicyTagList{Names: []string{testPolicyTag}}},
+			{Name: "name", Type: bigquery.StringFieldType},
+		}}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{PolicyTagComment: "PII", PolicyTagStructTag: `pii:"true"`})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"\t// PII: " + testPolicyTag + "\n\tEmail string `bigquery:\"email\" pii:\"true\"`\n",
+			"\tName string `bigquery:\"name\"`\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_TagDirective", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "email", Type: bigquery.StringFieldType, Description: "[DEPRECATED] the email @tag:validate=required,email @tag:pii=true"},
+			{Name: "name", Type: bigquery.StringFieldType, Description: "the name"},
+		}}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{TagDirective: "@tag:", DeprecatedMarker: "[DEPRECATED]"})
+		if err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"\t// Deprecated: the email\n\tEmail string `bigquery:\"email\" validate:\"required,email\" pii:\"true\"`\n",
+			"\tName string `bigquery:\"name\"`\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+			}
+		}
+	})
+
+	t.Run("異常系_TagDirective_column_tag_key", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "email", Type: bigquery.StringFieldType, Description: "@tag:bigquery=mail"},
+		}}
+		if _, _, err := generateTableMetadataCode(testTable, md, nil, Options{TagDirective: "@tag:"}); err == nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("正常系_Template_FieldContext", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "day", Type: bigquery.DateFieldType, Required: true, Description: "the  day"},
+			{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+			{Name: "note", Type: bigquery.StringFieldType},
+		}}
+		structTemplate := template.Must(template.New("test").Parse("{{range .Fields}}{{.ColumnName}} {{.Mode}} {{.Type}} {{printf \"%q\" .Import}} {{printf \"%q\" .Description}}\n{{end}}"))
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{Template: structTemplate})
+		if err != nil {
+			t.Error(err)
+		}
+		want := "day REQUIRED civil.Date \"" + typeOfDate.PkgPath() + "\" \"the  day\"\n" +
+			"tags REPEATED []string \"\" \"\"\n" +
+			"note NULLABLE string \"\" \"\"\n"
+		if generatedCode != want {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_SelectAll", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+			{Name: "select", Type: bigquery.StringFieldType},
+		}}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{SelectAll: true, RequiredOnly: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if want := "const Test_tableSelectAll = \"SELECT `id` FROM `" + testPublicDataProjectID + "." + testSupportedDatasetID + "." + testTableID + "`\"\n"; !strings.Contains(generatedCode, want) {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_SelectAll_escaped", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "a`b", Type: bigquery.StringFieldType},
+			{Name: `c\d`, Type: bigquery.StringFieldType},
+		}}
+		// NOTE: the field names of the columns are sanitized
+		nameMapper := func(tableID, columnName string) string {
+			return DefaultNameMapper(tableID, sanitizeIdentifier(columnName))
+		}
+		generatedCode, _, err := generateTableMetadataCode(&bigquery.Table{TableID: testTableID}, md, nil, Options{SelectAll: true, NameMapper: nameMapper})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "const Test_tableSelectAll = " + strconv.Quote("SELECT `a\\`b`, `c\\\\d` FROM `"+testTableID+"`") + "\n"; !strings.Contains(generatedCode, want) {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("正常系_concurrent_generations", func(t *testing.T) {
+		// NOTE: run with -race (see Makefile) to detect the data races.
+		// Each goroutine has its own generation, that is not safe for concurrent use, and shares the package-level state, e.g. tagDirectivePatterns.
+		md := &bigquery.TableMetadata{Schema: append(append(bigquery.Schema{}, testNullableRoundTripSchema...), testNullableRecordsSchema...)}
+		opts := Options{
+			Nullable:        nullableModeNull,
+			NullableRecords: nullableRecordsPointer,
+			TypeMap:         map[string]string{testTableID + "0.nullable_num": typeOfDateTime.PkgPath() + ".DateTime"},
+			FromRow:         true,
+			Getters:         true,
+			TagDirective:    "@concurrent:",
+		}
+		const n = 8
+		results := make([][]string, n)
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, pkgs, err := generateTableMetadataCode(&bigquery.Table{TableID: testTableID + strconv.Itoa(i)}, md, nil, opts)
+				if err != nil {
+					t.Error(err)
+				}
+				results[i] = pkgs
+			}(i)
+		}
+		wg.Wait()
+
+		var importPackages, wantImportPackages []string
+		for i, pkgs := range results {
+			importPackages = append(importPackages, pkgs...)
+			_, wantPkgs, err := generateTableMetadataCode(&bigquery.Table{TableID: testTableID + strconv.Itoa(i)}, md, nil, opts)
+			if err != nil {
+				t.Error(err)
+			}
+			wantImportPackages = append(wantImportPackages, wantPkgs...)
+		}
+		want := generateImportPackagesCode(wantImportPackages)
+		if !strings.Contains(want, typeOfDateTime.PkgPath()) {
+			t.Error("generateImportPackagesCode: current=`" + want + "`")
+		}
+		if generatedCode := generateImportPackagesCode(importPackages); generatedCode != want {
+			t.Error("generateImportPackagesCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+	})
+
+	t.Run("異常系_testNotSupportedFieldType", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{{Name: testTableID, Type: testNotSupportedFieldType}}}
+		if _, _, err := generateTableMetadataCode(testTable, md, nil, Options{Nullable: nullableModeNull}); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_reserveTableStructNames(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		nameMapper := func(tableID, columnName string) string {
+			return tableID
+		}
+		typeNames := make(map[string]bool)
+		structNames := reserveTableStructNames([]*bigquery.Table{{TableID: testCapitalized}, {TableID: "-"}}, typeNames, Options{NameMapper: nameMapper})
+		if !reflect.DeepEqual(typeNames, map[string]bool{testCapitalized: true}) {
+			t.Errorf("reserveTableStructNames: current=%v", typeNames)
+		}
+		if !reflect.DeepEqual(structNames, map[string]string{testCapitalized: testCapitalized}) {
+			t.Errorf("reserveTableStructNames: current=%v", structNames)
+		}
+	})
+
+	t.Run("正常系_sanitized_struct_names_conflict", func(t *testing.T) {
+		tables := []*bigquery.Table{{TableID: "events-v2"}, {TableID: "events_v2"}}
+		gen := newGeneration(tables, Options{})
+		if expected := map[string]string{"events-v2": "Events_v2", "events_v2": "Events_v2_2"}; !reflect.DeepEqual(expected, gen.structNames) {
+			t.Errorf("reserveTableStructNames: expected(%v) != actual(%v)", expected, gen.structNames)
+		}
+		generatedCode, _, err := generateTableMetadataCode(tables[1], &bigquery.TableMetadata{Schema: testNullableRoundTripSchema}, gen, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "func (Events_v2_2) TableName() string {\n\treturn \"events_v2\"\n}"; !strings.Contains(generatedCode, want) {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+	})
+}
+
+func Test_DefaultTypeResolver(t *testing.T) {
+	t.Run("正常系_INTEGER", func(t *testing.T) {
+		goType, importPath, err := DefaultTypeResolver{}.Resolve(&bigquery.FieldSchema{Type: bigquery.IntegerFieldType, Repeated: true})
+		if err != nil {
+			t.Error(err)
+		}
+		if goType != reflect.Int64.String() || importPath != "" {
+			t.Errorf("DefaultTypeResolver: goType=%s importPath=%s", goType, importPath)
+		}
+	})
+}
+
+func Test_generateSelectAllCode(t *testing.T) {
+	t.Run("正常系_reserved_words", func(t *testing.T) {
+		schemas := bigquery.Schema{{Name: "select"}, {Name: "from"}}
+		want := "// ConstName is the query that selects the columns of " + testTableID + ".\n" +
+			"const ConstName = \"SELECT `select`, `from` FROM `" + testTableID + "`\"\n"
+		if generatedCode := generateSelectAllCode(&bigquery.Table{TableID: testTableID}, "ConstName", schemas); generatedCode != want {
+			t.Error("generateSelectAllCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+	})
+}
+
+func Test_isFlattenableRecord(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		var (
+			value    = &bigquery.FieldSchema{Name: "value", Type: bigquery.IntegerFieldType}
+			required = &bigquery.FieldSchema{Name: "required", Type: bigquery.IntegerFieldType, Required: true}
+		)
+		for _, tt := range []struct {
+			name   string
+			schema *bigquery.FieldSchema
+			opts   Options
+			want   bool
+		}{
+			{name: "repeated_single_field", schema: &bigquery.FieldSchema{Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{value}}, want: true},
+			{name: "not_repeated", schema: &bigquery.FieldSchema{Type: bigquery.RecordFieldType, Schema: bigquery.Schema{value}}, want: false},
+			{name: "not_record", schema: &bigquery.FieldSchema{Type: bigquery.IntegerFieldType, Repeated: true}, want: false},
+			{name: "multiple_fields", schema: &bigquery.FieldSchema{Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{value, required}}, want: false},
+			{name: "multiple_fields_RequiredOnly", schema: &bigquery.FieldSchema{Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{value, required}}, opts: Options{RequiredOnly: true}, want: true},
+			{name: "multiple_fields_ExcludeColumns", schema: &bigquery.FieldSchema{Name: "items", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{value, required}}, opts: Options{ExcludeColumns: []string{"*.items." + required.Name}}, want: true},
+			{name: "repeated_field", schema: &bigquery.FieldSchema{Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{{Name: "values", Type: bigquery.IntegerFieldType, Repeated: true}}}, want: false},
+			{name: "record_field", schema: &bigquery.FieldSchema{Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{{Name: "record", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{value}}}}, want: false},
+		} {
+			if current := isFlattenableRecord(testTable.TableID, "", tt.schema, tt.opts); current != tt.want {
+				t.Errorf("isFlattenableRecord: %s: want=%t current=%t", tt.name, tt.want, current)
+			}
+		}
+	})
+}
+
+func Test_filterColumns(t *testing.T) {
+	t.Run("正常系_RequiredOnly", func(t *testing.T) {
+		filtered := filterColumns(testTable.TableID, "", testNullableRoundTripSchema, Options{RequiredOnly: true})
+		if len(filtered) != 1 || filtered[0] != testNullableRoundTripSchema[0] {
+			t.Errorf("filterColumns: current=%v", filtered)
+		}
+	})
+
+	t.Run("正常系_no_filter", func(t *testing.T) {
+		if filtered := filterColumns(testTable.TableID, "", testNullableRoundTripSchema, Options{}); len(filtered) != len(testNullableRoundTripSchema) {
+			t.Errorf("filterColumns: current=%v", filtered)
+		}
+	})
+}
+
+func Test_parseTypeMap(t *testing.T) {
+	t.Run("正常系_testEmptyString", func(t *testing.T) {
+		typeMap, err := parseTypeMap(testEmptyString)
+		if err != nil {
+			t.Error(err)
+		}
+		if len(typeMap) != 0 {
+			t.Errorf("parseTypeMap: current=%v", typeMap)
+		}
+	})
+
+	t.Run("正常系_pairs", func(t *testing.T) {
+		typeMap, err := parseTypeMap("a.b=int,a.c=cloud.google.com/go/civil.DateTime")
+		if err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(typeMap, map[string]string{"a.b": "int", "a.c": "cloud.google.com/go/civil.DateTime"}) {
+			t.Errorf("parseTypeMap: current=%v", typeMap)
+		}
+	})
+
+	t.Run("正常系_BigQuery_type", func(t *testing.T) {
+		typeMap, err := parseTypeMap("INTEGER=int,a.b=int32")
+		if err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(typeMap, map[string]string{"INTEGER": "int", "a.b": "int32"}) {
+			t.Errorf("parseTypeMap: current=%v", typeMap)
+		}
+	})
+
+	for _, s := range []string{"a.b", "a=int", "RECORD=int", "a.b=", "a.b=civil."} {
+		t.Run("異常系_"+s, func(t *testing.T) {
+			if _, err := parseTypeMap(s); err == nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func Test_importPathToAssumedName(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for importPath, want := range map[string]string{
+			"cloud.google.com/go/civil":    "civil",
+			"github.com/twpayne/go-geom":   "geom",
+			"gopkg.in/yaml.v2":             "yaml",
+			"github.com/go-redis/redis/v8": "redis",
+			"example.com/geo-json":         "geo",
+		} {
+			if name := importPathToAssumedName(importPath); name != want {
+				t.Errorf("importPathToAssumedName: importPath=%s want=%s current=%s", importPath, want, name)
+			}
+		}
+	})
+}
+
+func Test_parseLossyTypes(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		lossyTypes, err := parseLossyTypes(defaultValueLossyTypes + "," + string(bigquery.NumericFieldType))
+		if err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(lossyTypes, []bigquery.FieldType{bigquery.GeographyFieldType, bigquery.NumericFieldType}) {
+			t.Errorf("parseLossyTypes: unexpected types: %v", lossyTypes)
+		}
+		if lossyTypes, err := parseLossyTypes(testEmptyString); err != nil || lossyTypes != nil {
+			t.Errorf("parseLossyTypes: want=nil current=%v err=%v", lossyTypes, err)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for _, s := range []string{string(bigquery.RecordFieldType), testNotSupportedFieldType, "GEOGRAPHY,"} {
+			if _, err := parseLossyTypes(s); err == nil {
+				t.Error("parseLossyTypes: want error. s=" + s)
+			}
+		}
+	})
+}
+
+func Test_parseGoType(t *testing.T) {
+	for s, want := range map[string][2]string{
+		"int":                                {"int", testEmptyString},
+		"[]byte":                             {"[]byte", testEmptyString},
+		"time.Time":                          {"time.Time", "time"},
+		"*math/big.Rat":                      {"*big.Rat", "math/big"},
+		"cloud.google.com/go/civil.DateTime": {"civil.DateTime", "cloud.google.com/go/civil"},
+	} {
+		t.Run("正常系_"+s, func(t *testing.T) {
+			goType, pkg, err := parseGoType(s)
+			if err != nil {
+				t.Error(err)
+			}
+			if goType != want[0] || pkg != want[1] {
+				t.Error("parseGoType: want=" + want[0] + "," + want[1] + " current=" + goType + "," + pkg)
+			}
+		})
+	}
+
+	for _, s := range []string{testEmptyString, "*", "a-b", "time.time", "example.com/pkg", "civil."} {
+		t.Run("異常系_"+s, func(t *testing.T) {
+			if _, _, err := parseGoType(s); err == nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func Test_parseEmbedType(t *testing.T) {
+	t.Run("正常系_testEmbedType", func(t *testing.T) {
+		embedType, pkg, err := parseEmbedType(testEmbedType)
+		if err != nil {
+			t.Error(err)
+		}
+		if embedType != "*models.BaseModel" || pkg != "example.com/models" {
+			t.Errorf("parseEmbedType: embedType=%s pkg=%s", embedType, pkg)
+		}
+	})
+
+	t.Run("正常系_testEmptyString", func(t *testing.T) {
+		if embedType, _, err := parseEmbedType(testEmptyString); err != nil || embedType != "" {
+			t.Errorf("parseEmbedType: embedType=%s err=%v", embedType, err)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for _, s := range []string{"[]example.com/models.BaseModel", "**example.com/models.BaseModel", "example.com/models.baseModel"} {
+			if _, _, err := parseEmbedType(s); err == nil {
+				t.Error("parseEmbedType: want error: " + s)
+			}
+		}
+	})
+}
+
+func Test_parseTemplateFile(t *testing.T) {
+	t.Run("異常系_testErrNoSuchFileOrDirectoryPath", func(t *testing.T) {
+		if _, err := parseTemplateFile(testErrNoSuchFileOrDirectoryPath); err == nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_invalid_template", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "invalid.tmpl")
+		if err := writeGeneratedCode(filePath, testFileMode, []byte("{{.Name")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := parseTemplateFile(filePath); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_parseExcludeColumns(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		excludeColumns, err := parseExcludeColumns("events.payload,*.raw_*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(excludeColumns, []string{"events.payload", "*.raw_*"}) {
+			t.Errorf("parseExcludeColumns: unexpected columns: %v", excludeColumns)
+		}
+		if excludeColumns, err := parseExcludeColumns(""); err != nil || excludeColumns != nil {
+			t.Errorf("parseExcludeColumns: want no columns. columns=%v err=%v", excludeColumns, err)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for _, s := range []string{"payload", "events.[payload", "events.payload,"} {
+			if _, err := parseExcludeColumns(s); err == nil {
+				t.Error("parseExcludeColumns: want error. s=" + s)
+			}
+		}
+	})
+}
+
+func Test_parseWildcards(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		prefixes, err := parseWildcards("events_,sessions_")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(prefixes, []string{"events_", "sessions_"}) {
+			t.Errorf("parseWildcards: unexpected prefixes: %v", prefixes)
+		}
+		if prefixes, err := parseWildcards(""); err != nil || prefixes != nil {
+			t.Errorf("parseWildcards: want no prefixes. prefixes=%v err=%v", prefixes, err)
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		for _, s := range []string{"events_*", "dataset.events_", "events_,"} {
+			if _, err := parseWildcards(s); err == nil {
+				t.Error("parseWildcards: want error. s=" + s)
+			}
+		}
+	})
+}
+
+func Test_tagDirectivePattern(t *testing.T) {
+	t.Run("正常系_compiled_once", func(t *testing.T) {
+		if tagDirectivePattern("@tag:") != tagDirectivePattern("@tag:") {
+			t.Error("tagDirectivePattern: the pattern of the same prefix is compiled again")
+		}
+		if tagDirectivePattern("@tag:") == tagDirectivePattern("+") {
+			t.Error("tagDirectivePattern: the patterns of the different prefixes are the same")
+		}
+	})
+}
+
+func Test_parseTagDirectives(t *testing.T) {
+	for _, tt := range []struct {
+		description, prefix, stripped, tag string
+	}{
+		{"the email @tag:validate=required", "@tag:", "the email", `validate:"required"`},
+		{"+validate=required,email\nthe email", "+", "the email", `validate:"required,email"`},
+		{"the email @tag:db= @tag:=x", "@tag:", "the email @tag:=x", `db:""`},
+		{"the email @tag:a:b=c", "@tag:", "the email @tag:a:b=c", ""},
+		{"the \"quoted\" @tag:example=\"x\"", "@tag:", "the \"quoted\"", `example:"\"x\""`},
+		{"the email", "@tag:", "the email", ""},
+	} {
+		stripped, tag := parseTagDirectives(tt.description, tt.prefix)
+		if stripped != tt.stripped || tag != tt.tag {
+			t.Errorf("parseTagDirectives(%q, %q): want=(%q, %q) current=(%q, %q)", tt.description, tt.prefix, tt.stripped, tt.tag, stripped, tag)
+		}
+	}
+}
+
+func Test_validateStructTag(t *testing.T) {
+	for _, s := range []string{testEmptyString, `pii:"true"`, `pii:"true" json:"-"`, `pii:"a b"`} {
+		t.Run("正常系_"+s, func(t *testing.T) {
+			if err := validateStructTag(s, defaultValueTagKey); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	for _, s := range []string{"pii", `pii:true`, `pii:"true"  bigquery:"-"`, `pii:"true" bigquery:"-"`, "pii:\"true\"`"} {
+		t.Run("異常系_"+s, func(t *testing.T) {
+			if err := validateStructTag(s, defaultValueTagKey); err == nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	t.Run("異常系_tagKey", func(t *testing.T) {
+		if err := validateStructTag(`db:"-"`, "db"); err == nil {
+			t.Error("validateStructTag: want error")
+		}
+	})
+}
+
+func Test_validateTagKey(t *testing.T) {
+	for _, key := range []string{testEmptyString, defaultValueTagKey, "db", "x-bq"} {
+		t.Run("正常系_"+key, func(t *testing.T) {
+			if err := validateTagKey(key); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	for _, key := range []string{"big query", `db"`, "db:", "db`", "db\x7f"} {
+		t.Run("異常系_"+key, func(t *testing.T) {
+			if err := validateTagKey(key); err == nil {
+				t.Error("validateTagKey: want error")
+			}
+		})
+	}
+}
+
+func Test_commentText(t *testing.T) {
+	t.Run("正常系_multiline", func(t *testing.T) {
+		if text := commentText(" a\n b\r\n\tc "); text != "a b c" {
+			t.Error("commentText: current=" + text)
+		}
+	})
+}
+
+func Test_wrapComment(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		for _, tt := range []struct {
+			comment  string
+			width    int
+			expected []string
+		}{
+			{"Deprecated: use the column new_id instead", 16, []string{"Deprecated: use", "the column", "new_id instead"}},
+			{"short", 16, []string{"short"}},
+			{"", 16, []string{""}},
+			// NOTE(djeeno): The words longer than width are not split, and the widths of the multibyte characters are of the runes.
+			{"see https://cloud.google.com/bigquery/docs/schemas for details", 16, []string{"see", "https://cloud.google.com/bigquery/docs/schemas", "for details"}},
+			{"ユーザーの ID です", 8, []string{"ユーザーの ID", "です"}},
+			{"a b c", 0, []string{"a", "b", "c"}},
+		} {
+			if actual := wrapComment(tt.comment, tt.width); !reflect.DeepEqual(tt.expected, actual) {
+				t.Errorf("wrapComment: expected(%q) != actual(%q)", tt.expected, actual)
+			}
+		}
+	})
+
+	t.Run("正常系_CommentWidth", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "id", Type: bigquery.IntegerFieldType, Description: "[DEPRECATED] the id of the user, that is replaced by the column user_id"},
+		}}
+		generatedCode, _, err := generateTableMetadataCode(testTable, md, nil, Options{DeprecatedMarker: "[DEPRECATED]", CommentWidth: 40})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "\t// Deprecated: the id of the user, that\n\t// is replaced by the column user_id\n"; !strings.Contains(generatedCode, want) {
+			t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
+		}
+	})
+}
+
+func Test_consoleURL(t *testing.T) {
+	t.Run("正常系_testTable", func(t *testing.T) {
+		if current := consoleURL(testTable); current != testConsoleURL {
+			t.Error("consoleURL: want=" + testConsoleURL + " current=" + current)
+		}
+	})
+}
+
+func Test_selectDatasets(t *testing.T) {
+	datasetIDs := []string{"analytics_prod", "analytics_tmp", "billing", "raw_analytics"}
+
+	t.Run("正常系_nil_pattern", func(t *testing.T) {
+		actual := selectDatasets(datasetIDs, []string{"billing"}, nil)
+		expected := []string{"analytics_prod", "analytics_tmp", "raw_analytics"}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("selectDatasets: expected(%v) != actual(%v)", expected, actual)
+		}
+	})
+
+	t.Run("正常系_pattern_exclude", func(t *testing.T) {
+		actual := selectDatasets(datasetIDs, []string{"analytics_tmp"}, regexp.MustCompile("^analytics_"))
+		expected := []string{"analytics_prod"}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("selectDatasets: expected(%v) != actual(%v)", expected, actual)
+		}
+	})
+
+	t.Run("正常系_no_match", func(t *testing.T) {
+		if actual := selectDatasets(datasetIDs, nil, regexp.MustCompile("^sales_")); len(actual) != 0 {
+			t.Errorf("selectDatasets: expected no dataset, actual(%v)", actual)
+		}
+	})
+}
+
+func Test_getAllDatasets(t *testing.T) {
+	t.Run("正常系_testPublicDataProjectID_exclude_testSupportedDatasetID", func(t *testing.T) {
+		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
+			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
+		}
+
+		var (
+			ctx         = context.Background()
+			okClient, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+		)
+
+		datasetIDs, err := getAllDatasets(ctx, okClient, testPublicDataProjectID, []string{testSupportedDatasetID}, nil)
+		if err != nil {
+			t.Error(err)
+		}
+		for _, datasetID := range datasetIDs {
+			if datasetID == testSupportedDatasetID {
+				t.Error("getAllDatasets: excluded dataset is returned: " + datasetID)
+			}
+		}
+	})
+
+	t.Run("異常系_testProjectNotFound", func(t *testing.T) {
+		backupValue, exist := os.LookupEnv(envNameGoogleApplicationCredentials)
+		_ = os.Setenv(envNameGoogleApplicationCredentials, testGoogleApplicationCredentials)
+		defer func() {
+			if exist {
+				_ = os.Setenv(envNameGoogleApplicationCredentials, backupValue)
+				return
+			}
+			_ = os.Unsetenv(envNameGoogleApplicationCredentials)
+		}()
+
+		var (
+			ctx         = context.Background()
+			ngClient, _ = bigquery.NewClient(ctx, testProjectNotFound)
+		)
+
+		if _, err := getAllDatasets(ctx, ngClient, testProjectNotFound, nil, nil); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_getTables(t *testing.T) {
+	t.Run("正常系_Tables", func(t *testing.T) {
+		var (
+			ctx       = context.Background()
+			client, _ = bigquery.NewClient(ctx, testPublicDataProjectID, option.WithoutAuthentication())
+		)
+
+		tables, _, err := getTables(ctx, client, testPublicDataProjectID, testSupportedDatasetID, Options{Tables: []string{"stories", "comments"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tableIDs []string
+		for _, table := range tables {
+			if table.ProjectID != testPublicDataProjectID || table.DatasetID != testSupportedDatasetID {
+				t.Errorf("getTables: current=%s.%s", table.ProjectID, table.DatasetID)
+			}
+			tableIDs = append(tableIDs, table.TableID)
+		}
+		if want := []string{"comments", "stories"}; !reflect.DeepEqual(tableIDs, want) {
+			t.Errorf("getTables: want=%v current=%v", want, tableIDs)
+		}
+	})
+}
+
+func Test_parseTableList(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		list, err := parseTableList("# tables\n" +
+			testPublicDataProjectID + "." + testSupportedDatasetID + ".stories\n" +
+			"\n" +
+			"  " + testPublicDataProjectID + ":samples.shakespeare  \r\n" +
+			"`" + testPublicDataProjectID + "." + testSupportedDatasetID + ".comments`\n" +
+			testPublicDataProjectID + "." + testSupportedDatasetID + ".stories\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &tableList{
+			project:  testPublicDataProjectID,
+			datasets: []string{testSupportedDatasetID, "samples"},
+			tables:   map[string][]string{testSupportedDatasetID: {"stories", "comments"}, "samples": {"shakespeare"}},
+		}
+		if !reflect.DeepEqual(list, want) {
+			t.Errorf("parseTableList: want=%v current=%v", want, list)
+		}
+	})
+
+	t.Run("正常系_domain_scoped_project", func(t *testing.T) {
+		list, err := parseTableList("example.com:project.dataset.table")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if list.project != "example.com:project" || !reflect.DeepEqual(list.tables, map[string][]string{"dataset": {"table"}}) {
+			t.Errorf("parseTableList: current=%v", list)
+		}
+	})
+
+	for name, content := range map[string]string{
+		"dataset.table":     "dataset.table",
+		"empty_table":       "project.dataset.",
+		"no_table":          "# comment\n\n",
+		"multiple_projects": "project1.dataset.table\nproject2.dataset.table",
+	} {
+		content := content
+		t.Run("異常系_"+name, func(t *testing.T) {
+			if _, err := parseTableList(content); err == nil {
+				t.Error("parseTableList: want error")
+			}
+		})
+	}
+}
+
+func Test_readTableList(t *testing.T) {
+	t.Run("正常系_file", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "tables.txt")
+		if err := writeGeneratedCode(filePath, testFileMode, []byte(testPublicDataProjectID+"."+testSupportedDatasetID+".stories\n")); err != nil {
+			t.Fatal(err)
+		}
+		list, err := readTableList(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(list.tables, map[string][]string{testSupportedDatasetID: {"stories"}}) {
+			t.Errorf("readTableList: current=%v", list)
+		}
+	})
+
+	t.Run("正常系_stdin", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		backup := os.Stdin
+		os.Stdin = r
+		defer func() { os.Stdin = backup }()
+		if _, err = w.Write([]byte(testPublicDataProjectID + "." + testSupportedDatasetID + ".stories\n")); err != nil {
+			t.Fatal(err)
+		}
+		_ = w.Close()
+
+		list, err := readTableList(filePathStdin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if list.project != testPublicDataProjectID {
+			t.Errorf("readTableList: current=%v", list)
+		}
+	})
+
+	t.Run("異常系_testErrNoSuchFileOrDirectoryPath", func(t *testing.T) {
+		if _, err := readTableList(testErrNoSuchFileOrDirectoryPath); err == nil {
+			t.Error("readTableList: want error")
+		}
+	})
+}
+
+// fakeTableIterator returns tables, and then err or iterator.Done.
+type fakeTableIterator struct {
+	tables   []*bigquery.Table
+	err      error
+	pageInfo iterator.PageInfo
+}
+
+func (it *fakeTableIterator) PageInfo() *iterator.PageInfo {
+	return &it.pageInfo
+}
+
+func (it *fakeTableIterator) Next() (*bigquery.Table, error) {
+	if len(it.tables) == 0 {
+		if it.err != nil {
+			return nil, it.err
+		}
+		return nil, iterator.Done
+	}
+	table := it.tables[0]
+	it.tables = it.tables[1:]
+	return table, nil
+}
+
+func Test_collectTables(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		tables, truncated, err := collectTables(context.Background(), func(string) tableIterator {
+			return &fakeTableIterator{tables: []*bigquery.Table{testTable, testTable}}
+		}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tables) != 2 || truncated {
+			t.Errorf("collectTables: want=2 current=%d truncated=%t", len(tables), truncated)
+		}
+	})
+
+	t.Run("正常系_no_tables", func(t *testing.T) {
+		tables, truncated, err := collectTables(context.Background(), func(string) tableIterator {
+			return &fakeTableIterator{}
+		}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tables) != 0 || truncated {
+			t.Errorf("collectTables: want=0 current=%d truncated=%t", len(tables), truncated)
+		}
+	})
+
+	t.Run("異常系_pagination_error", func(t *testing.T) {
+		errPage := &googleapi.Error{Code: http.StatusServiceUnavailable}
+		tables, _, err := collectTables(context.Background(), func(string) tableIterator {
+			return &fakeTableIterator{tables: []*bigquery.Table{testTable, testTable}, err: errPage}
+		}, false)
+		if !errors.Is(err, errPage) {
+			t.Errorf("collectTables: want=%v current=%v", errPage, err)
+		}
+		if err == nil || !strings.Contains(err.Error(), "2 tables fetched") {
+			t.Errorf("collectTables: current=%v", err)
+		}
+		if tables != nil {
+			t.Errorf("collectTables: partial tables are returned: %v", tables)
+		}
+	})
+
+	t.Run("正常系_skipForbidden", func(t *testing.T) {
+		errForbidden := &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "accessDenied"}}}
+		tables, truncated, err := collectTables(context.Background(), func(string) tableIterator {
+			return &fakeTableIterator{tables: []*bigquery.Table{testTable}, err: errForbidden}
+		}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tables) != 1 {
+			t.Errorf("collectTables: want=1 current=%d", len(tables))
+		}
+		if !truncated {
+			t.Error("collectTables: want truncated")
+		}
+	})
+
+	t.Run("異常系_forbidden", func(t *testing.T) {
+		errForbidden := &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "accessDenied"}}}
+		if _, _, err := collectTables(context.Background(), func(string) tableIterator {
+			return &fakeTableIterator{tables: []*bigquery.Table{testTable}, err: errForbidden}
+		}, false); !errors.Is(err, errForbidden) {
+			t.Errorf("collectTables: want=%v current=%v", errForbidden, err)
+		}
+	})
+}
+
+func Test_nextWithRetry(t *testing.T) {
+	backup := listRetrySleep
+	defer func() { listRetrySleep = backup }()
+	var delays []time.Duration
+	listRetrySleep = func(_ context.Context, delay time.Duration) error {
+		delays = append(delays, delay)
+		return nil
+	}
+	errQuota := &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}}}
+
+	t.Run("正常系_resume_from_page_token", func(t *testing.T) {
+		delays = nil
+		var tokens []string
+		tables, _, err := collectTables(context.Background(), func(pageToken string) tableIterator {
+			tokens = append(tokens, pageToken)
+			if pageToken == "" {
+				// NOTE(djeeno): The first page is fetched, and the next page fails.
+				it := &fakeTableIterator{tables: []*bigquery.Table{testTable}, err: errQuota}
+				it.pageInfo.Token = "page2"
+				return it
+			}
+			return &fakeTableIterator{tables: []*bigquery.Table{testTable}}
+		}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tables) != 2 {
+			t.Errorf("collectTables: want=2 current=%d", len(tables))
+		}
+		if expected := []string{"", "page2"}; !reflect.DeepEqual(expected, tokens) {
+			t.Errorf("collectTables: expected(%v) != actual(%v)", expected, tokens)
+		}
+		if len(delays) != 1 || delays[0] <= 0 || delays[0] > listRetryBaseDelay {
+			t.Errorf("collectTables: delays=%v", delays)
+		}
+	})
+
+	t.Run("正常系_Retry-After", func(t *testing.T) {
+		delays = nil
+		errTooMany := &googleapi.Error{Code: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"10"}}}
+		calls := 0
+		err := nextWithRetry(context.Background(), func() error {
+			if calls++; calls == 1 {
+				return errTooMany
+			}
+			return nil
+		}, func() {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(delays) != 1 || delays[0] < 10*time.Second || delays[0] >= 10*time.Second+listRetryBaseDelay {
+			t.Errorf("nextWithRetry: delays=%v", delays)
+		}
+	})
+
+	t.Run("異常系_attempts_exceeded", func(t *testing.T) {
+		delays = nil
+		err := nextWithRetry(context.Background(), func() error { return errQuota }, func() {})
+		if !errors.Is(err, errQuota) {
+			t.Errorf("nextWithRetry: want=%v current=%v", errQuota, err)
+		}
+		if len(delays) != listRetryAttempts {
+			t.Errorf("nextWithRetry: want=%d current=%d", listRetryAttempts, len(delays))
+		}
+	})
+
+	t.Run("異常系_not_rate_limited", func(t *testing.T) {
+		delays = nil
+		errForbidden := &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "accessDenied"}}}
+		if err := nextWithRetry(context.Background(), func() error { return errForbidden }, func() {}); err != errForbidden {
+			t.Errorf("nextWithRetry: want=%v current=%v", errForbidden, err)
+		}
+		if len(delays) != 0 {
+			t.Errorf("nextWithRetry: unexpected retries: %v", delays)
+		}
+	})
+}
+
+func Test_retryAfterDelay(t *testing.T) {
+	now := time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+	t.Run("正常系", func(t *testing.T) {
+		for retryAfter, expected := range map[string]time.Duration{
+			"":                              0,
+			"30":                            30 * time.Second,
+			"Sun, 01 Nov 2020 00:01:00 GMT": time.Minute,
+			"Sat, 31 Oct 2020 23:59:00 GMT": 0,
+			"soon":                          0,
+		} {
+			err := fmt.Errorf("wrapped: %w", &googleapi.Error{Code: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{retryAfter}}})
+			if actual := retryAfterDelay(err, now); expected != actual {
+				t.Errorf("retryAfterDelay: Retry-After=%s expected(%s) != actual(%s)", retryAfter, expected, actual)
+			}
+		}
+	})
+}
+
+func Test_getAllTables(t *testing.T) {
+	t.Run("正常系_testPublicDataProjectID_testSupportedDatasetID", func(t *testing.T) {
+
+		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
+			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
+		}
+
+		var (
+			ctx         = context.Background()
+			okClient, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
+		)
+
+		if _, _, err := getAllTables(ctx, okClient, testPublicDataProjectID, testSupportedDatasetID, false); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("異常系_testProjectNotFound_testDatasetNotFound", func(t *testing.T) {
+
+		backupValue, exist := os.LookupEnv(envNameGoogleApplicationCredentials)
+		_ = os.Setenv(envNameGoogleApplicationCredentials, testGoogleApplicationCredentials)
+		defer func() {
+			if exist {
+				_ = os.Setenv(envNameGoogleApplicationCredentials, backupValue)
+				return
+			}
+			_ = os.Unsetenv(envNameGoogleApplicationCredentials)
+		}()
+
+		var (
+			ctx         = context.Background()
+			ngClient, _ = bigquery.NewClient(ctx, testProjectNotFound)
+		)
+
+		if _, _, err := getAllTables(ctx, ngClient, testProjectNotFound, testDatasetNotFound, false); err == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_applyWildcards(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		var tables []*bigquery.Table
+		for _, tableID := range []string{"events_20201102", "events_", "events_intraday_20201102", "events_20201101", "users"} {
+			tables = append(tables, &bigquery.Table{ProjectID: testPublicDataProjectID, DatasetID: testSupportedDatasetID, TableID: tableID})
+		}
+		applied, opts := applyWildcards(tables, Options{Wildcards: []string{"events_", "events_intraday_", "sessions_"}})
+
+		var tableIDs []string
+		for _, table := range applied {
+			tableIDs = append(tableIDs, table.TableID)
+		}
+		// NOTE(djeeno): "events_" itself is not the shard, and the shards of "events_intraday_" are not of "events_".
+		if want := []string{"events_", "events_*", "events_intraday_*", "users"}; !reflect.DeepEqual(tableIDs, want) {
+			t.Errorf("applyWildcards: want=%v current=%v", want, tableIDs)
+		}
+		var shardIDs []string
+		for _, shard := range opts.wildcardShards["events_*"] {
+			shardIDs = append(shardIDs, shard.TableID)
+		}
+		if want := []string{"events_20201102", "events_20201101"}; !reflect.DeepEqual(shardIDs, want) {
+			t.Errorf("applyWildcards: want=%v current=%v", want, shardIDs)
+		}
+		if applied[1].ProjectID != testPublicDataProjectID || applied[1].DatasetID != testSupportedDatasetID {
+			t.Errorf("applyWildcards: unexpected wildcard table: %+v", applied[1])
+		}
+
+		structName, err := tableStructName(applied[1], opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if structName != "Events" || opts.NameMapper("events_*", wildcardTableSuffixColumn) != "TableSuffix" || opts.NameMapper("events_", "") != "Events_" {
+			t.Errorf("applyWildcards: unexpected names: %s", structName)
+		}
+	})
+
+	t.Run("正常系_no_wildcards", func(t *testing.T) {
+		tables := []*bigquery.Table{{TableID: "events_20201101"}}
+		applied, opts := applyWildcards(tables, Options{})
+		if !reflect.DeepEqual(applied, tables) || opts.wildcardShards != nil || opts.NameMapper != nil {
+			t.Errorf("applyWildcards: unexpected result: %v %+v", applied, opts)
+		}
+	})
+}
+
+func Test_mergeWildcardSchemas(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		merged, err := mergeWildcardSchemas([]bigquery.Schema{
+			{
+				{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+				{Name: "added", Type: bigquery.StringFieldType, Required: true, Description: "added"},
+				{Name: "record", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{{Name: "a", Type: bigquery.StringFieldType, Required: true}}},
+			},
+			{
+				{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+				{Name: "removed", Type: bigquery.StringFieldType, Required: true},
+				{Name: "record", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{{Name: "a", Type: bigquery.StringFieldType, Required: true}, {Name: "b", Type: bigquery.IntegerFieldType}}},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := bigquery.Schema{
+			{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+			{Name: "added", Type: bigquery.StringFieldType, Description: "added"},
+			{Name: "record", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{{Name: "a", Type: bigquery.StringFieldType, Required: true}, {Name: "b", Type: bigquery.IntegerFieldType}}},
+			{Name: "removed", Type: bigquery.StringFieldType},
+		}
+		if !reflect.DeepEqual(merged, want) {
+			t.Errorf("mergeWildcardSchemas: want=%+v current=%+v", schemaJSONFields(want), schemaJSONFields(merged))
+		}
+	})
+
+	t.Run("異常系_conflict", func(t *testing.T) {
+		for _, schemas := range [][]bigquery.Schema{
+			{{{Name: "id", Type: bigquery.IntegerFieldType}}, {{Name: "id", Type: bigquery.StringFieldType}}},
+			{{{Name: "ids", Type: bigquery.IntegerFieldType, Repeated: true}}, {{Name: "ids", Type: bigquery.IntegerFieldType}}},
+			{{{Name: "r", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{{Name: "a", Type: bigquery.IntegerFieldType}}}}, {{Name: "r", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{{Name: "a", Type: bigquery.BooleanFieldType}}}}},
+		} {
+			if _, err := mergeWildcardSchemas(schemas); err == nil || !strings.Contains(err.Error(), "conflict") {
+				t.Errorf("mergeWildcardSchemas: want conflict error current=%v", err)
+			}
+		}
+	})
+}
+
+func Test_wildcardMetadata(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		wildcard := &bigquery.Table{ProjectID: testPublicDataProjectID, DatasetID: testSupportedDatasetID, TableID: "events_*"}
+		shards := []*bigquery.Table{{TableID: "events_20201101"}, {TableID: "events_20201102"}}
+		metadata := map[string]*bigquery.TableMetadata{
+			"events_20201101": {CreationTime: time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC), Schema: bigquery.Schema{{Name: "old", Type: bigquery.StringFieldType}}},
+			"events_20201102": {CreationTime: time.Date(2020, 11, 2, 0, 0, 0, 0, time.UTC), Schema: bigquery.Schema{{Name: "id", Type: bigquery.IntegerFieldType, Required: true}}},
+		}
+		opts := Options{NameMapper: wildcardNameMapper(nil)}
+		md, err := wildcardMetadata(context.Background(), nil, wildcard, shards, metadata, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// NOTE(djeeno): The columns of the most recently created shard come first.
+		var names []string
+		for _, column := range md.Schema {
+			names = append(names, column.Name)
+		}
+		if want := []string{"id", "old", wildcardTableSuffixColumn}; !reflect.DeepEqual(names, want) {
+			t.Errorf("wildcardMetadata: want=%v current=%v", want, names)
+		}
+
+		generatedCode, _, err := generateTableMetadataCode(wildcard, md, nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"type Events struct {",
+			"\tTableSuffix string `bigquery:\"_TABLE_SUFFIX\"`\n",
+			"\treturn \"events_*\"\n",
+		} {
+			if !strings.Contains(generatedCode, want) {
+				t.Error("generateTableMetadataCode: want=`" + want + "` current=`" + generatedCode + "`")
 			}
-		)
-		if _, _, err := generateTableSchemaCode(ctx, ngTable); err == nil {
-			t.Error(err)
 		}
 	})
+}
 
-	t.Run("異常系_testProjectNotFound_testNotSupportedDatasetID", func(t *testing.T) {
-		var (
-			ctx = context.Background()
-		)
+func Test_sortTables(t *testing.T) {
+	t.Run("正常系_scrambled", func(t *testing.T) {
+		tables := []*bigquery.Table{{TableID: "stories"}, {TableID: "comments"}, {TableID: "full_201510"}, {TableID: "full"}}
+		sortTables(tables)
+		var structNames []string
+		for _, table := range tables {
+			structName, err := tableStructName(table, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			structNames = append(structNames, structName)
+		}
+		if want := []string{"Comments", "Full", "Full_201510", "Stories"}; !reflect.DeepEqual(structNames, want) {
+			t.Errorf("sortTables: want=%v current=%v", want, structNames)
+		}
+	})
+}
 
-		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
-			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
+func Test_parseStandardSQLType(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		field, err := parseStandardSQLType("ARRAY<STRUCT<id INT64 NOT NULL, `name` STRING(10), tags ARRAY<STRING>, price NUMERIC(10, 2), location STRUCT<point GEOGRAPHY>>>")
+		if err != nil {
+			t.Fatal(err)
 		}
+		want := &bigquery.FieldSchema{Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+			{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+			{Name: "name", Type: bigquery.StringFieldType},
+			{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+			{Name: "price", Type: bigquery.NumericFieldType},
+			{Name: "location", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+				{Name: "point", Type: bigquery.GeographyFieldType},
+			}},
+		}}
+		if !reflect.DeepEqual(field, want) {
+			t.Errorf("parseStandardSQLType: want=%#v current=%#v", want, field)
+		}
+	})
 
-		var (
-			ngClient, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
-			ngTable, _  = ngClient.Dataset(testNotSupportedDatasetID).Tables(ctx).Next()
-		)
+	t.Run("異常系", func(t *testing.T) {
+		for _, dataType := range []string{testEmptyString, "BIGNUMERIC", "JSON", "ARRAY<INT64", "STRUCT<id INT64", "STRING(10", "INT64 INT64"} {
+			if _, err := parseStandardSQLType(dataType); err == nil {
+				t.Error("parseStandardSQLType: want error. dataType=" + dataType)
+			}
+		}
+	})
+}
 
-		ngTable.ProjectID = testProjectNotFound
-		if _, _, err := generateTableSchemaCode(ctx, ngTable); err == nil {
-			t.Error(err)
+func Test_setInformationSchemaDescription(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		md := &bigquery.TableMetadata{Schema: bigquery.Schema{
+			{Name: "user", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+				{Name: "name", Type: bigquery.StringFieldType},
+			}},
+		}}
+		setInformationSchemaDescription(md, testEmptyString, `"users \"table\""`)
+		setInformationSchemaDescription(md, "user", "user record")
+		setInformationSchemaDescription(md, "user.name", "user name")
+		setInformationSchemaDescription(md, "user.not_found", "not found")
+		if md.Description != `users "table"` || md.Schema[0].Description != "user record" || md.Schema[0].Schema[0].Description != "user name" {
+			t.Errorf("setInformationSchemaDescription: unexpected descriptions: %q %q %q", md.Description, md.Schema[0].Description, md.Schema[0].Schema[0].Description)
 		}
 	})
+}
 
-	t.Run("異常系_testPublicDataProjectID_testNotSupportedDatasetID_testSubStrFieldTypeNotSupported", func(t *testing.T) {
-		var (
-			ctx = context.Background()
-		)
+func Test_informationSchemaMetadata(t *testing.T) {
+	t.Run("正常系_disabled", func(t *testing.T) {
+		if metadata := informationSchemaMetadata(context.Background(), nil, testPublicDataProjectID, testSupportedDatasetID, Options{}); metadata != nil {
+			t.Errorf("informationSchemaMetadata: want=nil current=%v", metadata)
+		}
+	})
 
+	t.Run("正常系_"+testPublicDataProjectID+"_"+testSupportedDatasetID, func(t *testing.T) {
 		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
 			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
 		}
 
 		var (
-			ngClient, _     = bigquery.NewClient(ctx, testPublicDataProjectID)
-			ngTableIterator = ngClient.Dataset(testNotSupportedDatasetID).Tables(ctx)
+			ctx       = context.Background()
+			client, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
 		)
 
-		for {
-			table, err := ngTableIterator.Next()
-			if err == iterator.Done {
-				break
-			}
+		metadata, err := getInformationSchemaMetadata(ctx, client, testPublicDataProjectID, testSupportedDatasetID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for tableID, md := range metadata {
+			live, err := client.DatasetInProject(testPublicDataProjectID, testSupportedDatasetID).Table(tableID).Metadata(ctx)
 			if err != nil {
-				t.Error(err)
+				t.Fatal(err)
 			}
-			if _, _, err := generateTableSchemaCode(ctx, table); err != nil {
-				// NOTE(djeeno): "bigquery.FieldType not supported." 以外のエラーが出たら Fail
-				if !strings.Contains(err.Error(), testSubStrFieldTypeNotSupported) {
-					t.Error(err)
-				}
-				// NOTE(djeeno): ここまで来たら、確認したいことは確認済み。
-				// ref. https://github.com/djeeno/bqschema-gen-go/blob/260524ce0ae2dd5bdcbdd57446cdd8c140326ca4/main.go#L212
-				return
+			if !reflect.DeepEqual(md.Schema, live.Schema) {
+				t.Errorf("getInformationSchemaMetadata: schema differs from the metadata. tableID=%s", tableID)
 			}
 		}
 	})
 }
 
-func Test_getAllTables(t *testing.T) {
-	t.Run("正常系_testPublicDataProjectID_testSupportedDatasetID", func(t *testing.T) {
-
-		if os.Getenv(envNameGoogleApplicationCredentials) == "" {
-			t.Skip("WARN: " + envNameGoogleApplicationCredentials + " is not set")
+func Test_isForbidden(t *testing.T) {
+	t.Run("正常系_forbidden", func(t *testing.T) {
+		err := fmt.Errorf("table.Metadata: %w", &googleapi.Error{Code: http.StatusForbidden})
+		if !isForbidden(err) {
+			t.Error(err)
 		}
+	})
 
-		var (
-			ctx         = context.Background()
-			okClient, _ = bigquery.NewClient(ctx, testPublicDataProjectID)
-		)
-
-		if _, err := getAllTables(ctx, okClient, testSupportedDatasetID); err != nil {
+	t.Run("正常系_not_found", func(t *testing.T) {
+		err := fmt.Errorf("table.Metadata: %w", &googleapi.Error{Code: http.StatusNotFound})
+		if isForbidden(err) {
 			t.Error(err)
 		}
 	})
 
-	t.Run("異常系_testProjectNotFound_testDatasetNotFound", func(t *testing.T) {
-
-		backupValue, exist := os.LookupEnv(envNameGoogleApplicationCredentials)
-		_ = os.Setenv(envNameGoogleApplicationCredentials, testGoogleApplicationCredentials)
-		defer func() {
-			if exist {
-				_ = os.Setenv(envNameGoogleApplicationCredentials, backupValue)
-				return
-			}
-			_ = os.Unsetenv(envNameGoogleApplicationCredentials)
-		}()
-
-		var (
-			ctx         = context.Background()
-			ngClient, _ = bigquery.NewClient(ctx, testProjectNotFound)
-		)
-
-		if _, err := getAllTables(ctx, ngClient, testDatasetNotFound); err == nil {
-			t.Error(err)
+	t.Run("正常系_not_googleapi_error", func(t *testing.T) {
+		if isForbidden(errors.New(testSubStrFieldTypeNotSupported)) {
+			t.Error()
 		}
 	})
 }
@@ -395,6 +5269,50 @@ func Test_getOptOrEnvOrDefault(t *testing.T) {
 	})
 }
 
+func Test_getOptOrEnv(t *testing.T) {
+	t.Run("正常系_testOptValue", func(t *testing.T) {
+		v, err := getOptOrEnv(testOptName, testOptValue, testEnvName)
+		if err != nil {
+			t.Error(err)
+		}
+		if v != testOptValue {
+			t.Errorf("getOptOrEnv: want=%s current=%s", testOptValue, v)
+		}
+	})
+
+	t.Run("正常系_testEnvValue", func(t *testing.T) {
+		if err := os.Setenv(testEnvName, testEnvValue); err != nil {
+			t.Error(err)
+		}
+		v, err := getOptOrEnv(testOptName, testEmptyString, testEnvName)
+		if err != nil {
+			t.Error(err)
+		}
+		if v != testEnvValue {
+			t.Errorf("getOptOrEnv: want=%s current=%s", testEnvValue, v)
+		}
+		if err := os.Unsetenv(testEnvName); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("正常系_testEmptyString", func(t *testing.T) {
+		v, err := getOptOrEnv(testOptName, testEmptyString, testEnvName)
+		if err != nil {
+			t.Error(err)
+		}
+		if v != testEmptyString {
+			t.Errorf("getOptOrEnv: want empty current=%s", v)
+		}
+	})
+
+	t.Run("異常系_testEmptyString_all", func(t *testing.T) {
+		if _, err := getOptOrEnv(testEmptyString, testEmptyString, testEmptyString); err == nil {
+			t.Error("getOptOrEnv: expected error")
+		}
+	})
+}
+
 func Test_capitalizeInitial(t *testing.T) {
 	t.Run("正常系_testEmptyString", func(t *testing.T) {
 		if capitalizeInitial(testEmptyString) != testEmptyString {
@@ -409,6 +5327,204 @@ func Test_capitalizeInitial(t *testing.T) {
 	})
 }
 
+func Test_sanitizeIdentifier(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		if sanitized := sanitizeIdentifier("events-v2.日本"); sanitized != "events_v2_日本" {
+			t.Error("sanitizeIdentifier: current=" + sanitized)
+		}
+	})
+}
+
+func Test_exportedIdentifier(t *testing.T) {
+	for s, want := range map[string]string{
+		testEmptyString:    "X",
+		testNotCapitalized: testCapitalized,
+		"UserName":         "UserName",
+		"userName":         "UserName",
+		"ID":               "ID",
+		"_id":              "X_id",
+		"élan":             "Élan",
+		"日本":               "X日本",
+	} {
+		t.Run("正常系_"+s, func(t *testing.T) {
+			if identifier := exportedIdentifier(s); identifier != want {
+				t.Error("exportedIdentifier: want=" + want + " current=" + identifier)
+			}
+		})
+	}
+}
+
+func Test_DefaultNameMapper(t *testing.T) {
+	t.Run("正常系_struct_name", func(t *testing.T) {
+		if name := DefaultNameMapper(testNotCapitalized, testEmptyString); name != testCapitalized {
+			t.Error("DefaultNameMapper: want=" + testCapitalized + " current=" + name)
+		}
+	})
+
+	t.Run("正常系_struct_name_sanitized", func(t *testing.T) {
+		for tableID, want := range map[string]string{
+			"123_events": "X123_events",
+			"events-v2":  "Events_v2",
+			"events$0":   "Events_0",
+		} {
+			if name := DefaultNameMapper(tableID, testEmptyString); name != want || !isExportedIdentifier(name) {
+				t.Error("DefaultNameMapper: want=" + want + " current=" + name)
+			}
+		}
+	})
+
+	t.Run("正常系_field_name", func(t *testing.T) {
+		if name := DefaultNameMapper(testTableID, "_id"); name != "X_id" {
+			t.Error("DefaultNameMapper: want=X_id current=" + name)
+		}
+	})
+}
+
+func Test_isExportedIdentifier(t *testing.T) {
+	for s, want := range map[string]bool{
+		testCapitalized:    true,
+		"X_id":             true,
+		testNotCapitalized: false,
+		testEmptyString:    false,
+		"_id":              false,
+		"A-b":              false,
+		"1a":               false,
+	} {
+		t.Run("正常系_"+s, func(t *testing.T) {
+			if isExportedIdentifier(s) != want {
+				t.Error("isExportedIdentifier: " + s)
+			}
+		})
+	}
+}
+
+func Test_uniqueIdentifier(t *testing.T) {
+	t.Run("正常系_collision", func(t *testing.T) {
+		used := make(map[string]bool)
+		for _, want := range []string{testCapitalized, testCapitalized + "_2", testCapitalized + "_3"} {
+			if identifier := uniqueIdentifier(testCapitalized, used); identifier != want {
+				t.Error("uniqueIdentifier: want=" + want + " current=" + identifier)
+			}
+		}
+	})
+}
+
+func Test_logln(t *testing.T) {
+	t.Run("正常系_logJSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		backupLogJSON, backupLogWriter := logJSON, logWriter
+		logJSON, logWriter = true, &buf
+		defer func() {
+			logJSON, logWriter = backupLogJSON, backupLogWriter
+		}()
+
+		warnTableln(testTableID, "test")
+		infoln("test")
+
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("logln: unexpected lines: %q", lines)
+		}
+		var line logLine
+		if err := json.Unmarshal([]byte(lines[0]), &line); err != nil {
+			t.Fatal(err)
+		}
+		if line.Level != "WARN" || line.Table != testTableID || line.Message != "test" || line.Time == "" {
+			t.Errorf("logln: unexpected line: %+v", line)
+		}
+		if strings.Contains(lines[1], `"table"`) {
+			t.Errorf("logln: want no table: %s", lines[1])
+		}
+	})
+}
+
+func Test_traceln(t *testing.T) {
+	var buf bytes.Buffer
+	backupLogJSON, backupLogWriter, backupTrace := logJSON, logWriter, trace
+	logJSON, logWriter = true, &buf
+	defer func() {
+		logJSON, logWriter, trace = backupLogJSON, backupLogWriter, backupTrace
+	}()
+
+	t.Run("正常系_trace", func(t *testing.T) {
+		buf.Reset()
+		trace = true
+		traceln(testTableID, "table.Metadata", time.Now().Add(-time.Second))
+
+		var line logLine
+		if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+			t.Fatal(err)
+		}
+		if line.Level != "DEBUG" || line.Table != testTableID || !strings.HasPrefix(line.Message, "table.Metadata: took 1") {
+			t.Errorf("traceln: unexpected line: %+v", line)
+		}
+	})
+
+	t.Run("正常系_no_trace", func(t *testing.T) {
+		buf.Reset()
+		trace = false
+		traceln(testTableID, "table.Metadata", time.Now())
+		if buf.Len() != 0 {
+			t.Errorf("traceln: want no log current=%s", buf.String())
+		}
+	})
+}
+
+func Test_printProgress(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		var buf bytes.Buffer
+		backupProgressWriter := progressWriter
+		progressWriter = &buf
+		defer func() {
+			progressWriter = backupProgressWriter
+		}()
+
+		printProgress(12, 300, "generating", "orders")
+		if want := "\r\x1b[K\x1b[36m[12/300]\x1b[0m generating orders"; buf.String() != want {
+			t.Errorf("printProgress: want=%q current=%q", want, buf.String())
+		}
+
+		// NOTE(djeeno): The line of the progress is erased once before the log.
+		buf.Reset()
+		infoln("test")
+		clearProgress()
+		if want := "\r\x1b[K"; buf.String() != want {
+			t.Errorf("logln: want=%q current=%q", want, buf.String())
+		}
+	})
+
+	t.Run("正常系_no_progressWriter", func(t *testing.T) {
+		printProgress(1, 1, "generating", "orders")
+		clearProgress()
+	})
+}
+
+func Test_isTerminal(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "file")
+	if err := writeGeneratedCode(filePath, testFileMode, []byte(testCapitalized)); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if isTerminal(file) {
+		t.Error("isTerminal: want=false for the file")
+	}
+
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+
+	if isTerminal(devNull) {
+		t.Errorf("isTerminal: want=false for %s", os.DevNull)
+	}
+}
+
 func Test_infoln(t *testing.T) {
 	infoln("test")
 }
@@ -449,7 +5565,7 @@ func Test_bigqueryFieldTypeToGoType(t *testing.T) {
 			bigquery.FloatFieldType:     reflect.Float64.String(),
 			bigquery.BooleanFieldType:   reflect.Bool.String(),
 			bigquery.TimestampFieldType: typeOfGoTime.String(),
-			// TODO(djeeno): support bigquery.RecordFieldType
+			// NOTE(djeeno): bigquery.RecordFieldType is generated by generateStructCode
 			//bigquery.RecordFieldType: "",
 			bigquery.DateFieldType:      typeOfDate.String(),
 			bigquery.TimeFieldType:      typeOfTime.String(),
@@ -488,3 +5604,183 @@ func Test_bigqueryFieldTypeToGoType(t *testing.T) {
 		}
 	})
 }
+
+func Test_writeMappingReport(t *testing.T) {
+	report := &mappingReport{
+		columns: []mappedColumn{
+			{table: "p.d.users", column: "id", structName: "Users", field: "Id", goType: "uuid.UUID", note: "overridden"},
+			{table: "p.d.users", column: "name", structName: "Users", field: "Name", goType: "string"},
+		},
+		excluded: []string{"p.d.users.payload"},
+		skipped:  []string{"p.d.orders"},
+	}
+
+	t.Run("正常系_md", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "report.md")
+		if err := writeMappingReport(filePath, testFileMode, report); err != nil {
+			t.Fatal(err)
+		}
+		content, err := readFile(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"| `p.d.users` | `id` | Users | Id | `uuid.UUID` | overridden |\n",
+			"| `p.d.users` | `name` | Users | Name | `string` |  |\n",
+			"\n## Excluded columns\n\n- `p.d.users.payload`\n",
+			"\n## Skipped tables\n\n- `p.d.orders`\n",
+		} {
+			if !strings.Contains(string(content), want) {
+				t.Error("writeMappingReport: want=`" + want + "` current=`" + string(content) + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_txt", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "report.txt")
+		if err := writeMappingReport(filePath, testFileMode, report); err != nil {
+			t.Fatal(err)
+		}
+		content, err := readFile(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "TABLE      COLUMN  STRUCT  FIELD  GO TYPE    NOTE\n" +
+			"p.d.users  id      Users   Id     uuid.UUID  overridden\n" +
+			"p.d.users  name    Users   Name   string     \n" +
+			"\nEXCLUDED COLUMNS\np.d.users.payload\n" +
+			"\nSKIPPED TABLES\np.d.orders\n"
+		if string(content) != want {
+			t.Error("writeMappingReport: want=`" + want + "` current=`" + string(content) + "`")
+		}
+	})
+
+	t.Run("異常系", func(t *testing.T) {
+		// NOTE(djeeno): The path is the directory.
+		if err := writeMappingReport(t.TempDir(), testFileMode, report); err == nil {
+			t.Error("writeMappingReport: expected error")
+		}
+	})
+}
+
+func Test_writeFieldTypeList(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeFieldTypeList(&buf); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if want := 1 + len(bigqueryFieldTypes) + len(unsupportedFieldTypes); len(lines) != want {
+			t.Errorf("writeFieldTypeList: want=%d lines current=%d lines: %s", want, len(lines), buf.String())
+		}
+		for _, want := range []string{
+			"INTEGER        int64            bigquery.NullInt64\n",
+			"RECORD         (nested struct)  (nested struct)\n",
+			"RANGE          (not supported)  (not supported)\n",
+		} {
+			if !strings.Contains(buf.String(), want) {
+				t.Error("writeFieldTypeList: want=`" + want + "` current=`" + buf.String() + "`")
+			}
+		}
+	})
+
+	t.Run("正常系_unsupportedFieldTypes", func(t *testing.T) {
+		for _, fieldType := range unsupportedFieldTypes {
+			if _, _, err := bigqueryFieldTypeToGoType(fieldType); err == nil {
+				t.Errorf("bigqueryFieldTypeToGoType: %s is supported", fieldType)
+			}
+		}
+	})
+}
+
+func Test_resolveGoTypeOfFieldType(t *testing.T) {
+	t.Run("正常系_memoized", func(t *testing.T) {
+		for _, bigqueryFieldType := range append(bigqueryFieldTypes, testNotSupportedFieldType) {
+			goType, pkg, err := bigqueryFieldTypeToGoType(bigqueryFieldType)
+			wantGoType, wantPkg, wantErr := resolveGoTypeOfFieldType(bigqueryFieldType)
+			if goType != wantGoType || pkg != wantPkg || (err == nil) != (wantErr == nil) {
+				t.Errorf("bigqueryFieldTypeToGoType: bigquery.FieldType=%s goType=%s pkg=%s err=%v", bigqueryFieldType, goType, pkg, err)
+			}
+		}
+	})
+}
+
+// testWideSchema is the schema that has 1000 columns of bigqueryFieldTypes except RECORD.
+var testWideSchema = func() (schema bigquery.Schema) {
+	for i := 0; len(schema) < 1000; i++ {
+		if fieldType := bigqueryFieldTypes[i%len(bigqueryFieldTypes)]; fieldType != bigquery.RecordFieldType {
+			schema = append(schema, &bigquery.FieldSchema{Name: "column_" + strconv.Itoa(i), Type: fieldType, Required: true})
+		}
+	}
+	return schema
+}()
+
+func Benchmark_bigqueryFieldTypeToGoType(b *testing.B) {
+	b.Run("memoized", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, schema := range testWideSchema {
+				_, _, _ = bigqueryFieldTypeToGoType(schema.Type)
+			}
+		}
+	})
+
+	b.Run("resolveGoTypeOfFieldType", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, schema := range testWideSchema {
+				_, _, _ = resolveGoTypeOfFieldType(schema.Type)
+			}
+		}
+	})
+}
+
+func Benchmark_generateTableMetadataCode(b *testing.B) {
+	md := &bigquery.TableMetadata{Schema: testWideSchema}
+	for i := 0; i < b.N; i++ {
+		if _, _, err := generateTableMetadataCode(testTable, md, nil, Options{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Test_bigqueryNullableFieldTypeToGoType(t *testing.T) {
+	var (
+		supportedBigqueryFieldTypes = map[bigquery.FieldType][2]string{
+			bigquery.StringFieldType:    {typeOfNullString.String(), testEmptyString},
+			bigquery.BytesFieldType:     {typeOfByteSlice.String(), tagOptionNullable},
+			bigquery.IntegerFieldType:   {typeOfNullInt64.String(), testEmptyString},
+			bigquery.FloatFieldType:     {typeOfNullFloat64.String(), testEmptyString},
+			bigquery.BooleanFieldType:   {typeOfNullBool.String(), testEmptyString},
+			bigquery.TimestampFieldType: {typeOfNullTimestamp.String(), testEmptyString},
+			bigquery.DateFieldType:      {typeOfNullDate.String(), testEmptyString},
+			bigquery.TimeFieldType:      {typeOfNullTime.String(), testEmptyString},
+			bigquery.DateTimeFieldType:  {typeOfNullDateTime.String(), testEmptyString},
+			bigquery.NumericFieldType:   {typeOfRat.String(), tagOptionNullable},
+			bigquery.GeographyFieldType: {typeOfNullGeography.String(), testEmptyString},
+		}
+
+		unsupportedBigqueryFieldTypes = []bigquery.FieldType{
+			bigquery.RecordFieldType,
+			bigquery.FieldType(testNotSupportedFieldType),
+		}
+	)
+
+	t.Run("正常系_supportedBigqueryFieldTypes", func(t *testing.T) {
+		for bigqueryFieldType, want := range supportedBigqueryFieldTypes {
+			goType, _, tagOption, err := bigqueryNullableFieldTypeToGoType(bigqueryFieldType)
+			if err != nil {
+				t.Error(err)
+			}
+			if goType != want[0] || tagOption != want[1] {
+				t.Error("bigqueryNullableFieldTypeToGoType: want=" + want[0] + "," + want[1] + " current=" + goType + "," + tagOption)
+			}
+		}
+	})
+
+	t.Run("異常系_unsupportedBigqueryFieldTypes", func(t *testing.T) {
+		for _, bigqueryFieldType := range unsupportedBigqueryFieldTypes {
+			if _, _, _, err := bigqueryNullableFieldTypeToGoType(bigqueryFieldType); err == nil {
+				t.Error(err)
+			}
+		}
+	})
+}